@@ -0,0 +1,21 @@
+package stratumclient
+
+// StratumAPI is the API call interface of Client, for code which
+// wants to depend on an interface rather than the client, and be
+// tested with a fake like stratumclienttest.Fake instead of a server.
+//
+//	func countHosts(api stratumclient.StratumAPI) (int, error) {
+//		var hosts []*Host
+//		err := api.Get("host/?select=id", &hosts)
+//		return len(hosts), err
+//	}
+type StratumAPI interface {
+	Get(query string, resp interface{}, opts ...CallOption) error
+	Post(query string, post, resp interface{}, opts ...CallOption) error
+	Put(query string, post, resp interface{}, opts ...CallOption) error
+	Delete(query string, post, resp interface{}, opts ...CallOption) error
+	Call(method, query string, data interface{}, opts ...CallOption) ([]byte, error)
+	Unmarshal(method, query string, data, resp interface{}, opts ...CallOption) error
+}
+
+var _ StratumAPI = (*Client)(nil)
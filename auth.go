@@ -0,0 +1,202 @@
+package stratumclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthMethod authenticates the login request the client sends to get
+// its bearer token, for deployments fronting the login with single
+// sign-on. Name identifies the principal logged in as, and keys the
+// token in a TokenStore.
+type AuthMethod interface {
+	Name() string
+	Authorize(req *http.Request) error
+}
+
+// authMethod returns the authentication method of the client: Auth,
+// then OIDC, then Basic authentication with Username and Password.
+// It returns nil if the client has no credentials to log in with.
+func (c *Client) authMethod() AuthMethod {
+	switch {
+	case c.Auth != nil:
+		return c.Auth
+	case c.OIDC != nil:
+		return c.OIDC
+	case c.Username != "" && c.Password != "":
+		return &BasicAuth{Username: c.Username, Password: c.Password}
+	case c.Username != "" && c.passwordProvider() != nil:
+		return &BasicAuth{Username: c.Username, Provider: c.passwordProvider()}
+	}
+
+	return nil
+}
+
+// BasicAuth logs in with a username and password, which is what the
+// client does when Auth and OIDC are unset. If Password is "", it is
+// got from Provider for each login and not kept.
+type BasicAuth struct {
+	Username string
+	Password string
+	Provider SecretProvider
+}
+
+// Name implements AuthMethod.
+func (b *BasicAuth) Name() string {
+	return b.Username
+}
+
+// Authorize implements AuthMethod.
+func (b *BasicAuth) Authorize(req *http.Request) error {
+	password := b.Password
+	if password == "" && b.Provider != nil {
+		var err error
+		if password, err = b.Provider.Secret(); err != nil {
+			return fmt.Errorf("password: %v", err)
+		}
+	}
+
+	// wipe the copy of the credentials we own once encoded
+	creds := make([]byte, 0, len(b.Username)+1+len(password))
+	creds = append(append(append(creds, b.Username...), ':'), password...)
+	defer clear(creds)
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(creds))
+
+	return nil
+}
+
+// OIDCClientCredentials logs in with an access token from an OpenID
+// Connect provider, got with the client credentials flow. The access
+// token is reused until it expires. HTTPClient is used to reach the
+// provider, and defaults to http.DefaultClient.
+type OIDCClientCredentials struct {
+	TokenURL     string       `yaml:"tokenURL" json:"token_url"`
+	ClientID     string       `yaml:"clientID" json:"client_id"`
+	ClientSecret string       `yaml:"clientSecret" json:"client_secret"`
+	Scopes       []string     `yaml:"scopes" json:"scopes"`
+	HTTPClient   *http.Client `yaml:"-" json:"-"`
+
+	mu         sync.Mutex
+	token      string
+	validUntil time.Time
+}
+
+// Name implements AuthMethod.
+func (o *OIDCClientCredentials) Name() string {
+	return o.ClientID
+}
+
+// Authorize implements AuthMethod.
+func (o *OIDCClientCredentials) Authorize(req *http.Request) error {
+	token, err := o.accessToken(req)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return nil
+}
+
+// LogValue implements slog.LogValuer, so the credentials can be
+// logged without revealing the client secret.
+func (o *OIDCClientCredentials) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token_url", o.TokenURL),
+		slog.String("client_id", o.ClientID),
+		slog.String("client_secret", redacted),
+	)
+}
+
+// accessToken returns a valid access token from the provider,
+// requesting a new one if the current one has expired.
+func (o *OIDCClientCredentials) accessToken(req *http.Request) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token != "" && time.Now().Before(o.validUntil) {
+		return o.token, nil
+	}
+	if o.TokenURL == "" || o.ClientID == "" {
+		return "", fmt.Errorf("missing: OIDC TokenURL or ClientID")
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	treq, err := http.NewRequestWithContext(req.Context(), "POST", o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	treq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	treq.Header.Set("Accept", "application/json")
+	treq.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(treq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc token: %s: %s", resp.Status, errorSummary(body))
+	}
+
+	var tresp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tresp); err != nil {
+		return "", fmt.Errorf("oidc token: %v", err)
+	}
+	if tresp.AccessToken == "" {
+		return "", fmt.Errorf("oidc token: missing: access_token")
+	}
+
+	o.token = tresp.AccessToken
+	o.validUntil = time.Now().Add(time.Duration(tresp.ExpiresIn) * time.Second)
+
+	return o.token, nil
+}
+
+// Negotiate logs in with SPNEGO, as used by Kerberos single sign-on.
+// Token returns the base64 encoded SPNEGO token for the service
+// principal of host, like HTTP/stratum.example.com, and is typically
+// backed by a Kerberos library holding the credentials of Principal.
+type Negotiate struct {
+	Principal string
+	Token     func(host string) (string, error)
+}
+
+// Name implements AuthMethod.
+func (n *Negotiate) Name() string {
+	return n.Principal
+}
+
+// Authorize implements AuthMethod.
+func (n *Negotiate) Authorize(req *http.Request) error {
+	if n.Token == nil {
+		return fmt.Errorf("missing: Negotiate Token")
+	}
+	token, err := n.Token(req.URL.Hostname())
+	if err != nil {
+		return fmt.Errorf("negotiate: %v", err)
+	}
+	req.Header.Set("Authorization", "Negotiate "+token)
+
+	return nil
+}
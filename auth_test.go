@@ -0,0 +1,63 @@
+package stratumclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAuthMethods(t *testing.T) {
+	var issued int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "stratum-sync" || secret != "s3cret" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+		atomic.AddInt32(&issued, 1)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": "idp-token", "expires_in": 300})
+	}))
+	t.Cleanup(idp.Close)
+
+	var logins []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			logins = append(logins, r.Header.Get("Authorization"))
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+
+	oidc := &OIDCClientCredentials{TokenURL: idp.URL, ClientID: "stratum-sync", ClientSecret: "s3cret", Scopes: []string{"stratum"}}
+	for _, tt := range []struct {
+		cl   *Client
+		want string
+	}{
+		{&Client{Username: "user", Password: "secret"}, "Basic dXNlcjpzZWNyZXQ="},
+		{&Client{OIDC: oidc}, "Bearer idp-token"},
+		{&Client{OIDC: oidc}, "Bearer idp-token"},
+		{&Client{Auth: &Negotiate{Principal: "svc", Token: func(host string) (string, error) {
+			return "spnego-" + host, nil
+		}}}, "Negotiate spnego-127.0.0.1"},
+	} {
+		logins = nil
+		tt.cl.BaseURL = srv.URL + "/stratum/v1"
+		if err := tt.cl.Open(); err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if len(logins) != 1 || logins[0] != tt.want {
+			t.Errorf("login: got %v, want %s", logins, tt.want)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("OIDC tokens issued: got %d, want 1", issued)
+	}
+
+	bad := &Client{BaseURL: srv.URL + "/stratum/v1", OIDC: &OIDCClientCredentials{TokenURL: idp.URL, ClientID: "other"}}
+	if err := bad.Open(); err == nil {
+		t.Error("open with rejected OIDC client: expected error")
+	}
+}
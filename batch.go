@@ -0,0 +1,93 @@
+package stratumclient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Operation holds a single API call in a batch. The response is
+// unmarshalled into Resp if set, and the outcome of the call is
+// stored in Err. Options are call options of this operation only,
+// like CaptureStatus or WithIdempotencyKey, given after the call
+// options of the batch.
+type Operation struct {
+	Method  string
+	Query   string
+	Data    interface{}
+	Resp    interface{}
+	Options []CallOption
+	Err     error
+}
+
+// Batch performs the operations concurrently with at most
+// parallelism calls in flight, sharing the client's token. The
+// Stratum API has no bulk endpoint, so each operation is sent as a
+// separate request and the operations may complete in any order.
+// Each operation's outcome is stored in its Err field. The call
+// options opts apply to every operation, so options capturing the
+// outcome of a call, like CaptureStatus, CaptureHeaders and
+// DecodeInto, or giving it an idempotency key, are rejected and must
+// be given per Operation instead. The function returns the joined
+// errors of the failed operations, or nil if all succeeded.
+func (c *Client) Batch(ops []*Operation, parallelism int, opts ...CallOption) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	o := newCallOptions(opts)
+	if o.status != nil || o.headers != nil || o.meta != nil || len(o.decodeTo) > 0 ||
+		o.idempotencyKey != "" || o.header.Get(IdempotencyKeyHeader) != "" {
+		return fmt.Errorf("batch options can't capture results or set idempotency keys, give them per Operation")
+	}
+
+	// log in up front, so the workers don't race to refresh the token
+	if _, err := c.AccessToken(); err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for _, op := range ops {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(op *Operation) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			op.Err = c.Unmarshal(op.Method, op.Query, op.Data, op.Resp, append(opts[:len(opts):len(opts)], op.Options...)...)
+		}(op)
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, op := range ops {
+		if op.Err != nil {
+			errs = append(errs, fmt.Errorf("operation %d: %s %s: %w", i+1, op.Method, op.Query, op.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// MultiGet performs the GET queries concurrently like Batch, with at
+// most parallelism calls in flight, unmarshalling the response of
+// each query into the destination at the same index, for pages
+// needing many queries at once. A nil destination discards the
+// response. The call options apply to every query, as with Batch.
+// The function returns the joined errors of the failed
+// queries, or nil if all succeeded.
+//
+//	var platforms []*Platform
+//	var hosts []*Host
+//	err := c.MultiGet([]string{"platform/", "host/"}, []interface{}{&platforms, &hosts}, 4)
+func (c *Client) MultiGet(queries []string, dests []interface{}, parallelism int, opts ...CallOption) error {
+	if len(queries) != len(dests) {
+		return fmt.Errorf("got %d queries but %d destinations", len(queries), len(dests))
+	}
+
+	ops := make([]*Operation, len(queries))
+	for i, query := range queries {
+		ops[i] = &Operation{Method: "GET", Query: query, Resp: dests[i]}
+	}
+
+	return c.Batch(ops, parallelism, opts...)
+}
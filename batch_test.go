@@ -0,0 +1,138 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	var inflight, peak int32
+	var mu sync.Mutex
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		mu.Lock()
+		if n > peak {
+			peak = n
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inflight, -1)
+
+		if r.URL.Query().Get("where") == "id=3" {
+			writeJSON(w, http.StatusNotFound, &ErrorResponse{Message: "no such row"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []map[string]string{{"where": r.URL.Query().Get("where")}})
+	})
+
+	var ops []*Operation
+	for i := 1; i <= 8; i++ {
+		var resp []map[string]string
+		ops = append(ops, &Operation{Method: "PUT", Query: fmt.Sprintf("host/?where=id=%d", i), Data: map[string]int{"rack": i}, Resp: &resp})
+	}
+
+	err := cl.Batch(ops, 3)
+	if err == nil || !IsNotFound(err) {
+		t.Fatalf("batch: got %v, want not found error", err)
+	}
+	if peak > 3 {
+		t.Errorf("peak parallelism: got %d, want <= 3", peak)
+	}
+	for i, op := range ops {
+		if (i == 2) != (op.Err != nil) {
+			t.Errorf("operation %d: err %v", i+1, op.Err)
+		}
+		if op.Err == nil {
+			resp := *op.Resp.(*[]map[string]string)
+			if resp[0]["where"] != fmt.Sprintf("id=%d", i+1) {
+				t.Errorf("operation %d: response %v", i+1, resp)
+			}
+		}
+	}
+}
+
+func TestMultiGet(t *testing.T) {
+	var logins int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			atomic.AddInt32(&logins, 1)
+		}
+		if strings.Contains(r.URL.Path, "missing") {
+			writeJSON(w, http.StatusNotFound, &ErrorResponse{Message: "no such table"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []testPlatform{{ID: 1, Name: r.URL.Path}})
+	})
+
+	var platforms, hosts []*testPlatform
+	var missing []*testPlatform
+	err := cl.MultiGet([]string{"platform/", "missing/", "host/", "other/"}, []interface{}{&platforms, &missing, &hosts, nil}, 2)
+	if err == nil || !strings.Contains(err.Error(), "operation 2: GET missing/") || StatusCode(err) != http.StatusNotFound {
+		t.Fatalf("multi get: got %v", err)
+	}
+	if len(platforms) != 1 || !strings.HasSuffix(platforms[0].Name, "/platform/") || len(hosts) != 1 || !strings.HasSuffix(hosts[0].Name, "/host/") {
+		t.Errorf("results: got %+v and %+v", platforms, hosts)
+	}
+	if logins != 0 {
+		t.Errorf("calls without the shared token: %d", logins)
+	}
+
+	if err := cl.MultiGet([]string{"platform/"}, nil, 2); err == nil {
+		t.Error("mismatched destinations: no error")
+	}
+}
+
+func TestBatchCallOptions(t *testing.T) {
+	var mu sync.Mutex
+	keys := make(map[string]bool)
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys[r.Header.Get(IdempotencyKeyHeader)] = true
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Query().Get("where"), "=2") {
+			writeJSON(w, http.StatusConflict, &ErrorResponse{Message: "exists"})
+			return
+		}
+		writeJSON(w, http.StatusCreated, []interface{}{})
+	})
+
+	var status int
+	ops := []*Operation{{Method: "GET", Query: "host/"}}
+	if err := cl.Batch(ops, 2, CaptureStatus(&status)); err == nil {
+		t.Fatal("shared CaptureStatus: expected error")
+	}
+	if err := cl.Batch(ops, 2, WithIdempotencyKey("job")); err == nil {
+		t.Fatal("shared idempotency key: expected error")
+	}
+
+	statuses := make([]int, 8)
+	ops = nil
+	for i := range statuses {
+		ops = append(ops, &Operation{
+			Method:  "POST",
+			Query:   fmt.Sprintf("host/?where=id=%d", i),
+			Data:    map[string]int{"id": i},
+			Options: []CallOption{CaptureStatus(&statuses[i]), WithIdempotencyKey(fmt.Sprint("job-", i))},
+		})
+	}
+	if err := cl.Batch(ops, 4, WithTimeout(time.Second)); !IsConflict(err) {
+		t.Fatalf("batch: got %v, want conflict", err)
+	}
+	for i, s := range statuses {
+		want := http.StatusCreated
+		if i == 2 {
+			want = http.StatusConflict
+		}
+		if s != want {
+			t.Errorf("operation %d: got status %d, want %d", i, s, want)
+		}
+	}
+	if len(keys) != len(statuses) {
+		t.Errorf("idempotency keys: got %d distinct, want %d", len(keys), len(statuses))
+	}
+}
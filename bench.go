@@ -0,0 +1,110 @@
+package stratumclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchResult holds the latency distribution of a benchmarked query.
+// The latencies are measured from sending the request until the
+// response headers arrive, which is dominated by the time the server
+// spends evaluating the query. Errors counts the failed calls, which
+// are left out of the distribution.
+type BenchResult struct {
+	Query  string
+	Calls  int
+	Errors int
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+// Stringer function for BenchResult fmt.String() compliant.
+func (r *BenchResult) String() string {
+	return fmt.Sprintf("%s: %d calls, %d errors, min %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s",
+		r.Query, r.Calls, r.Errors, r.Min, r.P50, r.P90, r.P95, r.P99, r.Max)
+}
+
+// BenchQuery sends the GET query n times with at most concurrency
+// calls in flight and returns the latency distribution, so where
+// clause formulations can be compared before they are used in tight
+// loops. The calls bypass the client Cache and are not retried. The
+// function returns the result and an error if n is not positive or
+// all calls failed.
+func (c *Client) BenchQuery(query string, n, concurrency int, opts ...CallOption) (*BenchResult, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid call count: %d", n)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	opts = append(opts, WithRetryPolicy(&RetryPolicy{MaxAttempts: 1}))
+
+	latencies := make([]time.Duration, n)
+	errs := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			resp, err := c.do("GET", query, nil, newCallOptions(opts))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			latencies[i] = time.Since(start)
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	r := &BenchResult{Query: query, Calls: n}
+	var ok []time.Duration
+	var total time.Duration
+	for i, err := range errs {
+		if err != nil {
+			r.Errors++
+			continue
+		}
+		ok = append(ok, latencies[i])
+		total += latencies[i]
+	}
+	if len(ok) == 0 {
+		return r, fmt.Errorf("bench %s: all %d calls failed: %w", query, n, errs[0])
+	}
+
+	sort.Slice(ok, func(i, j int) bool { return ok[i] < ok[j] })
+	r.Min = ok[0]
+	r.Max = ok[len(ok)-1]
+	r.Mean = total / time.Duration(len(ok))
+	r.P50 = percentile(ok, 50)
+	r.P90 = percentile(ok, 90)
+	r.P95 = percentile(ok, 95)
+	r.P99 = percentile(ok, 99)
+
+	return r, nil
+}
+
+// percentile returns the p-th percentile of the sorted durations by
+// the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,45 @@
+package stratumclient
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBenchQuery(t *testing.T) {
+	var calls int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 10 {
+			writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.Cache = NewMemoryCache(time.Minute)
+
+	r, err := cl.BenchQuery("host/?where=name~db", 20, 4)
+	if err != nil {
+		t.Fatalf("bench: %v", err)
+	}
+	if calls != 20 || r.Calls != 20 || r.Errors != 1 {
+		t.Errorf("calls: got %d sent, %+v", calls, r)
+	}
+	if r.Min < 5*time.Millisecond || r.Min > r.P50 || r.P50 > r.P99 || r.P99 > r.Max {
+		t.Errorf("distribution: got %s", r)
+	}
+
+	if _, err := cl.BenchQuery("host/", 0, 1); err == nil {
+		t.Error("bench with no calls: expected error")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for p, want := range map[int]time.Duration{50: 5, 90: 9, 95: 10, 99: 10, 1: 1} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("p%d: got %d, want %d", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package stratumclient
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Annotation tells who last changed a field of a row, and when. User
+// and Time are empty if the history holds no change of the field.
+type Annotation struct {
+	Value     interface{} `json:"value"`
+	User      string      `json:"user,omitempty"`
+	Time      time.Time   `json:"time"`
+	Operation string      `json:"operation,omitempty"`
+}
+
+// Blame holds the current state of a row with an annotation per
+// field.
+type Blame struct {
+	Table  string                 `json:"table"`
+	ID     string                 `json:"id"`
+	Fields map[string]*Annotation `json:"fields"`
+}
+
+// Blame returns the current fields of the row of table with the given
+// id, each annotated with the last change of the field found in the
+// row History. The function returns the blame and an error.
+func (c *Client) Blame(table string, id interface{}, opts ...CallOption) (*Blame, error) {
+	table = strings.Trim(table, "/")
+	rowID := valueText(id)
+	if rowID == "" {
+		return nil, fmt.Errorf("missing: id")
+	}
+
+	q := &Query{Table: table, Where: &Cond{Column: "id", Op: "=", Value: rowID}}
+	var rows []map[string]interface{}
+	if err := c.Get(q.String(), &rows, opts...); err != nil {
+		return nil, fmt.Errorf("blame %s %s: %w", table, rowID, err)
+	}
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("blame %s %s: got %d rows, want 1", table, rowID, len(rows))
+	}
+
+	changes, err := c.History(table, rowID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Blame{Table: table, ID: rowID, Fields: make(map[string]*Annotation)}
+	for field, v := range rows[0] {
+		b.Fields[field] = &Annotation{Value: v}
+	}
+
+	var prev map[string]interface{}
+	for _, ch := range changes {
+		for field, a := range b.Fields {
+			v, ok := ch.Data[field]
+			if !ok {
+				continue
+			}
+			if old, ok := prev[field]; ok && valueText(old) == valueText(v) {
+				continue
+			}
+			a.User = ch.User
+			a.Time = ch.Time
+			a.Operation = ch.Operation
+		}
+		prev = ch.Data
+	}
+
+	return b, nil
+}
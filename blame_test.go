@@ -0,0 +1,35 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBlame(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "//stratum/v1/host/":
+			writeJSON(w, http.StatusOK, []map[string]interface{}{{"id": 7, "name": "db1", "os": "linux", "note": "x"}})
+		case "//stratum/v1/host_history/":
+			writeJSON(w, http.StatusOK, []map[string]interface{}{
+				{"changed_at": "2026-01-01T00:00:00Z", "changed_by": "alice", "operation": "insert", "data": map[string]interface{}{"id": 7, "name": "db0", "os": "linux"}},
+				{"changed_at": "2026-02-01T00:00:00Z", "changed_by": "bob", "operation": "update", "data": map[string]interface{}{"id": 7, "name": "db1", "os": "linux"}},
+			})
+		default:
+			t.Errorf("path: got %q", r.URL.Path)
+		}
+	})
+
+	b, err := cl.Blame("host", 7)
+	if err != nil {
+		t.Fatalf("blame: %v", err)
+	}
+	for field, want := range map[string]string{"id": "alice", "name": "bob", "os": "alice", "note": ""} {
+		if got := b.Fields[field].User; got != want {
+			t.Errorf("%s: got user %q, want %q", field, got, want)
+		}
+	}
+	if b.Fields["name"].Value != "db1" {
+		t.Errorf("name: got value %v, want db1", b.Fields["name"].Value)
+	}
+}
@@ -0,0 +1,130 @@
+package stratumclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops calls to a failing API server, so dependent
+// services fail fast instead of piling up on timeouts. The breaker
+// opens after Threshold consecutive failures, which are 5xx responses
+// and network errors, and calls fail with ErrCircuitOpen for the
+// Cooldown period. The breaker then lets a single probe call through
+// (half-open), and closes again if it succeeds or reopens if it
+// fails. Zero fields get defaults. A breaker may be shared by several
+// clients of the same server.
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive failures opening the
+	// breaker. Default 5.
+	Threshold int `yaml:"threshold" json:"threshold"`
+	// Cooldown is how long the breaker stays open before a probe
+	// call is let through. Default 30s.
+	Cooldown time.Duration `yaml:"cooldown" json:"cooldown"`
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Breaker states returned by CircuitBreaker.State.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// State returns the state of the breaker: BreakerClosed,
+// BreakerOpen or BreakerHalfOpen.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state()
+}
+
+// state returns the state of the breaker. It must be called with
+// b.mu held.
+func (b *CircuitBreaker) state() string {
+	switch {
+	case b.openedAt.IsZero():
+		return BreakerClosed
+	case time.Since(b.openedAt) < b.cooldown():
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// cooldown returns the cooldown period.
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return 30 * time.Second
+	}
+
+	return b.Cooldown
+}
+
+// allow returns ErrCircuitOpen if a call may not be sent. In the
+// half-open state the first caller becomes the probe.
+func (b *CircuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state() {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+
+	return nil
+}
+
+// record updates the breaker with the outcome of a call. Errors which
+// tell nothing about the server's health, like cancelled calls,
+// leave the failure count as is.
+func (b *CircuitBreaker) record(resp *http.Response, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.probing
+	b.probing = false
+
+	switch {
+	case resp != nil && resp.StatusCode >= 500, resp == nil && isNetworkError(err):
+		b.failures++
+		threshold := b.Threshold
+		if threshold <= 0 {
+			threshold = 5
+		}
+		if probe || b.failures >= threshold {
+			b.openedAt = time.Now()
+		}
+	case resp != nil || err == nil:
+		b.failures = 0
+		b.openedAt = time.Time{}
+	}
+}
+
+// isNetworkError reports whether err is a failure to reach the
+// server, as opposed to a cancelled call.
+func isNetworkError(err error) bool {
+	var uerr *url.Error
+
+	return errors.As(err, &uerr) && !errors.Is(err, context.Canceled)
+}
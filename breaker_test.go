@@ -0,0 +1,58 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var down atomic.Bool
+	var calls atomic.Int32
+	down.Store(true)
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "down"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	b := &CircuitBreaker{Threshold: 2, Cooldown: 50 * time.Millisecond}
+	cl.Breaker = b
+
+	for i := 0; i < 2; i++ {
+		if err := cl.Get("host/", nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("get %d: got %v, want server error", i, err)
+		}
+	}
+	if err := cl.Get("host/", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("get: got %v, want ErrCircuitOpen", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls: got %d, want 2", n)
+	}
+
+	// a failed probe reopens the breaker
+	time.Sleep(60 * time.Millisecond)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state: got %s, want %s", b.State(), BreakerHalfOpen)
+	}
+	if err := cl.Get("host/", nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe: got %v, want server error", err)
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("state: got %s, want %s", b.State(), BreakerOpen)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	down.Store(false)
+	if err := cl.Get("host/", nil); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("state: got %s, want %s", b.State(), BreakerClosed)
+	}
+}
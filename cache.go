@@ -0,0 +1,362 @@
+package stratumclient
+
+import (
+	"container/list"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry holds a cached response body, with the ETag and
+// Last-Modified validators of the response if the server sent them.
+// Stale is set by caches on entries returned after their lifetime,
+// which the client revalidates with a conditional request before
+// they are used. Caches set Background too on stale entries which
+// may be used at once while the client refreshes them in the
+// background.
+type CacheEntry struct {
+	Body         []byte
+	Stored       time.Time
+	ETag         string
+	LastModified string
+	Stale        bool
+	Background   bool
+}
+
+// revalidatable reports whether the entry has a validator for a
+// conditional request.
+func (e *CacheEntry) revalidatable() bool {
+	return e.ETag != "" || e.LastModified != ""
+}
+
+// Cache stores the responses of GET calls. Keys are normalized
+// queries starting with the table name followed by a slash, so all
+// entries of a table can be purged by prefix. Implementations must be
+// safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Purge(prefix string)
+}
+
+// MemoryCache is an in-memory Cache whose entries expire after TTL.
+// A zero TTL keeps entries until they are purged. Expired entries
+// with an ETag or Last-Modified validator are kept and returned as
+// Stale, so the client can revalidate them instead of fetching the
+// response again. MaxEntries limits the number of entries, evicting
+// the least recently used. Zero means no limit. Clock tells the age
+// of entries, default the system clock.
+//
+// StaleWhileRevalidate is how long after their TTL entries are still
+// returned at once, while the client refreshes them in the
+// background, for lookup tables which change rarely but are read on
+// latency-sensitive paths. Entries older than that are handled as
+// expired.
+type MemoryCache struct {
+	TTL                  time.Duration
+	MaxEntries           int
+	StaleWhileRevalidate time.Duration
+	Clock                Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// memoryEntry is an entry of a MemoryCache.
+type memoryEntry struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache returns an empty MemoryCache with the given TTL.
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{TTL: ttl}
+}
+
+// init allocates the entries on first use, so the zero value is
+// ready for use. It must be called with m.mu held.
+func (m *MemoryCache) init() {
+	if m.entries == nil {
+		m.entries = make(map[string]*list.Element)
+		m.lru = list.New()
+	}
+}
+
+// now returns the time of the cache clock.
+func (m *MemoryCache) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+
+	return time.Now()
+}
+
+// Get returns the entry stored under key. Expired entries are
+// returned as Stale if they can be revalidated, and removed
+// otherwise.
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*memoryEntry).entry
+	if age := m.now().Sub(e.Stored); m.TTL > 0 && age > m.TTL {
+		background := age <= m.TTL+m.StaleWhileRevalidate
+		if !background && !e.revalidatable() {
+			m.lru.Remove(el)
+			delete(m.entries, key)
+			return nil, false
+		}
+		stale := *e
+		stale.Stale = true
+		stale.Background = background
+		e = &stale
+	}
+	m.lru.MoveToFront(el)
+
+	return e, true
+}
+
+// Set stores entry under key, evicting the least recently used entry
+// if the cache is full.
+func (m *MemoryCache) Set(key string, entry *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryEntry).entry = entry
+		m.lru.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(&memoryEntry{key: key, entry: entry})
+
+	for m.MaxEntries > 0 && m.lru.Len() > m.MaxEntries {
+		el := m.lru.Back()
+		m.lru.Remove(el)
+		delete(m.entries, el.Value.(*memoryEntry).key)
+	}
+}
+
+// Purge removes all entries whose key starts with prefix.
+func (m *MemoryCache) Purge(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	for key, el := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.lru.Remove(el)
+			delete(m.entries, key)
+		}
+	}
+}
+
+// cacheKey returns the cache key of a GET query. Semantically equal
+// queries get the same key: select columns and parameters are
+// sorted, and the terms of where clauses are sorted since their
+// order doesn't change the result. The orderby columns are kept in
+// order. Queries which don't parse are used as is.
+func cacheKey(query string, o *callOptions) string {
+	key := query
+	if q, err := ParseQuery(query); err == nil {
+		key = canonicalQuery(q).String()
+	}
+	if o.accept != "" {
+		key += "#" + o.accept
+	}
+
+	return key
+}
+
+// canonicalQuery returns a normalized copy of q.
+func canonicalQuery(q *Query) *Query {
+	n := &Query{Table: q.Table, OrderBy: q.OrderBy, Where: canonicalExpr(q.Where)}
+
+	seen := make(map[string]bool)
+	for _, col := range q.Select {
+		col = strings.TrimSpace(col)
+		if !seen[col] {
+			seen[col] = true
+			n.Select = append(n.Select, col)
+		}
+	}
+	sort.Strings(n.Select)
+
+	n.Params = append(n.Params, q.Params...)
+	sort.SliceStable(n.Params, func(i, j int) bool {
+		return n.Params[i].Key < n.Params[j].Key
+	})
+
+	return n
+}
+
+// canonicalExpr returns a normalized copy of e, with nested lists of
+// the same kind flattened and their terms sorted and deduplicated.
+func canonicalExpr(e Expr) Expr {
+	switch e := e.(type) {
+	case And:
+		return And(canonicalList(e, func(x Expr) ([]Expr, bool) {
+			a, ok := x.(And)
+			return a, ok
+		}))
+	case Or:
+		return Or(canonicalList(e, func(x Expr) ([]Expr, bool) {
+			o, ok := x.(Or)
+			return o, ok
+		}))
+	case *Not:
+		return &Not{Expr: canonicalExpr(e.Expr)}
+	}
+
+	return e
+}
+
+// canonicalList normalizes the terms of an And or Or list. The split
+// function returns the terms of a nested list of the same kind.
+func canonicalList(list []Expr, split func(Expr) ([]Expr, bool)) []Expr {
+	var terms []Expr
+	for _, x := range list {
+		x = canonicalExpr(x)
+		if nested, ok := split(x); ok {
+			terms = append(terms, nested...)
+		} else {
+			terms = append(terms, x)
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].String() < terms[j].String()
+	})
+
+	var ret []Expr
+	for i, x := range terms {
+		if i == 0 || x.String() != terms[i-1].String() {
+			ret = append(ret, x)
+		}
+	}
+
+	return ret
+}
+
+// cachedCall performs a call through the client's Cache. GET
+// responses are served from and stored in the cache, and other
+// successful calls purge the entries of their table. Stale entries
+// are revalidated with a conditional request, and used again if the
+// server responds 304 Not Modified. Stale entries marked Background
+// are returned at once and refreshed in the background.
+func (c *Client) cachedCall(method, query string, data interface{}, o *callOptions) ([]byte, error) {
+	cacheable := method == "GET" && o.headers == nil && o.meta == nil && o.status == nil
+	key := cacheKey(o.withParams(query), o)
+	if prefix := c.apiPrefix(o); prefix != c.prefix {
+		// other API versions are cached apart
+		key += "@" + path.Base(prefix)
+	}
+	var stale *CacheEntry
+	if cacheable {
+		if e, ok := c.Cache.Get(key); ok {
+			if !e.Stale {
+				// copy, so callers can't change the cached body
+				return append([]byte(nil), e.Body...), nil
+			}
+			if e.Background {
+				c.refresh(key, query, e, o)
+				return append([]byte(nil), e.Body...), nil
+			}
+			stale = e
+			o = c.conditional(o, e)
+		}
+	}
+
+	return c.fill(key, method, query, data, stale, cacheable, o)
+}
+
+// fill performs a call for cachedCall and updates the cache with the
+// response. The stale entry, if not nil, is used again if the server
+// responds 304 Not Modified.
+func (c *Client) fill(key, method, query string, data interface{}, stale *CacheEntry, cacheable bool, o *callOptions) ([]byte, error) {
+	resp, err := c.do(method, query, data, o)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		c.Cache.Set(key, &CacheEntry{Body: stale.Body, Stored: c.clock().Now(), ETag: stale.ETag, LastModified: stale.LastModified})
+		return append([]byte(nil), stale.Body...), nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		c.Cache.Set(key, &CacheEntry{
+			Body:         body,
+			Stored:       c.clock().Now(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		})
+	} else if !safeMethod(method) {
+		c.Cache.Purge(endpoint(query) + "/")
+	}
+
+	return body, nil
+}
+
+// refresh refetches the stale entry e of a GET query in the
+// background, unless a refresh of key is already running. The
+// refresh isn't cancelled with the context of the call, and failures
+// are logged, keeping the stale entry until it expires.
+func (c *Client) refresh(key, query string, e *CacheEntry, o *callOptions) {
+	if _, running := c.refreshing.LoadOrStore(key, true); running {
+		return
+	}
+
+	n := *o
+	n.ctx = context.WithoutCancel(o.context())
+	n.status = nil
+	n.downloadProgress = nil
+	var stale *CacheEntry
+	if e.revalidatable() {
+		stale = e
+		n = *c.conditional(&n, e)
+	}
+
+	go func() {
+		defer c.refreshing.Delete(key)
+		if _, err := c.fill(key, "GET", query, nil, stale, true, &n); err != nil && c.Logger != nil {
+			c.Logger.Warn("stratum cache refresh failed", "query", query, "error", err)
+		}
+	}()
+}
+
+// conditional returns a copy of the call options which revalidate
+// the cache entry e, accepting 304 Not Modified as a result.
+func (c *Client) conditional(o *callOptions, e *CacheEntry) *callOptions {
+	n := *o
+	n.header = o.header.Clone()
+	if n.header == nil {
+		n.header = make(http.Header)
+	}
+	if e.ETag != "" {
+		n.header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		n.header.Set("If-Modified-Since", e.LastModified)
+	}
+
+	n.success = append(append([]int(nil), c.successCodes(o)...), http.StatusNotModified)
+
+	return &n
+}
@@ -0,0 +1,198 @@
+package stratumclient
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheKey(t *testing.T) {
+	o := &callOptions{}
+	same := [][2]string{
+		{"host/?select=id,name&where=a=1,b=2", "host/?where=b=2,a=1&select=name,id"},
+		{"/host/?limit=5&select=id", "host/?select=id,id&limit=5"},
+		{"host/?where=a=1|(b=2|c=3)", "host/?where=c=3|b=2|a=1"},
+		{"host/?where=name=a%20b", "host/?where=name=a+b"},
+	}
+	for _, q := range same {
+		if a, b := cacheKey(q[0], o), cacheKey(q[1], o); a != b {
+			t.Errorf("keys differ: %s and %s", a, b)
+		}
+	}
+
+	different := [][2]string{
+		{"host/?orderby=name,id", "host/?orderby=id,name"},
+		{"host/?where=a=1,b=2|c=3", "host/?where=a=1,(b=2|c=3)"},
+		{"host/", "hostgroup/"},
+	}
+	for _, q := range different {
+		if a, b := cacheKey(q[0], o), cacheKey(q[1], o); a == b {
+			t.Errorf("keys equal: %s and %s", q[0], q[1])
+		}
+	}
+	if cacheKey("host/", o) == cacheKey("host/", &callOptions{accept: "text/csv"}) {
+		t.Errorf("keys equal for different media types")
+	}
+}
+
+func TestCache(t *testing.T) {
+	calls := 0
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeJSON(w, http.StatusOK, []map[string]int{{"id": calls}})
+	})
+	cl.Cache = NewMemoryCache(time.Minute)
+
+	var a, b []map[string]int
+	if err := cl.Get("host/?select=id,name&where=a=1,b=2", &a); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if err := cl.Get("host/?select=name,id&where=b=2,a=1", &b); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if calls != 1 || b[0]["id"] != 1 {
+		t.Fatalf("equal queries: %d calls, got %v", calls, b)
+	}
+
+	if err := cl.Post("host/", map[string]string{"name": "web1"}, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if err := cl.Get("host/?select=id,name&where=a=1,b=2", &a); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if calls != 3 || a[0]["id"] != 3 {
+		t.Fatalf("after post: %d calls, got %v", calls, a)
+	}
+
+	var status int
+	if err := cl.Get("host/?select=id,name&where=a=1,b=2", &a, CaptureStatus(&status)); err != nil {
+		t.Fatalf("get with status: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("cached entry with CaptureStatus: got status %d", status)
+	}
+
+	m := NewMemoryCache(time.Millisecond)
+	m.Set("host/", &CacheEntry{Stored: time.Now().Add(-time.Second)})
+	if _, ok := m.Get("host/"); ok {
+		t.Fatalf("expired entry returned")
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	var full, notModified int
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		writeJSON(w, http.StatusOK, []map[string]int{{"id": 1}})
+	})
+	m := NewMemoryCache(time.Millisecond)
+	cl.Cache = m
+
+	for i := 0; i < 3; i++ {
+		var rows []map[string]int
+		if err := cl.Get("host/", &rows); err != nil {
+			t.Fatalf("get %d: %v", i, err)
+		}
+		if len(rows) != 1 || rows[0]["id"] != 1 {
+			t.Fatalf("get %d: got %v", i, rows)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if full != 1 || notModified != 2 {
+		t.Fatalf("requests: got %d full and %d conditional, want 1 and 2", full, notModified)
+	}
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	m := &MemoryCache{MaxEntries: 2}
+	m.Set("a/", &CacheEntry{Body: []byte("a")})
+	m.Set("b/", &CacheEntry{Body: []byte("b")})
+	m.Get("a/")
+	m.Set("c/", &CacheEntry{Body: []byte("c")})
+
+	if _, ok := m.Get("b/"); ok {
+		t.Fatal("least recently used entry not evicted")
+	}
+	for _, key := range []string{"a/", "c/"} {
+		if _, ok := m.Get(key); !ok {
+			t.Fatalf("entry %s evicted", key)
+		}
+	}
+
+	m.Purge("a")
+	if _, ok := m.Get("a/"); ok {
+		t.Fatal("purged entry returned")
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	release := make(chan struct{})
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n > 1 {
+			<-release
+		}
+		writeJSON(w, http.StatusOK, []map[string]int{{"id": n}})
+	})
+	m := &MemoryCache{TTL: time.Minute, StaleWhileRevalidate: time.Hour}
+	cl.Cache = m
+
+	get := func() int {
+		t.Helper()
+		var rows []map[string]int
+		if err := cl.Get("host/", &rows); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		return rows[0]["id"]
+	}
+	age := func(d time.Duration) {
+		e, _ := m.Get("host/")
+		e.Stored = time.Now().Add(-d)
+		e.Stale, e.Background = false, false
+		m.Set("host/", e)
+	}
+
+	get()
+	age(2 * time.Minute)
+	// stale entries are served at once, with one refresh in flight
+	if id, id2 := get(), get(); id != 1 || id2 != 1 {
+		t.Fatalf("stale: got %d and %d, want 1", id, id2)
+	}
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if e, _ := m.Get("host/"); !e.Stale {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("entry not refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if id := get(); id != 2 {
+		t.Fatalf("refreshed: got %d, want 2", id)
+	}
+
+	// entries past the stale window are fetched before use
+	age(2 * time.Hour)
+	if id := get(); id != 3 {
+		t.Fatalf("expired: got %d, want 3", id)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 3 {
+		t.Errorf("calls: got %d, want 3", calls)
+	}
+}
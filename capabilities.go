@@ -0,0 +1,145 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Capabilities holds what the server supports, as described by its
+// OpenAPI document: the tables and their columns, the methods allowed
+// on each table, and the where clause operators. Tables allowing GET
+// allow HEAD too. The operators are
+// listed in the x-stratum-operators extension of the document, and
+// default to all operators of the query syntax if it is missing.
+type Capabilities struct {
+	Schema    *Schema
+	Methods   map[string][]string
+	Operators []string
+}
+
+// Capabilities returns the capabilities of the server, loading the
+// OpenAPI document from SchemaURL on first use, like Schema. The
+// function returns the capabilities and an error.
+func (c *Client) Capabilities() (*Capabilities, error) {
+	if !c.opened {
+		return nil, fmt.Errorf("config not opened with Open()")
+	}
+
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	if c.schema.caps != nil {
+		return c.schema.caps, nil
+	}
+
+	doc, err := c.fetchOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	caps, err := ParseOpenAPICapabilities(doc)
+	if err != nil {
+		return nil, err
+	}
+	c.schema.caps = caps
+	if c.schema.schema == nil {
+		c.schema.schema = caps.Schema
+	}
+
+	return caps, nil
+}
+
+// ParseOpenAPICapabilities returns the capabilities described by an
+// OpenAPI 3 document. The tables are parsed like ParseOpenAPISchema.
+// The function returns the capabilities and an error.
+func ParseOpenAPICapabilities(doc []byte) (*Capabilities, error) {
+	schema, err := ParseOpenAPISchema(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec struct {
+		Paths     map[string]map[string]json.RawMessage `json:"paths"`
+		Operators []string                              `json:"x-stratum-operators"`
+	}
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("capabilities: %v", err)
+	}
+
+	caps := &Capabilities{Schema: schema, Methods: make(map[string][]string), Operators: spec.Operators}
+	if caps.Operators == nil {
+		caps.Operators = append([]string(nil), operators...)
+	}
+	for p, ops := range spec.Paths {
+		var methods []string
+		for method := range ops {
+			switch method = strings.ToUpper(method); method {
+			case "GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE":
+				methods = append(methods, method)
+			}
+		}
+		if slices.Contains(methods, "GET") && !slices.Contains(methods, "HEAD") {
+			methods = append(methods, "HEAD")
+		}
+		sort.Strings(methods)
+		caps.Methods[strings.Trim(p, "/")] = methods
+	}
+
+	return caps, nil
+}
+
+// CheckQuery checks a query against the capabilities before it is
+// sent: the table must exist and allow method, and the columns of its
+// select, where and orderby parameters must be columns of the table,
+// compared with supported operators. Misspelled columns are reported
+// with the closest column name. Aggregates like count(*) are not
+// checked. The function returns an error for the first problem found,
+// otherwise nil.
+func (caps *Capabilities) CheckQuery(method, query string) error {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	t, ok := caps.Schema.Tables[q.Table]
+	if !ok {
+		return fmt.Errorf("unknown table %s", q.Table)
+	}
+	method = strings.ToUpper(method)
+	if methods, ok := caps.Methods[q.Table]; ok && !slices.Contains(methods, method) {
+		return fmt.Errorf("method %s not allowed on %s", method, q.Table)
+	}
+
+	check := func(column string) error {
+		if strings.Contains(column, "(") {
+			return nil
+		}
+		if _, ok := t.Columns[column]; ok {
+			return nil
+		}
+		if guess := t.closestColumn(column); guess != "" {
+			return fmt.Errorf("unknown column %s in %s, did you mean %s?", column, t.Name, guess)
+		}
+		return fmt.Errorf("unknown column %s in %s", column, t.Name)
+	}
+	for _, col := range q.Select {
+		if err := check(strings.TrimSpace(col)); err != nil {
+			return err
+		}
+	}
+	for _, cond := range Conds(q.Where) {
+		if err := check(cond.Column); err != nil {
+			return err
+		}
+		if !slices.Contains(caps.Operators, cond.Op) {
+			return fmt.Errorf("operator %s not supported in %s", cond.Op, cond)
+		}
+	}
+	for _, col := range q.OrderBy {
+		if err := check(strings.TrimPrefix(strings.TrimSpace(col), "-")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
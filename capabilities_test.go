@@ -0,0 +1,52 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	doc := strings.Replace(testOpenAPI, `"openapi": "3.0.3",`, `"openapi": "3.0.3", "x-stratum-operators": ["=", "!=", "~"],`, 1)
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(doc))
+	})
+
+	caps, err := cl.Capabilities()
+	if err != nil {
+		t.Fatalf("capabilities: %v", err)
+	}
+	if got := caps.Methods["platform"]; len(got) != 3 || got[0] != "GET" || got[1] != "HEAD" || got[2] != "POST" {
+		t.Errorf("methods: got %q", got)
+	}
+	if len(caps.Operators) != 3 {
+		t.Errorf("operators: got %q", caps.Operators)
+	}
+	if s, _ := cl.Schema(); s != caps.Schema {
+		t.Error("schema not shared")
+	}
+
+	tests := []struct {
+		method, query, err string
+	}{
+		{"GET", "platform/?select=id,count(*)&where=name~linux&orderby=-name", ""},
+		{"post", "platform/", ""},
+		{"DELETE", "platform/?where=id=1", "method DELETE not allowed on platform"},
+		{"GET", "hosts/", "unknown table hosts"},
+		{"GET", "host/?where=platformid=1", "did you mean platform_id?"},
+		{"GET", "host/?orderby=-nmae", "did you mean name?"},
+		{"GET", "host/?where=ram>=4", "operator >= not supported in ram>=4"},
+	}
+	for _, tt := range tests {
+		err := caps.CheckQuery(tt.method, tt.query)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s %s: got %v, want %q", tt.method, tt.query, err, tt.err)
+		}
+	}
+
+	caps, _ = ParseOpenAPICapabilities([]byte(testOpenAPI))
+	if err := caps.CheckQuery("GET", "host/?where=ram>=4"); err != nil {
+		t.Errorf("default operators: %v", err)
+	}
+}
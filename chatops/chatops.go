@@ -0,0 +1,376 @@
+// Package chatops maps chat commands to Stratum API calls, for quick
+// lookups from Slack, Teams and similar chat tools:
+//
+//	stratum get platform name~linux select=id,name
+//	stratum tables
+//	stratum columns platform
+//	stratum help
+//
+// The arguments of get following the table name are where conditions
+// which must all match, except select=, orderby= and limit= which set
+// the corresponding query parameters. Arguments may be double quoted
+// to hold spaces. Results are formatted as a fixed width table in a
+// code block, truncated to fit a chat message.
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stianwa/stratumclient"
+)
+
+// Bot handles chat commands with a Stratum client. Zero valued
+// fields are replaced by their defaults.
+type Bot struct {
+	Client *stratumclient.Client
+	// Prefix is the word commands start with. Default "stratum".
+	Prefix string
+	// MaxRows is the number of rows shown. Default 20.
+	MaxRows int
+	// MaxWidth is the number of characters shown of a value.
+	// Default 40.
+	MaxWidth int
+	// MaxLength is the maximum length of a reply. Slack truncates
+	// messages at 4000 characters. Default 3500.
+	MaxLength int
+}
+
+// Handle handles a chat message. It returns the reply and true if
+// the message is a command for the bot, otherwise false. Errors are
+// reported in the reply.
+func (b *Bot) Handle(ctx context.Context, message string) (string, bool) {
+	args, err := splitArgs(message)
+	prefix := b.Prefix
+	if prefix == "" {
+		prefix = "stratum"
+	}
+	if len(args) == 0 || args[0] != prefix {
+		return "", false
+	}
+	if err != nil {
+		return "error: " + err.Error(), true
+	}
+
+	if len(args) == 1 {
+		return b.help(), true
+	}
+
+	var reply string
+	switch args[1] {
+	case "get":
+		reply, err = b.get(ctx, args[2:])
+	case "tables":
+		reply, err = b.tables()
+	case "columns":
+		reply, err = b.columns(args[2:])
+	case "help":
+		reply = b.help()
+	default:
+		err = fmt.Errorf("unknown command %q, try %s help", args[1], prefix)
+	}
+	if err != nil {
+		return "error: " + err.Error(), true
+	}
+
+	return b.truncate(reply), true
+}
+
+// help returns the usage text.
+func (b *Bot) help() string {
+	p := b.Prefix
+	if p == "" {
+		p = "stratum"
+	}
+
+	return fmt.Sprintf("usage:\n"+
+		"  %[1]s get <table> [condition...] [select=col,...] [orderby=col,...] [limit=n]\n"+
+		"  %[1]s tables\n"+
+		"  %[1]s columns <table>\n", p)
+}
+
+// get performs a query and formats the rows.
+func (b *Bot) get(ctx context.Context, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("missing table")
+	}
+
+	maxRows := b.MaxRows
+	if maxRows <= 0 {
+		maxRows = 20
+	}
+	limit := maxRows
+
+	var selects []string
+	params := url.Values{}
+	var where []string
+	for _, arg := range args[1:] {
+		key, value, _ := strings.Cut(arg, "=")
+		switch key {
+		case "select":
+			selects = strings.Split(value, ",")
+			params.Set(key, value)
+		case "orderby":
+			params.Set(key, value)
+		case "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return "", fmt.Errorf("invalid limit %q", value)
+			}
+			if n < limit {
+				limit = n
+			}
+		default:
+			where = append(where, arg)
+		}
+	}
+	if len(where) > 0 {
+		params.Set("where", strings.Join(where, ","))
+	}
+	// fetch one row more than shown, to tell if there are more
+	params.Set("limit", strconv.Itoa(limit+1))
+
+	query := strings.Trim(args[0], "/") + "/?" + params.Encode()
+	body, err := b.Client.Call("GET", query, nil, stratumclient.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return "", err
+	}
+
+	if len(rows) == 0 {
+		return "no rows", nil
+	}
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	text := b.table(columns(rows, selects), rows)
+	if more {
+		text += fmt.Sprintf("first %d rows shown, add conditions or limit= to narrow the result", limit)
+	} else {
+		text += fmt.Sprintf("%d rows", len(rows))
+	}
+
+	return text, nil
+}
+
+// tables lists the tables of the schema.
+func (b *Bot) tables() (string, error) {
+	s, err := b.Client.Schema()
+	if err != nil {
+		return "", err
+	}
+
+	return "```\n" + strings.Join(s.TableNames(), "\n") + "\n```", nil
+}
+
+// columns lists the columns of a table.
+func (b *Bot) columns(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("missing table")
+	}
+	s, err := b.Client.Schema()
+	if err != nil {
+		return "", err
+	}
+	t, ok := s.Tables[strings.Trim(args[0], "/")]
+	if !ok {
+		return "", fmt.Errorf("unknown table %q", args[0])
+	}
+
+	rows := make([]map[string]interface{}, 0, len(t.Columns))
+	for _, name := range t.ColumnNames() {
+		col := t.Columns[name]
+		rows = append(rows, map[string]interface{}{"column": col.Name, "type": col.Type, "nullable": col.Nullable})
+	}
+
+	return b.table([]string{"column", "type", "nullable"}, rows), nil
+}
+
+// table formats rows as a fixed width table in a code block.
+func (b *Bot) table(cols []string, rows []map[string]interface{}) string {
+	maxWidth := b.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = 40
+	}
+
+	cells := make([][]string, len(rows)+1)
+	cells[0] = cols
+	for i, row := range rows {
+		cells[i+1] = make([]string, len(cols))
+		for j, col := range cols {
+			cells[i+1][j] = clip(valueText(row[col]), maxWidth)
+		}
+	}
+
+	widths := make([]int, len(cols))
+	for _, line := range cells {
+		for j, cell := range line {
+			if n := utf8.RuneCountInString(cell); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```\n")
+	for _, line := range cells {
+		for j, cell := range line {
+			if j == len(line)-1 {
+				sb.WriteString(cell)
+				break
+			}
+			sb.WriteString(cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)+2))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+
+	return sb.String()
+}
+
+// truncate cuts a reply to MaxLength, closing an open code block.
+// The reply is cut at a line break if it has one, otherwise between
+// runes. A MaxLength too short for the truncation note cuts the reply
+// without it.
+func (b *Bot) truncate(reply string) string {
+	maxLength := b.MaxLength
+	if maxLength <= 0 {
+		maxLength = 3500
+	}
+	if len(reply) <= maxLength {
+		return reply
+	}
+
+	const note = "\n```\n(truncated)"
+	if maxLength < len(note) {
+		return reply[:runeStart(reply, maxLength)]
+	}
+	cut := runeStart(reply, maxLength-len(note))
+	if i := strings.LastIndexByte(reply[:cut], '\n'); i > 0 {
+		cut = i
+	}
+
+	return reply[:cut] + note
+}
+
+// runeStart returns the largest index of s up to i which starts a
+// rune, so s[:i] doesn't cut a multi-byte rune.
+func runeStart(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+
+	return i
+}
+
+// columns returns the columns to show: the selected ones, or all
+// columns found in the rows with id first.
+func columns(rows []map[string]interface{}, selects []string) []string {
+	if len(selects) > 0 && selects[0] != "*" {
+		return selects
+	}
+
+	seen := make(map[string]bool)
+	var cols []string
+	for _, row := range rows {
+		for col := range row {
+			if !seen[col] {
+				seen[col] = true
+				cols = append(cols, col)
+			}
+		}
+	}
+	sort.Slice(cols, func(i, j int) bool {
+		if (cols[i] == "id") != (cols[j] == "id") {
+			return cols[i] == "id"
+		}
+		return cols[i] < cols[j]
+	})
+
+	return cols
+}
+
+// valueText returns the text of a decoded JSON value.
+func valueText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	text, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(text)
+}
+
+// clip shortens s to n characters, marking the cut with an ellipsis,
+// and replaces line breaks so a value stays on its row.
+func clip(s string, n int) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ", "`", "'").Replace(s)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// splitArgs splits a message into whitespace separated arguments.
+// Double quoted parts may hold whitespace and are kept with their
+// quotes, so quoted where values keep their meaning.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inQuote, inArg := false, false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '\\' && inQuote && i+1 < len(s):
+			cur.WriteByte(ch)
+			cur.WriteByte(s[i+1])
+			i++
+		case ch == '"':
+			inQuote = !inQuote
+			inArg = true
+			cur.WriteByte(ch)
+		case !inQuote && (ch == ' ' || ch == '\t' || ch == '\n'):
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			inArg = true
+			cur.WriteByte(ch)
+		}
+	}
+	if inQuote {
+		return args, fmt.Errorf("unterminated quote")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+
+	return args, nil
+}
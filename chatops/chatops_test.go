@@ -0,0 +1,93 @@
+package chatops
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stianwa/stratumclient"
+	"github.com/stianwa/stratumclient/stratumclienttest"
+)
+
+func TestBot(t *testing.T) {
+	srv := stratumclienttest.NewServer()
+	defer srv.Close()
+	srv.AddTable("platform",
+		map[string]interface{}{"id": 1, "name": "Linux", "vendor": "Red Hat"},
+		map[string]interface{}{"id": 2, "name": "Windows", "vendor": "Microsoft"},
+		map[string]interface{}{"id": 3, "name": "linux-arm", "vendor": "Red Hat"},
+	)
+	c, err := srv.Client()
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	c.SetSchema(&stratumclient.Schema{Tables: map[string]*stratumclient.TableSchema{
+		"platform": {Name: "platform", Columns: map[string]*stratumclient.ColumnSchema{
+			"id":   {Name: "id", Type: "integer"},
+			"name": {Name: "name", Type: "string"},
+		}},
+	}})
+	b := &Bot{Client: c, MaxRows: 2}
+	ctx := context.Background()
+
+	if _, ok := b.Handle(ctx, "hello there"); ok {
+		t.Fatalf("handled a non-command")
+	}
+
+	reply, ok := b.Handle(ctx, `stratum get platform name~linux vendor="Red Hat" select=id,name orderby=id`)
+	want := "```\nid  name\n1   Linux\n3   linux-arm\n```\n2 rows"
+	if !ok || reply != want {
+		t.Fatalf("get: got %q, want %q", reply, want)
+	}
+
+	reply, _ = b.Handle(ctx, "stratum get platform")
+	if !strings.Contains(reply, "first 2 rows shown") || strings.Count(reply, "\n") != 5 {
+		t.Fatalf("get truncated: got %q", reply)
+	}
+
+	reply, _ = b.Handle(ctx, "stratum columns platform")
+	if !strings.Contains(reply, "name    string") {
+		t.Fatalf("columns: got %q", reply)
+	}
+	reply, _ = b.Handle(ctx, "stratum get nothing")
+	if !strings.HasPrefix(reply, "error: ") {
+		t.Fatalf("unknown table: got %q", reply)
+	}
+	reply, _ = b.Handle(ctx, "stratum frobnicate")
+	if !strings.HasPrefix(reply, "error: unknown command") {
+		t.Fatalf("unknown command: got %q", reply)
+	}
+
+	b.MaxLength = 30
+	reply, _ = b.Handle(ctx, "stratum help")
+	if len(reply) > 30 || !strings.HasSuffix(reply, "(truncated)") {
+		t.Fatalf("help truncated: got %q", reply)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(`stratum  get host name="web 1" x`)
+	if err != nil || len(args) != 5 || args[3] != `name="web 1"` {
+		t.Fatalf("split: got %q, %v", args, err)
+	}
+	if _, err := splitArgs(`get "x`); err == nil {
+		t.Fatalf("unterminated quote: expected error")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	b := &Bot{MaxLength: 5}
+	if got := b.truncate("platform list"); got != "platf" {
+		t.Fatalf("tiny MaxLength: got %q", got)
+	}
+
+	reply := strings.Repeat("æøå", 20)
+	for _, max := range []int{4, 20, 21, 22, 30} {
+		b.MaxLength = max
+		got := b.truncate(reply)
+		if len(got) > max || !utf8.ValidString(got) {
+			t.Errorf("MaxLength %d: got %q", max, got)
+		}
+	}
+}
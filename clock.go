@@ -0,0 +1,38 @@
+package stratumclient
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time for token expiry, retry backoff and cache
+// lifetimes, so tests can simulate the passing of time without
+// sleeping. Sleep waits for d or until ctx is done, in which case the
+// context error is returned. See stratumclienttest.Clock for a manual
+// clock.
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep implements Clock.
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, d)
+}
+
+// clock returns the Clock of the client, default the system clock.
+func (c *Client) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+
+	return systemClock{}
+}
@@ -0,0 +1,69 @@
+package stratumclient
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Clone returns an independent copy of the client. The copy shares
+// the connection pool, rate limiter, schema and Cache of c, but has
+// its own token lifecycle and transfer counters, so it can be
+// reconfigured, refreshed and closed without affecting c. It is safe
+// to call Clone while c is in use.
+func (c *Client) Clone() *Client {
+	if c.mu != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	}
+	if c.limiterMu != nil {
+		c.limiterMu.Lock()
+		defer c.limiterMu.Unlock()
+	}
+
+	n := *c
+	n.Filters = append([]string(nil), c.Filters...)
+	n.DefaultHeaders = maps.Clone(c.DefaultHeaders)
+	if c.opened {
+		n.mu = &sync.Mutex{}
+		n.limiterMu = &sync.Mutex{}
+		n.stats = &statsCounter{}
+		n.deprecations = &deprecationLog{seen: make(map[string]bool)}
+	}
+
+	return &n
+}
+
+// WithCredentials returns a clone of c which acts as another user,
+// for services working on behalf of several Stratum users. The clone
+// logs in with the given credentials on its first call, or in Open if
+// c isn't opened yet. The clone has no Cache, since responses depend
+// on the user. The function returns the client and an error.
+func (c *Client) WithCredentials(username, password string) (*Client, error) {
+	if username == "" {
+		return nil, fmt.Errorf("missing: username")
+	}
+	if password == "" {
+		return nil, fmt.Errorf("missing: password")
+	}
+
+	n := c.Clone()
+	n.Username = username
+	n.Password = password
+	n.Auth = nil
+	n.OIDC = nil
+	n.PasswordProvider = nil
+	n.PasswordFile = ""
+	n.Token = ""
+	n.Cache = nil
+	n.token = ""
+	n.validUntil = time.Time{}
+	if !n.opened {
+		if err := n.Open(); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
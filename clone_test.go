@@ -0,0 +1,62 @@
+package stratumclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWithCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			user, _, _ := r.BasicAuth()
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token-" + user, ExpiresIn: 3600, TokenType: "bearer"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{map[string]string{"auth": r.Header.Get("Authorization")}})
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{Username: "admin", Password: "secret", BaseURL: srv.URL + "/stratum/v1"}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, user := range []string{"alice", "bob"} {
+		tenant, err := cl.WithCredentials(user, "pw")
+		if err != nil {
+			t.Fatalf("with credentials %s: %v", user, err)
+		}
+		if tenant.client != cl.client {
+			t.Fatalf("%s: clone doesn't share the http client", user)
+		}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(user string) {
+				defer wg.Done()
+				var rows []map[string]string
+				if err := tenant.Get("host/", &rows); err != nil {
+					t.Errorf("%s: get: %v", user, err)
+					return
+				}
+				if got, want := rows[0]["auth"], "Bearer token-"+user; got != want {
+					t.Errorf("%s: got %q, want %q", user, got, want)
+				}
+			}(user)
+		}
+	}
+	wg.Wait()
+
+	clone := cl.Clone()
+	if err := clone.Close(); err != nil {
+		t.Fatalf("close clone: %v", err)
+	}
+	if _, err := cl.AccessToken(); err != nil {
+		t.Fatalf("closing the clone closed the original: %v", err)
+	}
+	if _, err := cl.WithCredentials("", "pw"); err == nil {
+		t.Fatal("with credentials: missing username gave no error")
+	}
+}
@@ -0,0 +1,39 @@
+package stratumclient
+
+import (
+	"time"
+)
+
+// Close invalidates the client's session. The token is revoked with
+// a call to LogoutQuery if set, then forgotten, and idle connections
+// are closed. Further API calls fail with ErrClosed. Calls already in
+// flight are not aborted. Closing a client which isn't opened or is
+// already closed does nothing. The function returns the error of the
+// logout call, but the client is closed regardless.
+func (c *Client) Close() error {
+	if !c.opened || c.isClosed() {
+		return nil
+	}
+
+	var err error
+	if c.LogoutQuery != "" {
+		_, err = c.Call("POST", c.LogoutQuery, nil)
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	c.token = ""
+	c.validUntil = time.Time{}
+	c.mu.Unlock()
+	c.client.CloseIdleConnections()
+
+	return err
+}
+
+// isClosed reports whether the client is closed.
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
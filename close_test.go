@@ -0,0 +1,43 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClose(t *testing.T) {
+	var logouts int
+	cl, srv := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "//stratum/v1/logout/" {
+			if r.Header.Get("Authorization") == "Bearer token" {
+				logouts++
+			}
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.LogoutQuery = "logout/"
+
+	if err := cl.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := cl.Close(); err != nil {
+		t.Fatalf("close twice: %v", err)
+	}
+	if logouts != 1 {
+		t.Fatalf("logout calls: got %d, want 1", logouts)
+	}
+
+	if err := cl.Get("platform/", nil); !errors.Is(err, ErrClosed) {
+		t.Fatalf("get after close: got %v", err)
+	}
+	if _, err := cl.AccessToken(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("token after close: got %v", err)
+	}
+	if _, err := cl.HTTPClient().Get(srv.URL + "/stratum/v1/platform/"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("http client after close: got %v", err)
+	}
+	if err := (&Client{}).Close(); err != nil {
+		t.Fatalf("close unopened: %v", err)
+	}
+}
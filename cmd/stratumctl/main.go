@@ -0,0 +1,256 @@
+// Command stratumctl runs Stratum API calls from the shell, like
+//
+//	stratumctl get 'platform/?where=name~linux' -o table
+//	stratumctl post platform/ -d '{"name": "BSD"}'
+//	stratumctl delete 'platform/?where=id=7'
+//	stratumctl doctor 'platform/?limit=1'
+//
+// The client is configured with stratumclient.LoadConfig: the JSON
+// config file given by -config or $STRATUM_CONFIG, then the STRATUM_
+// environment variables, like STRATUM_BASE_URL, STRATUM_USERNAME and
+// STRATUM_PASSWORD.
+//
+// Responses are written as indented JSON, or with -o csv or -o table
+// as rows with a column per key, in sorted order. Post data for post,
+// put and delete is given with -d as JSON text, as @file to read a
+// file, or as - to read standard input. The doctor command runs the
+// client SelfTest and exits with status 1 if a check fails.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/stianwa/stratumclient"
+)
+
+// usage is the help text of the command.
+const usage = `usage: stratumctl [flags] command query
+
+commands:
+  get QUERY       GET the rows of QUERY
+  post QUERY      POST the -d data to QUERY
+  put QUERY       PUT the -d data to QUERY
+  delete QUERY    DELETE the rows of QUERY
+  doctor [QUERY]  diagnose the connection to the API
+
+flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command line args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stratumctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	configFlag := fs.String("config", os.Getenv("STRATUM_CONFIG"), "JSON config file")
+	outputFlag := fs.String("o", "json", "output format: json, csv or table")
+	dataFlag := fs.String("d", "", "post data: JSON text, @file, or - for stdin")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return 2
+	}
+	command, query := positional[0], ""
+	if len(positional) > 1 {
+		query = positional[1]
+	}
+	if len(positional) > 2 || command != "doctor" && query == "" {
+		fs.Usage()
+		return 2
+	}
+
+	c, err := stratumclient.LoadConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintf(stderr, "stratumctl: %v\n", err)
+		return 1
+	}
+	if command == "doctor" {
+		report := c.SelfTest(query)
+		fmt.Fprint(stdout, report)
+		if !report.OK() {
+			return 1
+		}
+		return 0
+	}
+
+	if err := call(c, command, query, *dataFlag, *outputFlag, stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "stratumctl: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// parseInterspersed parses the flags of args, which may come before,
+// between and after the positional arguments. The function returns
+// the positional arguments and an error.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// call performs the API call of command and writes the response in
+// the output format.
+func call(c *stratumclient.Client, command, query, data, output string, stdin io.Reader, stdout io.Writer) error {
+	method := strings.ToUpper(command)
+	switch method {
+	case "GET", "POST", "PUT", "DELETE":
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	switch output {
+	case "json", "csv", "table":
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+
+	post, err := readData(data, stdin)
+	if err != nil {
+		return err
+	}
+	if post != nil && method == "GET" {
+		return fmt.Errorf("get takes no -d data")
+	}
+	if post == nil && (method == "POST" || method == "PUT") {
+		return fmt.Errorf("missing: -d data")
+	}
+
+	if err := c.Open(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var body []byte
+	if post == nil {
+		body, err = c.Call(method, query, nil)
+	} else {
+		body, err = c.Call(method, query, post)
+	}
+	if err != nil {
+		return err
+	}
+
+	return write(stdout, body, output)
+}
+
+// readData returns the post data given by -d, or nil if none.
+func readData(data string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case data == "":
+		return nil, nil
+	case data == "-":
+		return io.ReadAll(stdin)
+	case strings.HasPrefix(data, "@"):
+		return os.ReadFile(data[1:])
+	}
+
+	return []byte(data), nil
+}
+
+// write writes the response body in the output format.
+func write(w io.Writer, body []byte, output string) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if output == "json" {
+		var b bytes.Buffer
+		if err := json.Indent(&b, body, "", "  "); err != nil {
+			return err
+		}
+		b.WriteByte('\n')
+		_, err := b.WriteTo(w)
+		return err
+	}
+
+	rows, err := decodeRows(body)
+	if err != nil {
+		return err
+	}
+	columns := rows.Columns()
+	if output == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write(columns)
+		for _, row := range rows {
+			record := make([]string, len(columns))
+			for i, col := range columns {
+				record[i] = row.String(col)
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = strings.ReplaceAll(row.String(col), "\t", " ")
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// decodeRows decodes a response body holding a row, an array of rows
+// or rows wrapped in an envelope like {"rows": [...]}.
+func decodeRows(body []byte) (stratumclient.Rows, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		list, ok := obj["rows"].([]interface{})
+		if !ok {
+			return stratumclient.Rows{obj}, nil
+		}
+		v = list
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("response doesn't hold rows")
+	}
+
+	rows := make(stratumclient.Rows, 0, len(list))
+	for _, item := range list {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("response doesn't hold rows")
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stianwa/stratumclient/stratumclienttest"
+)
+
+func TestRun(t *testing.T) {
+	srv := stratumclienttest.NewServer()
+	defer srv.Close()
+	if err := srv.AddTable("platform",
+		map[string]interface{}{"name": "Linux"},
+		map[string]interface{}{"name": "BSD"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STRATUM_CONFIG", "")
+	t.Setenv("STRATUM_BASE_URL", srv.BaseURL())
+	t.Setenv("STRATUM_USERNAME", srv.Username)
+	t.Setenv("STRATUM_PASSWORD", srv.Password)
+
+	ctl := func(stdin string, args ...string) (string, int) {
+		var stdout, stderr bytes.Buffer
+		status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String() + stderr.String(), status
+	}
+
+	out, status := ctl("", "get", "platform/?where=name~linux", "-o", "csv")
+	if status != 0 || out != "id,name\n1,Linux\n" {
+		t.Fatalf("get csv: got %d, %q", status, out)
+	}
+
+	out, status = ctl(`{"name": "Plan 9"}`, "-d", "-", "post", "platform/?returning=*")
+	if status != 0 || !strings.Contains(out, `"name": "Plan 9"`) {
+		t.Fatalf("post: got %d, %q", status, out)
+	}
+
+	out, status = ctl("", "-o", "table", "get", "platform/?orderby=name")
+	want := "ID  NAME\n2   BSD\n1   Linux\n3   Plan 9\n"
+	if status != 0 || out != want {
+		t.Fatalf("get table: got %d, %q, want %q", status, out, want)
+	}
+
+	if out, status = ctl("", "get"); status != 2 {
+		t.Fatalf("missing query: got %d, %q", status, out)
+	}
+	if out, status = ctl("", "patch", "platform/"); status != 1 || !strings.Contains(out, "unknown command") {
+		t.Fatalf("unknown command: got %d, %q", status, out)
+	}
+
+	out, status = ctl("", "doctor", "platform/")
+	if status != 0 || !strings.Contains(out, "query") {
+		t.Fatalf("doctor: got %d, %q", status, out)
+	}
+}
@@ -0,0 +1,232 @@
+// Command stratumgen generates typed Go bindings for the tables of a
+// Stratum API from its OpenAPI document: a struct per table row, and
+// an API type with List, Create, Update and Delete methods per table
+// built on stratumclient.Client, like
+//
+//	api := &models.API{Client: c}
+//	platforms, err := api.ListPlatforms(ctx, &stratumclient.Params{Where: stratumclient.W("name").Like("linux")})
+//
+// Use it with go generate:
+//
+//	//go:generate go run github.com/stianwa/stratumclient/cmd/stratumgen -schema https://stratum.example.com/stratum/docs/openapi.json -package models -o models.go
+//
+// The schema is read from a file, or fetched from an http(s) URL.
+// Columns which aren't required are pointers, so they can be left
+// out of created and updated rows.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/stianwa/stratumclient"
+)
+
+func main() {
+	schemaFlag := flag.String("schema", "", "OpenAPI document file or URL")
+	pkgFlag := flag.String("package", "models", "package name of the generated code")
+	outFlag := flag.String("o", "", "output file, default stdout")
+	flag.Parse()
+
+	if err := run(*schemaFlag, *pkgFlag, *outFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "stratumgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the bindings of the schema document at source.
+func run(source, pkg, out string) error {
+	if source == "" {
+		return fmt.Errorf("missing: -schema")
+	}
+
+	doc, err := readSchema(source)
+	if err != nil {
+		return err
+	}
+	schema, err := stratumclient.ParseOpenAPISchema(doc)
+	if err != nil {
+		return err
+	}
+	code, err := generate(schema, pkg)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+
+	return os.WriteFile(out, code, 0o644)
+}
+
+// readSchema reads the OpenAPI document from a file or URL.
+func readSchema(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", source, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// generate returns the formatted Go source of the bindings.
+func generate(schema *stratumclient.Schema, pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by stratumgen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	imports := []string{`"context"`}
+	if usesType(schema, "time.Time") {
+		imports = append(imports, `"time"`)
+	}
+	if usesType(schema, "json.RawMessage") {
+		imports = append(imports, `"encoding/json"`)
+	}
+	fmt.Fprintf(&b, "import (\n%s\n\n\"github.com/stianwa/stratumclient\"\n)\n\n", strings.Join(imports, "\n"))
+
+	b.WriteString("// API gives typed access to the tables of the API.\ntype API struct {\n\tClient *stratumclient.Client\n}\n")
+
+	for _, name := range schema.TableNames() {
+		t := schema.Tables[name]
+		typ := goName(name)
+		plural := pluralize(typ)
+
+		fmt.Fprintf(&b, "\n// %s is a row of the %s table.\ntype %s struct {\n", typ, name, typ)
+		for _, col := range t.ColumnNames() {
+			c := t.Columns[col]
+			ft := fieldType(c)
+			tag := col
+			if !c.Required {
+				ft = "*" + ft
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&b, "\t%s %s `json:%q`\n", goName(col), ft, tag)
+		}
+		b.WriteString("}\n")
+
+		fmt.Fprintf(&b, `
+// %[2]s returns the %[1]s table.
+func (a *API) %[2]s() *stratumclient.Table[%[3]s] {
+	return stratumclient.NewTable[%[3]s](a.Client, %[1]q)
+}
+
+// List%[2]s returns the %[1]s rows matching params, or all rows if
+// params is nil.
+func (a *API) List%[2]s(ctx context.Context, params *stratumclient.Params) ([]*%[3]s, error) {
+	if params == nil {
+		params = &stratumclient.Params{}
+	}
+
+	return stratumclient.GetAs[%[3]s](a.Client, params.Query(%[1]q), stratumclient.WithContext(ctx))
+}
+
+// Create%[3]s inserts a %[1]s row and returns it.
+func (a *API) Create%[3]s(ctx context.Context, row *%[3]s) ([]*%[3]s, error) {
+	return a.%[2]s().Create(ctx, row)
+}
+
+// Update%[2]s changes the %[1]s rows matching where and returns them.
+func (a *API) Update%[2]s(ctx context.Context, where stratumclient.Expr, row *%[3]s) ([]*%[3]s, error) {
+	return a.%[2]s().Update(ctx, where.String(), row)
+}
+
+// Delete%[2]s removes the %[1]s rows matching where and returns them.
+func (a *API) Delete%[2]s(ctx context.Context, where stratumclient.Expr) ([]*%[3]s, error) {
+	return a.%[2]s().Delete(ctx, where.String())
+}
+`, name, plural, typ)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// usesType reports whether a column of the schema has the Go type
+// typ.
+func usesType(schema *stratumclient.Schema, typ string) bool {
+	for _, t := range schema.Tables {
+		for _, c := range t.Columns {
+			if fieldType(c) == typ {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fieldType returns the Go type of a column.
+func fieldType(c *stratumclient.ColumnSchema) string {
+	switch c.Type {
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "string":
+		if c.Format == "date-time" {
+			return "time.Time"
+		}
+		return "string"
+	default:
+		return "json.RawMessage"
+	}
+}
+
+// initialisms are the words written in upper case in Go names.
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "DNS": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "MAC": true, "OS": true, "SQL": true, "URL": true, "UUID": true,
+}
+
+// goName returns the exported Go name of a table or column name like
+// "host_interface" or "ip-address".
+func goName(name string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if up := strings.ToUpper(w); initialisms[up] {
+			b.WriteString(up)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "X" + s
+	}
+
+	return s
+}
+
+// pluralize returns the plural of a Go name, for the names of the
+// list methods.
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
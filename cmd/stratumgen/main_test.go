@@ -0,0 +1,77 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/stianwa/stratumclient"
+)
+
+func TestGenerate(t *testing.T) {
+	schema := &stratumclient.Schema{Tables: map[string]*stratumclient.TableSchema{
+		"platform": {Name: "platform", Columns: map[string]*stratumclient.ColumnSchema{
+			"id":   {Name: "id", Type: "integer"},
+			"name": {Name: "name", Type: "string", Required: true},
+		}},
+		"host_ip_address": {Name: "host_ip_address", Columns: map[string]*stratumclient.ColumnSchema{
+			"host_id": {Name: "host_id", Type: "integer", Required: true},
+			"created": {Name: "created", Type: "string", Format: "date-time"},
+			"tags":    {Name: "tags", Type: "array"},
+		}},
+	}}
+
+	code, err := generate(schema, "models")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	src := string(code)
+	for _, want := range []string{
+		"type Platform struct",
+		"Name string `json:\"name\"`",
+		"ID   *int64 `json:\"id,omitempty\"`",
+		"type HostIPAddress struct",
+		"Created *time.Time",
+		"Tags    *json.RawMessage",
+		"func (a *API) ListPlatforms(",
+		"func (a *API) CreateHostIPAddress(",
+		"func (a *API) DeleteHostIPAddresses(",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "models.go", code, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("models", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("type check: %v\n%s", err, src)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"platform":        "Platform",
+		"host_ip_address": "HostIPAddress",
+		"os-version":      "OSVersion",
+		"2fa":             "X2fa",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q): got %q, want %q", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{"Host": "Hosts", "Policy": "Policies", "Address": "Addresses", "Key": "Keys"} {
+		if got := pluralize(in); got != want {
+			t.Errorf("pluralize(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
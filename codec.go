@@ -0,0 +1,94 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Codec encodes post data and decodes response bodies. It lets a
+// faster JSON library be plugged in, or the decoding be tuned with
+// JSONCodec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is a Codec using encoding/json. UseNumber decodes numbers
+// into interface{} values as json.Number instead of float64, so large
+// numeric IDs decoded into map[string]interface{} keep all their
+// digits. DisallowUnknownFields makes decoding into a struct fail on
+// fields the struct lacks, to catch API changes early. SnakeCase maps
+// snake_case columns onto the CamelCase names of struct fields without
+// json tags, both ways, so plain structs like
+//
+//	type Host struct {
+//		ID      int
+//		GuestOS string // guest_os
+//	}
+//
+// can be used without tags. Fields with json tags keep their names.
+type JSONCodec struct {
+	UseNumber             bool `yaml:"useNumber" json:"use_number"`
+	DisallowUnknownFields bool `yaml:"disallowUnknownFields" json:"disallow_unknown_fields"`
+	SnakeCase             bool `yaml:"snakeCase" json:"snake_case"`
+}
+
+// Marshal implements Codec.
+func (j *JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	if j.SnakeCase {
+		return snakeEncode(v)
+	}
+
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (j *JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	if j.SnakeCase {
+		var err error
+		if data, err = snakeDecode(data, v); err != nil {
+			return err
+		}
+	}
+	if !j.UseNumber && !j.DisallowUnknownFields {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if j.UseNumber {
+		dec.UseNumber()
+	}
+	if j.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid character after top-level value")
+	}
+
+	return nil
+}
+
+// defaultCodec is the Codec used when Client.Codec is nil.
+var defaultCodec = &JSONCodec{}
+
+// codec returns the Codec of the client.
+func (c *Client) codec() Codec {
+	if c.Codec != nil {
+		return c.Codec
+	}
+
+	return defaultCodec
+}
+
+// snakeCase reports whether the client codec maps snake_case columns
+// onto CamelCase fields, see JSONCodec.
+func (c *Client) snakeCase() bool {
+	j, ok := c.codec().(*JSONCodec)
+
+	return ok && j.SnakeCase
+}
@@ -0,0 +1,83 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCodec(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 9007199254740993, "name": "db1", "extra": true}]`))
+	})
+
+	var rows []map[string]interface{}
+	if err := cl.Get("host/", &rows); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if _, ok := rows[0]["id"].(float64); !ok {
+		t.Fatalf("default codec: got %T", rows[0]["id"])
+	}
+
+	cl.Codec = &JSONCodec{UseNumber: true}
+	if err := cl.Get("host/", &rows); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if id, ok := rows[0]["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Fatalf("UseNumber: got %v", rows[0]["id"])
+	}
+
+	cl.Codec = &JSONCodec{DisallowUnknownFields: true}
+	var hosts []*testPlatform
+	if err := cl.Get("host/", &hosts); err == nil {
+		t.Fatal("DisallowUnknownFields: expected error")
+	}
+	if err := cl.Codec.Unmarshal([]byte(`{} x`), &rows); err == nil {
+		t.Fatal("trailing data: expected error")
+	}
+}
+
+func TestCodecSnakeCase(t *testing.T) {
+	type base struct {
+		ID int
+	}
+	type host struct {
+		base
+		GuestOS   string
+		HostID    int64
+		Name      string `json:"hostname"`
+		CreatedAt time.Time
+		Tags      map[string]string
+		Disks     []struct{ SizeGB int }
+	}
+
+	codec := &JSONCodec{SnakeCase: true}
+	var hosts []*host
+	data := `[{"id": 1, "guest_os": "linux", "host_id": 9007199254740993, "hostname": "db1",
+		"created_at": "2024-01-02T03:04:05Z", "tags": {"Env_Name": "prod"}, "disks": [{"size_gb": 20}]}]`
+	if err := codec.Unmarshal([]byte(data), &hosts); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	h := hosts[0]
+	if h.ID != 1 || h.GuestOS != "linux" || h.HostID != 9007199254740993 || h.Name != "db1" ||
+		h.CreatedAt.Year() != 2024 || h.Tags["Env_Name"] != "prod" || h.Disks[0].SizeGB != 20 {
+		t.Fatalf("unmarshal: got %+v", h)
+	}
+
+	out, err := codec.Marshal(h)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"created_at":"2024-01-02T03:04:05Z","disks":[{"size_gb":20}],"guest_os":"linux","host_id":9007199254740993,"hostname":"db1","id":1,"tags":{"Env_Name":"prod"}}`
+	if string(out) != want {
+		t.Fatalf("marshal: got %s, want %s", out, want)
+	}
+
+	for name, want := range map[string]string{"GuestOS": "guest_os", "HostID": "host_id", "Name2": "name2", "URLPath": "url_path"} {
+		if got := snakeName(name); got != want {
+			t.Errorf("snakeName(%s): got %s, want %s", name, got, want)
+		}
+	}
+}
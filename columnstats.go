@@ -0,0 +1,105 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ColumnStats holds data quality statistics for a single column. Min
+// and Max hold a json.Number, string or bool, or nil if the column
+// only holds nulls.
+type ColumnStats struct {
+	Table    string
+	Column   string
+	Rows     int
+	Nulls    int
+	Distinct int
+	Min      interface{}
+	Max      interface{}
+}
+
+// ColumnStats computes the row count, null count, distinct count,
+// minimum and maximum of a column with aggregate selects evaluated by
+// the API backend, so only the results are transferred regardless of
+// the table size. Each aggregate is selected by its own query, since
+// the backend names the result columns after the aggregate function,
+// and the queries are sent concurrently. The function returns the
+// statistics and an error.
+func (c *Client) ColumnStats(table, column string, opts ...CallOption) (*ColumnStats, error) {
+	if table == "" || column == "" {
+		return nil, fmt.Errorf("missing: table or column")
+	}
+
+	aggregates := []string{
+		"count(*)",
+		"count(" + column + ")",
+		"count(distinct " + column + ")",
+		"min(" + column + ")",
+		"max(" + column + ")",
+	}
+	results := make([][]map[string]json.RawMessage, len(aggregates))
+	ops := make([]*Operation, len(aggregates))
+	for i, aggregate := range aggregates {
+		query := fmt.Sprintf("%s/?select=%s", strings.Trim(table, "/"), url.QueryEscape(aggregate))
+		ops[i] = &Operation{Method: "GET", Query: query, Resp: &results[i]}
+	}
+	if err := c.Batch(ops, len(ops), opts...); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(aggregates))
+	for i, rows := range results {
+		v, err := aggregateValue(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", aggregates[i], err)
+		}
+		values[i] = v
+	}
+
+	counts := make([]int, 3)
+	for i := range counts {
+		n, ok := values[i].(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a number, got %v", aggregates[i], values[i])
+		}
+		count, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", aggregates[i], err)
+		}
+		counts[i] = int(count)
+	}
+
+	return &ColumnStats{
+		Table:    table,
+		Column:   column,
+		Rows:     counts[0],
+		Nulls:    counts[0] - counts[1],
+		Distinct: counts[2],
+		Min:      values[3],
+		Max:      values[4],
+	}, nil
+}
+
+// aggregateValue returns the single value of an aggregate query
+// response, which is one row with one column. Numbers are returned
+// as json.Number.
+func aggregateValue(rows []map[string]json.RawMessage) (interface{}, error) {
+	if len(rows) != 1 || len(rows[0]) != 1 {
+		return nil, fmt.Errorf("expected a single aggregate value, got %d rows", len(rows))
+	}
+
+	for _, raw := range rows[0] {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestColumnStats(t *testing.T) {
+	var mu sync.Mutex
+	var selects []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		sel := r.URL.Query().Get("select")
+		mu.Lock()
+		selects = append(selects, sel)
+		mu.Unlock()
+
+		values := map[string]interface{}{
+			"count(*)":            6,
+			"count(ram)":          5,
+			"count(distinct ram)": 4,
+			"min(ram)":            2,
+			"max(ram)":            128,
+		}
+		writeJSON(w, http.StatusOK, []map[string]interface{}{{sel[:3]: values[sel]}})
+	})
+
+	stats, err := cl.ColumnStats("host", "ram")
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	sort.Strings(selects)
+	want := []string{"count(*)", "count(distinct ram)", "count(ram)", "max(ram)", "min(ram)"}
+	if len(selects) != len(want) {
+		t.Fatalf("selects: got %q, want %q", selects, want)
+	}
+	for i := range want {
+		if selects[i] != want[i] {
+			t.Errorf("select %d: got %q, want %q", i, selects[i], want[i])
+		}
+	}
+	if stats.Rows != 6 || stats.Nulls != 1 || stats.Distinct != 4 {
+		t.Errorf("counts: got %+v", stats)
+	}
+	if stats.Min != json.Number("2") || stats.Max != json.Number("128") {
+		t.Errorf("min/max: got %v/%v", stats.Min, stats.Max)
+	}
+}
+
+func TestAggregateValue(t *testing.T) {
+	if _, err := aggregateValue(nil); err == nil {
+		t.Errorf("no rows: expected error")
+	}
+	v, err := aggregateValue([]map[string]json.RawMessage{{"min": json.RawMessage(`"a"`)}})
+	if err != nil || v != "a" {
+		t.Errorf("string: got %v, %v", v, err)
+	}
+}
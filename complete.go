@@ -0,0 +1,108 @@
+package stratumclient
+
+import (
+	"strings"
+)
+
+// queryParams lists the query parameters offered by Complete.
+var queryParams = []string{"limit", "offset", "orderby", "returning", "select", "where"}
+
+// Complete returns the completions of a partial query, for building
+// editors, REPLs and chat integrations on top of the client. It
+// completes table names, query parameters, column names in select,
+// orderby, returning and where, and operators in where conditions,
+// using the API schema. Each completion is the partial query with
+// its last token completed. The function is safe for concurrent use
+// and returns the completions and an error.
+func (c *Client) Complete(partial string) ([]string, error) {
+	s, err := c.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Complete(partial), nil
+}
+
+// Complete returns the completions of a partial query like
+// Client.Complete.
+func (s *Schema) Complete(partial string) []string {
+	path, params, hasParams := strings.Cut(partial, "?")
+	if !strings.Contains(path, "/") {
+		lead := path[:len(path)-len(strings.TrimLeft(path, "/"))]
+		return complete(lead, strings.TrimLeft(path, "/"), "/", s.TableNames())
+	}
+
+	t, ok := s.Tables[strings.Trim(path, "/")]
+	if !ok {
+		return nil
+	}
+	if !hasParams {
+		if strings.HasSuffix(path, "/") {
+			return []string{partial + "?"}
+		}
+		return nil
+	}
+
+	i := strings.LastIndexByte(params, '&')
+	head := partial[:len(path)+1+i+1]
+	key, value, hasValue := strings.Cut(params[i+1:], "=")
+	if !hasValue {
+		return complete(head, key, "=", queryParams)
+	}
+	head += key + "="
+
+	switch key {
+	case "select", "returning", "orderby":
+		j := strings.LastIndexByte(value, ',')
+		head += value[:j+1]
+		token := value[j+1:]
+		if key == "orderby" && strings.HasPrefix(token, "-") {
+			head += "-"
+			token = token[1:]
+		}
+		return complete(head, token, "", t.ColumnNames())
+	case "where":
+		j := strings.LastIndexAny(value, ",|(")
+		head += value[:j+1]
+		return completeCond(head, value[j+1:], t)
+	}
+
+	return nil
+}
+
+// completeCond completes the column or operator of a where
+// condition.
+func completeCond(head, token string, t *TableSchema) []string {
+	k := strings.IndexAny(token, "=!<>~")
+	if k < 0 {
+		ret := complete(head, token, "", t.ColumnNames())
+		if _, ok := t.Columns[token]; ok {
+			ret = append(ret, complete(head+token, "", "", operators)...)
+		}
+		return ret
+	}
+
+	column, op := token[:k], token[k:]
+	if _, ok := t.Columns[column]; !ok {
+		return nil
+	}
+	ret := complete(head+column, op, "", operators)
+	if len(ret) == 1 && ret[0] == head+token {
+		return nil
+	}
+
+	return ret
+}
+
+// complete returns head followed by each of the candidates starting
+// with token, followed by suffix.
+func complete(head, token, suffix string, candidates []string) []string {
+	var ret []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, token) {
+			ret = append(ret, head+c+suffix)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,373 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix is the prefix of the environment variables read by
+// LoadEnv.
+const envPrefix = "STRATUM_"
+
+// DefaultConfig returns a client with the configuration fields set to
+// the defaults used when they are left zero:
+//
+//	Timeout              30 (seconds)
+//	HistoryTable         %s_history
+//	MaxErrorBody         65536 (bytes)
+//	TLSSessionCacheSize  64
+//	MaxIdleConnsPerHost  16
+//	DialTimeout          30s
+//	RefreshWindow        30s
+//
+// A nil Retry disables retries and a nil Breaker disables the circuit
+// breaker. Other zero fields are disabled or use the net/http
+// defaults.
+func DefaultConfig() *Client {
+	return &Client{
+		Timeout:             30,
+		HistoryTable:        "%s_history",
+		MaxErrorBody:        defaultMaxErrorBody,
+		TLSSessionCacheSize: defaultTLSSessionCacheSize,
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		DialTimeout:         defaultDialTimeout,
+		RefreshWindow:       defaultRefreshWindow,
+	}
+}
+
+// LoadConfig returns a client configured in layers: the defaults of
+// DefaultConfig, then the JSON config file if file isn't "", then
+// the environment as read by LoadEnv. Code takes the last say by
+// setting fields on the returned client before calling Open. The
+// function returns the client and an error, also if the resulting
+// configuration fails Validate.
+func LoadConfig(file string) (*Client, error) {
+	c := DefaultConfig()
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(content, c); err != nil {
+			return nil, fmt.Errorf("config %s: %v", file, err)
+		}
+	}
+	if err := c.LoadEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// secondsFields are the JSON names of the client fields counting
+// seconds, and sizeFields those counting bytes, which take
+// human-friendly values like "90s" and "64MB" in the config file and
+// the environment besides plain numbers.
+var (
+	secondsFields = map[string]bool{"timeout": true}
+	sizeFields    = map[string]bool{"max_error_body": true, "compress_threshold": true, "max_url_length": true}
+)
+
+// UnmarshalJSON decodes a JSON config file into the client. Besides
+// the values encoding/json takes, durations may be given as strings
+// like "1m30s", also the Timeout seconds, and byte sizes like
+// MaxErrorBody as strings like "64MB", see ParseSize, also in nested
+// settings like Retry.
+func (c *Client) UnmarshalJSON(data []byte) error {
+	data, err := humanizeJSON(reflect.TypeOf(*c), data)
+	if err != nil {
+		return err
+	}
+	type plain Client
+
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// humanizeJSON returns the JSON object data for struct type t with
+// the human-friendly duration and size strings of its fields
+// replaced by the numbers encoding/json expects. Values which aren't
+// strings are kept as is.
+func humanizeJSON(t reflect.Type, data []byte) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(data, &obj) != nil || obj == nil {
+		return data, nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		raw, ok := obj[name]
+		if !f.IsExported() || name == "" || name == "-" || !ok {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		var text string
+		if ft.Kind() == reflect.Struct && ft != durationType {
+			nested, err := humanizeJSON(ft, raw)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = nested
+			continue
+		}
+		if json.Unmarshal(raw, &text) != nil {
+			continue
+		}
+
+		var n int64
+		var err error
+		switch {
+		case ft == durationType:
+			var d time.Duration
+			d, err = time.ParseDuration(text)
+			n = int64(d)
+		case secondsFields[name]:
+			n, err = parseSeconds(text)
+		case sizeFields[name]:
+			n, err = ParseSize(text)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		obj[name] = json.RawMessage(strconv.FormatInt(n, 10))
+	}
+
+	return json.Marshal(obj)
+}
+
+// parseSeconds parses a number of seconds, given plain or as a
+// duration of whole seconds like 90s or 2m.
+func parseSeconds(s string) (int64, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d%time.Second != 0 {
+		return 0, fmt.Errorf("duration %s is not whole seconds", s)
+	}
+
+	return int64(d / time.Second), nil
+}
+
+// sizeUnits are the units taken by ParseSize.
+var sizeUnits = []struct {
+	suffix string
+	n      int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30},
+	{"b", 1},
+}
+
+// ParseSize parses a byte size, like 512, 64KB, 64MB or 1.5GiB. The
+// units K, M and G count powers of 1024 whether written as K, KB or
+// KiB, and are case-insensitive. The function returns the number of
+// bytes and an error.
+func ParseSize(s string) (int64, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(text, u.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, u.suffix))
+			mult = u.n
+			break
+		}
+	}
+
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n * mult, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return int64(f * float64(mult)), nil
+}
+
+// LoadEnv sets the configuration fields given in the environment.
+// The variable of a field is STRATUM_ followed by its JSON name in
+// upper case, like STRATUM_BASE_URL and STRATUM_RATE_LIMIT. Durations
+// are given like 1m30s, Timeout in seconds or like 90s, sizes like
+// 64MB, see ParseSize, lists are comma separated, and maps are comma
+// separated key=value pairs, like STRATUM_DEFAULT_HEADERS. Fields
+// holding structs, like Retry, are only set by code or config file.
+// The function returns an error if a variable can't be parsed.
+func (c *Client) LoadEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		key := envPrefix + strings.ToUpper(name)
+		s, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		var n int64
+		var err error
+		switch {
+		case secondsFields[name]:
+			n, err = parseSeconds(s)
+			s = strconv.FormatInt(n, 10)
+		case sizeFields[name]:
+			n, err = ParseSize(s)
+			s = strconv.FormatInt(n, 10)
+		}
+		if err != nil {
+			return fmt.Errorf("environment %s: %v", key, err)
+		}
+		if err := setField(v.Field(i), s); err != nil {
+			return fmt.Errorf("environment %s: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// durationType is the type of time.Duration fields.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField sets a field of a supported kind from its text form.
+func setField(f reflect.Value, s string) error {
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		list := splitList(s)
+		slice := reflect.MakeSlice(f.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := setField(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		f.Set(slice)
+	case reflect.Map:
+		if f.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", f.Type())
+		}
+		m := reflect.MakeMap(f.Type())
+		for _, item := range splitList(s) {
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid pair %q: missing =", item)
+			}
+			v := reflect.New(f.Type().Elem()).Elem()
+			if err := setField(v, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(f.Type().Key()), v)
+		}
+		f.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+
+	return nil
+}
+
+// Validate checks the configuration of the client without connecting
+// to the server. Open calls it before anything else. The function
+// returns the first problem found as an error, otherwise nil.
+func (c *Client) Validate() error {
+	if c.Token == "" && c.Auth == nil && c.OIDC == nil {
+		if c.Username == "" {
+			return fmt.Errorf("missing: Username")
+		}
+		if c.Password == "" && c.passwordProvider() == nil {
+			return fmt.Errorf("missing: Password")
+		}
+	}
+	if c.BaseURL == "" {
+		return fmt.Errorf("missing: BaseURL")
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid BaseURL %q: scheme must be http or https", c.BaseURL)
+	}
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return fmt.Errorf("missing: ClientCertFile and ClientKeyFile must be set together")
+	}
+	if c.HistoryTable != "" && strings.Count(c.HistoryTable, "%s") != 1 {
+		return fmt.Errorf("invalid HistoryTable %q: must hold one %%s", c.HistoryTable)
+	}
+	switch c.VerifyOrder {
+	case "", VerifyOrderError, VerifyOrderWarn:
+	default:
+		return fmt.Errorf("invalid VerifyOrder %q: must be %s or %s", c.VerifyOrder, VerifyOrderError, VerifyOrderWarn)
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("invalid RateLimit: must not be negative")
+	}
+	for _, f := range []struct {
+		name string
+		n    int64
+	}{
+		{"Timeout", int64(c.Timeout)},
+		{"RateBurst", int64(c.RateBurst)},
+		{"MaxErrorBody", int64(c.MaxErrorBody)},
+		{"MaxIdleConnsPerHost", int64(c.MaxIdleConnsPerHost)},
+		{"IdleConnTimeout", int64(c.IdleConnTimeout)},
+		{"DialTimeout", int64(c.DialTimeout)},
+		{"TLSHandshakeTimeout", int64(c.TLSHandshakeTimeout)},
+		{"CompressThreshold", int64(c.CompressThreshold)},
+	} {
+		if f.n < 0 {
+			return fmt.Errorf("invalid %s: must not be negative", f.name)
+		}
+	}
+
+	return nil
+}
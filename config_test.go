@@ -0,0 +1,105 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stratum.json")
+	content := `{"username": "file", "password": "secret", "base_url": "https://file/stratum/v1", "rate_limit": 5, "retry": {"max_attempts": 4}}`
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Setenv("STRATUM_USERNAME", "env")
+	t.Setenv("STRATUM_DIAL_TIMEOUT", "5s")
+	t.Setenv("STRATUM_DEFAULT_HEADERS", "X-Tenant-ID=acme, X-Feature=beta")
+	t.Setenv("STRATUM_SUCCESS_STATUS", "200, 202")
+
+	c, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if c.Username != "env" || c.Password != "secret" || c.RateLimit != 5 || c.Retry.MaxAttempts != 4 {
+		t.Errorf("layers: got %s %s %g %+v", c.Username, c.Password, c.RateLimit, c.Retry)
+	}
+	if c.DialTimeout != 5*time.Second || len(c.SuccessStatus) != 2 || c.SuccessStatus[1] != 202 {
+		t.Errorf("env: got %s %v", c.DialTimeout, c.SuccessStatus)
+	}
+	if len(c.DefaultHeaders) != 2 || c.DefaultHeaders["X-Tenant-ID"] != "acme" || c.DefaultHeaders["X-Feature"] != "beta" {
+		t.Errorf("env map: got %v", c.DefaultHeaders)
+	}
+	if c.Timeout != 30 || c.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("defaults: got %d %d", c.Timeout, c.MaxIdleConnsPerHost)
+	}
+
+	t.Setenv("STRATUM_RATE_BURST", "many")
+	if _, err := LoadConfig(file); err == nil {
+		t.Error("load with bad STRATUM_RATE_BURST: expected error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		edit func(c *Client)
+		ok   bool
+	}{
+		{"valid", func(c *Client) {}, true},
+		{"token", func(c *Client) { c.Username, c.Password, c.Token = "", "", "jwt" }, true},
+		{"scheme", func(c *Client) { c.BaseURL = "ftp://server/stratum/v1" }, false},
+		{"cert without key", func(c *Client) { c.ClientCertFile = "cert.pem" }, false},
+		{"history table", func(c *Client) { c.HistoryTable = "audit" }, false},
+		{"negative timeout", func(c *Client) { c.DialTimeout = -time.Second }, false},
+		{"negative rate", func(c *Client) { c.RateLimit = -0.5 }, false},
+	} {
+		c := DefaultConfig()
+		c.Username, c.Password, c.BaseURL = "user", "secret", "https://server/stratum/v1"
+		tt.edit(c)
+		if err := c.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
+
+func TestHumanConfigValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stratum.json")
+	content := `{"username": "u", "password": "p", "base_url": "https://file/stratum/v1",
+		"timeout": "90s", "max_error_body": "64MB", "max_url_length": 4096, "dial_timeout": "2s",
+		"idle_conn_timeout": 1000000000, "retry": {"initial_backoff": "250ms", "max_backoff": "1m"}}`
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Setenv("STRATUM_COMPRESS_THRESHOLD", "1.5KiB")
+
+	c, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if c.Timeout != 90 || c.MaxErrorBody != 64<<20 || c.MaxURLLength != 4096 || c.DialTimeout != 2*time.Second || c.IdleConnTimeout != time.Second {
+		t.Errorf("client: got timeout %d, max error body %d, max URL length %d, dial timeout %s, idle timeout %s",
+			c.Timeout, c.MaxErrorBody, c.MaxURLLength, c.DialTimeout, c.IdleConnTimeout)
+	}
+	if c.Retry.InitialBackoff != 250*time.Millisecond || c.Retry.MaxBackoff != time.Minute {
+		t.Errorf("retry: got %+v", c.Retry)
+	}
+	if c.CompressThreshold != 1536 {
+		t.Errorf("compress threshold: got %d", c.CompressThreshold)
+	}
+
+	for _, bad := range []string{`{"timeout": "1.5s"}`, `{"max_error_body": "lots"}`, `{"retry": {"max_backoff": "soon"}}`} {
+		if err := json.Unmarshal([]byte(bad), &Client{}); err == nil {
+			t.Errorf("%s: no error", bad)
+		}
+	}
+
+	sizes := map[string]int64{"512": 512, "64k": 64 << 10, "64KB": 64 << 10, "2 MiB": 2 << 20, "1GB": 1 << 30, "10b": 10}
+	for s, want := range sizes {
+		if got, err := ParseSize(s); err != nil || got != want {
+			t.Errorf("ParseSize(%q): got %d, %v, want %d", s, got, err, want)
+		}
+	}
+}
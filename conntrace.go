@@ -0,0 +1,103 @@
+package stratumclient
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConnEvent is a connection level event of an API request, for
+// diagnosing where the time of slow requests goes. Kind is one of
+//
+//	dns      the host name was resolved, Addr holds the addresses
+//	connect  a connection to Addr was established
+//	tls      the TLS handshake with Addr completed
+//	reused   an idle connection to Addr was reused
+//
+// Duration is the time the DNS lookup, connect or handshake took,
+// or how long a reused connection was idle. Err is set if the step
+// failed.
+type ConnEvent struct {
+	Kind     string
+	Addr     string
+	Duration time.Duration
+	Err      error
+}
+
+// ConnObserver is implemented by MetricsCollectors which also observe
+// connection events, like PrometheusMetrics.
+type ConnObserver interface {
+	ObserveConnEvent(e *ConnEvent)
+}
+
+// connEvent passes a connection event to the OnConnEvent hook, the
+// Metrics collector and the debug log.
+func (c *Client) connEvent(e *ConnEvent) {
+	if c.OnConnEvent != nil {
+		c.OnConnEvent(e)
+	}
+	if m, ok := c.Metrics.(ConnObserver); ok {
+		m.ObserveConnEvent(e)
+	}
+	c.debug("stratum connection", "event", e.Kind, "addr", e.Addr, "duration", e.Duration, "error", e.Err)
+}
+
+// clientTrace returns the trace hooks of a request, which count TLS
+// handshakes and report connection events.
+func (c *Client) clientTrace() *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var dnsStart, tlsStart time.Time
+	connStart := make(map[string]time.Time)
+	var host string
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			mu.Lock()
+			dnsStart, host = time.Now(), info.Host
+			mu.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mu.Lock()
+			e := &ConnEvent{Kind: "dns", Addr: host, Duration: time.Since(dnsStart), Err: info.Err}
+			mu.Unlock()
+			if len(info.Addrs) > 0 {
+				addrs := make([]string, len(info.Addrs))
+				for i, a := range info.Addrs {
+					addrs[i] = a.String()
+				}
+				e.Addr = strings.Join(addrs, ",")
+			}
+			c.connEvent(e)
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			connStart[addr] = time.Now()
+			mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			d := time.Since(connStart[addr])
+			mu.Unlock()
+			c.connEvent(&ConnEvent{Kind: "connect", Addr: addr, Duration: d, Err: err})
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			tlsStart = time.Now()
+			mu.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			c.stats.countHandshake(state, err)
+			mu.Lock()
+			e := &ConnEvent{Kind: "tls", Addr: state.ServerName, Duration: time.Since(tlsStart), Err: err}
+			mu.Unlock()
+			c.connEvent(e)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.connEvent(&ConnEvent{Kind: "reused", Addr: info.Conn.RemoteAddr().String(), Duration: info.IdleTime})
+			}
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package stratumclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConnEvents(t *testing.T) {
+	_, srv := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	var mu sync.Mutex
+	kinds := map[string]int{}
+	m := NewPrometheusMetrics()
+	cl := &Client{Username: "user", Password: "secret", BaseURL: strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/stratum/v1", Metrics: m}
+	cl.OnConnEvent = func(e *ConnEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if e.Err == nil {
+			kinds[e.Kind]++
+		}
+	}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := cl.Get("host/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, kind := range []string{"dns", "connect", "reused"} {
+		if kinds[kind] == 0 {
+			t.Errorf("no %s event in %v", kind, kinds)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if want := `stratum_connection_events_total{event="reused",result="success"} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("missing %s in:\n%s", want, rec.Body.String())
+	}
+}
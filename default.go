@@ -0,0 +1,119 @@
+package stratumclient
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrNoDefault is returned by the package-level functions when no
+// default client is set.
+var ErrNoDefault = errors.New("missing: default client, see SetDefault")
+
+// defaultClient is the client used by the package-level functions.
+var defaultClient atomic.Pointer[Client]
+
+// SetDefault sets the client used by the package-level functions like
+// Get and Post, for small scripts and tools which don't want to pass
+// a client around. The client should be opened. A nil client unsets
+// the default. It is safe to call concurrently with the package-level
+// functions; calls already started keep using the previous client.
+// The function returns the previous default client, or nil.
+//
+//	c := &stratumclient.Client{BaseURL: base, Username: user, Password: pw}
+//	if err := c.Open(); err != nil {
+//		log.Fatal(err)
+//	}
+//	stratumclient.SetDefault(c)
+//	err := stratumclient.Get("host/", &hosts)
+func SetDefault(c *Client) *Client {
+	return defaultClient.Swap(c)
+}
+
+// Default returns the default client set by SetDefault, or nil.
+func Default() *Client {
+	return defaultClient.Load()
+}
+
+// defaultOrErr returns the default client, or ErrNoDefault if none is
+// set.
+func defaultOrErr() (*Client, error) {
+	c := defaultClient.Load()
+	if c == nil {
+		return nil, ErrNoDefault
+	}
+
+	return c, nil
+}
+
+// Get will perform a GET API call with the default client, see
+// Client.Get. The function returns ErrNoDefault if no default client
+// is set, otherwise an error upon errors or nil.
+func Get(query string, resp interface{}, opts ...CallOption) error {
+	c, err := defaultOrErr()
+	if err != nil {
+		return err
+	}
+
+	return c.Get(query, resp, opts...)
+}
+
+// Post will perform a POST API call with the default client, see
+// Client.Post. The function returns ErrNoDefault if no default client
+// is set, otherwise an error upon errors or nil.
+func Post(query string, post, resp interface{}, opts ...CallOption) error {
+	c, err := defaultOrErr()
+	if err != nil {
+		return err
+	}
+
+	return c.Post(query, post, resp, opts...)
+}
+
+// Put will perform a PUT API call with the default client, see
+// Client.Put. The function returns ErrNoDefault if no default client
+// is set, otherwise an error upon errors or nil.
+func Put(query string, post, resp interface{}, opts ...CallOption) error {
+	c, err := defaultOrErr()
+	if err != nil {
+		return err
+	}
+
+	return c.Put(query, post, resp, opts...)
+}
+
+// Delete will perform a DELETE API call with the default client, see
+// Client.Delete. The function returns ErrNoDefault if no default
+// client is set, otherwise an error upon errors or nil.
+func Delete(query string, post, resp interface{}, opts ...CallOption) error {
+	c, err := defaultOrErr()
+	if err != nil {
+		return err
+	}
+
+	return c.Delete(query, post, resp, opts...)
+}
+
+// Call will perform an API call with the default client, see
+// Client.Call. The function returns the response body and an error,
+// ErrNoDefault if no default client is set.
+func Call(method, query string, data interface{}, opts ...CallOption) ([]byte, error) {
+	c, err := defaultOrErr()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Call(method, query, data, opts...)
+}
+
+// GetRows will perform a GET API call with the default client and
+// return the response rows as maps, see Client.GetRows. The function
+// returns the rows and an error, ErrNoDefault if no default client is
+// set.
+func GetRows(query string, opts ...CallOption) (Rows, error) {
+	c, err := defaultOrErr()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetRows(query, opts...)
+}
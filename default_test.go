@@ -0,0 +1,46 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestDefaultClient(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	SetDefault(nil)
+	if err := Get("host/", nil); !errors.Is(err, ErrNoDefault) {
+		t.Fatalf("unset: got %v, want ErrNoDefault", err)
+	}
+
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []testPlatform{{ID: 1, Name: "linux"}})
+	})
+	if prev := SetDefault(cl); prev != nil {
+		t.Fatalf("set: got previous %v, want nil", prev)
+	}
+	if Default() != cl {
+		t.Fatalf("default: got another client")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rows []*testPlatform
+			if err := Get("platform/", &rows); err != nil || len(rows) != 1 || rows[0].Name != "linux" {
+				t.Errorf("get: got %v, %v", rows, err)
+			}
+			SetDefault(cl)
+		}()
+	}
+	wg.Wait()
+
+	rows, err := GetRows("platform/")
+	if err != nil || len(rows) != 1 || rows[0].String("name") != "linux" {
+		t.Fatalf("rows: got %v, %v", rows, err)
+	}
+}
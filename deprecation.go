@@ -0,0 +1,105 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Deprecation holds the deprecation related headers the server sent
+// for an endpoint.
+type Deprecation struct {
+	Endpoint    string
+	Warning     string
+	Deprecation string
+	Sunset      string
+	Link        string
+}
+
+// Stringer function for Deprecation fmt.String() compliant.
+func (d *Deprecation) String() string {
+	ret := []string{fmt.Sprintf("endpoint %s is deprecated", d.Endpoint)}
+	if d.Deprecation != "" && d.Deprecation != "true" {
+		ret = append(ret, fmt.Sprintf("since: %s", d.Deprecation))
+	}
+	if d.Sunset != "" {
+		ret = append(ret, fmt.Sprintf("sunset: %s", d.Sunset))
+	}
+	if d.Warning != "" {
+		ret = append(ret, fmt.Sprintf("warning: %s", d.Warning))
+	}
+	if d.Link != "" {
+		ret = append(ret, fmt.Sprintf("link: %s", d.Link))
+	}
+
+	return strings.Join(ret, ": ")
+}
+
+// deprecationLog keeps track of the endpoints already reported as
+// deprecated.
+type deprecationLog struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// checkDeprecation reports deprecation headers found in the response
+// headers, once per endpoint. The report is passed to OnDeprecation
+// if set, otherwise it is logged with the client's Logger. Nothing
+// is reported if neither is set.
+func (c *Client) checkDeprecation(query string, h http.Header) {
+	if c.OnDeprecation == nil && c.Logger == nil {
+		return
+	}
+
+	d := &Deprecation{
+		Endpoint:    endpoint(query),
+		Warning:     deprecationWarning(h.Values("Warning")),
+		Deprecation: h.Get("Deprecation"),
+		Sunset:      h.Get("Sunset"),
+		Link:        h.Get("Link"),
+	}
+	if d.Warning == "" && d.Deprecation == "" && d.Sunset == "" {
+		return
+	}
+
+	if c.deprecations != nil {
+		c.deprecations.mu.Lock()
+		seen := c.deprecations.seen[d.Endpoint]
+		c.deprecations.seen[d.Endpoint] = true
+		c.deprecations.mu.Unlock()
+		if seen {
+			return
+		}
+	}
+
+	if c.OnDeprecation != nil {
+		c.OnDeprecation(d)
+		return
+	}
+	c.Logger.Warn("stratum endpoint deprecated", "endpoint", d.Endpoint, "deprecation", d.Deprecation, "sunset", d.Sunset, "warning", d.Warning, "link", d.Link)
+}
+
+// deprecationWarning returns the first Warning header value which
+// flags a deprecation, either with the miscellaneous persistent
+// warning code 299 or by mentioning deprecation in its text. Other
+// warnings, like 110 Response is stale, are ignored.
+func deprecationWarning(values []string) string {
+	for _, v := range values {
+		if strings.HasPrefix(v, "299 ") || strings.Contains(strings.ToLower(v), "deprecat") {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// endpoint returns the endpoint part of a query, which is the query
+// without its parameters and surrounding slashes.
+func endpoint(query string) string {
+	if i := strings.IndexByte(query, '?'); i >= 0 {
+		query = query[:i]
+	}
+
+	return strings.Trim(query, "/")
+}
@@ -0,0 +1,51 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDeprecation(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "//stratum/v1/platform/" {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Sunset", "Wed, 11 Nov 2026 23:59:59 GMT")
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	var got []*Deprecation
+	cl.OnDeprecation = func(d *Deprecation) {
+		got = append(got, d)
+	}
+
+	for _, q := range []string{"platform/?select=id", "platform/", "host/"} {
+		if err := cl.Get(q, nil); err != nil {
+			t.Fatalf("get %s: %v", q, err)
+		}
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("deprecations: got %d, want 1", len(got))
+	}
+	if got[0].Endpoint != "platform" || got[0].Sunset == "" {
+		t.Fatalf("deprecation: got %+v", got[0])
+	}
+}
+
+func TestDeprecationWarning(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{`110 - "Response is stale"`}, ""},
+		{[]string{`110 - "Response is stale"`, `299 - "Deprecated API"`}, `299 - "Deprecated API"`},
+		{[]string{`199 stratum "endpoint is deprecated"`}, `199 stratum "endpoint is deprecated"`},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := deprecationWarning(tt.values); got != tt.want {
+			t.Errorf("warning %q: got %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
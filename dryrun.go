@@ -0,0 +1,52 @@
+package stratumclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// dryRunMu serializes additions to dry run plans, which may be
+// shared by several clients.
+var dryRunMu sync.Mutex
+
+// dryRunPlan returns the plan calls are added to instead of being
+// sent, or nil if the call isn't a dry run.
+func (c *Client) dryRunPlan(o *callOptions) *Plan {
+	if o.dryRun != nil {
+		return o.dryRun
+	}
+
+	return c.DryRun
+}
+
+// dryRun adds a call to plan, and returns an empty result in place
+// of the response.
+func (c *Client) dryRun(plan *Plan, method, query string, body *payload) (*http.Response, error) {
+	if body.upload != nil {
+		return nil, fmt.Errorf("dry run %s %s: uploads can't be added to a plan", method, query)
+	}
+
+	var data interface{}
+	if body.data != nil {
+		data = body.data
+	}
+	dryRunMu.Lock()
+	err := plan.Add(method, query, data)
+	dryRunMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if c.Logger != nil {
+		c.debug("stratum dry run", "method", method, "query", query, "data", string(c.RedactPayload(body.data)))
+	}
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("[]")),
+	}, nil
+}
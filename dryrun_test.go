@@ -0,0 +1,50 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("dry run sent %s %s", r.Method, r.URL)
+		}
+		writeJSON(w, http.StatusOK, []map[string]int{{"id": 1}})
+	})
+	cl.DryRun = NewPlan("alice")
+
+	var rows []map[string]int
+	if err := cl.Get("host/", &rows); err != nil || len(rows) != 1 {
+		t.Fatalf("get: got %v, %v", rows, err)
+	}
+	if err := cl.Put("host/?where=id=1", map[string]string{"name": "db1"}, &rows); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if len(rows) != 0 {
+		t.Fatalf("put: got rows %v, want none", rows)
+	}
+	if err := cl.Delete("host/", nil, nil, WithQueryParam("where", "id=2")); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	steps := cl.DryRun.Steps
+	if len(steps) != 2 {
+		t.Fatalf("steps: got %d, want 2", len(steps))
+	}
+	if s := steps[0]; s.Method != "PUT" || s.Query != "host/?where=id=1" || string(s.Data) != `{"name":"db1"}` {
+		t.Fatalf("step 1: got %+v", s)
+	}
+	if s := steps[1]; s.Method != "DELETE" || s.Query != "host/?where=id%3D2" {
+		t.Fatalf("step 2: got %+v", s)
+	}
+
+	other := NewPlan("bob")
+	cl.DryRun = nil
+	if err := cl.Post("host/", map[string]string{"name": "db2"}, nil, WithDryRun(other)); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if len(other.Steps) != 1 {
+		t.Fatalf("per call plan: got %d steps, want 1", len(other.Steps))
+	}
+}
@@ -0,0 +1,43 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// envelope is the wrapped form of a result, which servers may send
+// instead of a bare array when asked, like with Pages.Envelope.
+type envelope struct {
+	Rows  json.RawMessage `json:"rows"`
+	Total *int            `json:"total"`
+}
+
+// unwrapEnvelope returns the rows of a response body wrapped in an
+// envelope like {"rows": [...], "total": N}, and the total row count
+// or -1 if the envelope has none. Other bodies, like bare arrays, are
+// returned as is with a total of -1.
+func unwrapEnvelope(content []byte) ([]byte, int) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return content, -1
+	}
+
+	var e envelope
+	if err := json.Unmarshal(trimmed, &e); err != nil || e.Rows == nil {
+		return content, -1
+	}
+	if e.Total == nil {
+		return e.Rows, -1
+	}
+
+	return e.Rows, *e.Total
+}
+
+// wantsRows reports whether resp is a pointer to a slice, which
+// enveloped responses are unwrapped for.
+func wantsRows(resp interface{}) bool {
+	t := reflect.TypeOf(resp)
+
+	return t != nil && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Slice
+}
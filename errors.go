@@ -0,0 +1,115 @@
+package stratumclient
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sentinel errors matching an ErrorResponse with the corresponding
+// HTTP status code using errors.Is.
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// ErrClosed is returned by API calls on a client closed with Close.
+var ErrClosed = errors.New("client closed")
+
+// ErrCircuitOpen is returned by API calls while the client's
+// CircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// statusErrors maps status codes to their sentinel errors.
+var statusErrors = map[int]error{
+	http.StatusBadRequest:      ErrBadRequest,
+	http.StatusUnauthorized:    ErrUnauthorized,
+	http.StatusForbidden:       ErrForbidden,
+	http.StatusNotFound:        ErrNotFound,
+	http.StatusConflict:        ErrConflict,
+	http.StatusTooManyRequests: ErrTooManyRequests,
+}
+
+// Is reports whether the ErrorResponse matches target, which makes
+// errors.Is(err, ErrNotFound) and friends work on API errors.
+func (e *ErrorResponse) Is(target error) bool {
+	return target != nil && statusErrors[e.StatusCode] == target
+}
+
+// IsNotFound reports whether err is an API error with status 404.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports whether err is an API error with status 401.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden reports whether err is an API error with status 403.
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsConflict reports whether err is an API error with status 409.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsBackendError reports whether err is an API error carrying an
+// error from the API backend (PostgreSQL).
+func IsBackendError(err error) bool {
+	var e *ErrorResponse
+	return errors.As(err, &e) && e.Backend != nil
+}
+
+// StatusCode returns the HTTP status code of an API error, or 0 if
+// err isn't an API error.
+func StatusCode(err error) int {
+	var e *ErrorResponse
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+
+	return 0
+}
+
+// maxSummary is the maximum length of an error page summary.
+const maxSummary = 200
+
+// titleTag matches the title of an HTML page.
+var titleTag = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// errorSummary returns a one line summary of a non-JSON error
+// response body, like the error page of a gateway: the title of an
+// HTML page, or else the first non-empty line of text.
+func errorSummary(body []byte) string {
+	var summary string
+	text := string(body)
+	if m := titleTag.FindStringSubmatch(text); m != nil {
+		summary = strings.Join(strings.Fields(html.UnescapeString(m[1])), " ")
+	} else if !strings.HasPrefix(strings.TrimSpace(text), "<") {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.Join(strings.Fields(line), " "); line != "" {
+				summary = line
+				break
+			}
+		}
+	}
+	if len(summary) > maxSummary {
+		summary = summary[:maxSummary]
+		for !utf8.ValidString(summary) {
+			summary = summary[:len(summary)-1]
+		}
+		summary += "..."
+	}
+
+	return summary
+}
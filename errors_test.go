@@ -0,0 +1,140 @@
+package stratumclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestErrorClassification(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "//stratum/v1/missing/":
+			w.WriteHeader(http.StatusNotFound)
+		case "//stratum/v1/conflict/":
+			writeJSON(w, http.StatusConflict, &ErrorResponse{
+				Message: "duplicate key",
+				Backend: &BackendError{Code: "23505", Message: "duplicate key value"},
+			})
+		}
+	})
+
+	err := cl.Get("missing/", nil)
+	if !IsNotFound(err) || IsConflict(err) || IsBackendError(err) {
+		t.Fatalf("missing: unexpected classification of %v", err)
+	}
+	if err.Error() != "404 Not Found" {
+		t.Fatalf("missing: got %q", err)
+	}
+
+	err = cl.Post("conflict/", map[string]string{"name": "x"}, nil)
+	if !IsConflict(err) || !IsBackendError(err) || IsNotFound(err) {
+		t.Fatalf("conflict: unexpected classification of %v", err)
+	}
+	wrapped := fmt.Errorf("create: %w", err)
+	if !errors.Is(wrapped, ErrConflict) || StatusCode(wrapped) != http.StatusConflict {
+		t.Fatalf("wrapped conflict: unexpected classification of %v", wrapped)
+	}
+	if IsUnauthorized(errors.New("401 Unauthorized")) {
+		t.Fatalf("plain error classified as unauthorized")
+	}
+}
+
+func TestMaxErrorBody(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadGateway, &ErrorResponse{Message: strings.Repeat("x", 1000)})
+	})
+	cl.MaxErrorBody = 100
+
+	err := cl.Get("host/", nil)
+	var eresp *ErrorResponse
+	if !errors.As(err, &eresp) {
+		t.Fatalf("get: got %v, want ErrorResponse", err)
+	}
+	if eresp.StatusCode != http.StatusBadGateway || eresp.Message != "error response exceeds 100 bytes" {
+		t.Fatalf("error: got %+v", eresp)
+	}
+
+	body, truncated, err := cl.readErrorBody(strings.NewReader("short"))
+	if err != nil || truncated || string(body) != "short" {
+		t.Fatalf("short body: got %q, %t, %v", body, truncated, err)
+	}
+}
+
+func TestErrorSummary(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"<html><head><title>502 Bad\n  Gateway &amp; more</title></head><body>nginx</body></html>", "502 Bad Gateway & more"},
+		{"\n\n  upstream connect error  \nreset reason: overflow", "upstream connect error"},
+		{"<html><body>no title</body></html>", ""},
+		{strings.Repeat("é", 150), strings.Repeat("é", 100) + "..."},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := errorSummary([]byte(tt.body)); got != tt.want {
+			t.Errorf("summary %q: got %q, want %q", tt.body, got, tt.want)
+		}
+	}
+
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "<html><title>Upstream timed out</title></html>")
+	})
+	err := cl.Get("host/", nil)
+	if err == nil || err.Error() != "502 Bad Gateway: Upstream timed out" {
+		t.Fatalf("get: got %v", err)
+	}
+}
+
+func TestErrorResponseContext(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-1")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/broken/"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "{not json")
+		case strings.HasSuffix(r.URL.Path, "/text/"):
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "maintenance mode")
+		}
+	})
+
+	err := cl.Get("broken/?name=x&token=secret", nil)
+	var eresp *ErrorResponse
+	if !errors.As(err, &eresp) {
+		t.Fatalf("broken: got %v, want ErrorResponse", err)
+	}
+	if eresp.StatusCode != http.StatusInternalServerError || eresp.Method != "GET" || eresp.RequestID != "req-1" {
+		t.Fatalf("broken: got %+v", eresp)
+	}
+	if string(eresp.Body) != "{not json" || !strings.HasPrefix(eresp.Message, "invalid JSON error response") {
+		t.Fatalf("broken: got body %q, message %q", eresp.Body, eresp.Message)
+	}
+	if strings.Contains(eresp.URL, "secret") || !strings.Contains(eresp.URL, "broken/") || !strings.Contains(eresp.URL, "name=x") {
+		t.Fatalf("broken: got URL %q", eresp.URL)
+	}
+
+	err = cl.Get("text/", nil)
+	if !errors.As(err, &eresp) {
+		t.Fatalf("text: got %v, want ErrorResponse", err)
+	}
+	if eresp.StatusCode != http.StatusOK || string(eresp.Body) != "maintenance mode" ||
+		eresp.Message != "server responded with unknown Content-Type: text/plain: maintenance mode" {
+		t.Fatalf("text: got %+v", eresp)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	u, _ := url.Parse("https://user:pw@example.com/stratum/v1/host/?Access_Token=abc&name=x")
+	got := redactURL(u)
+	if strings.Contains(got, "pw") || strings.Contains(got, "abc") || !strings.Contains(got, "name=x") {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,208 @@
+package stratumclient
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Site is a named Stratum server of a fleet, like a datacenter
+// running its own Stratum instance. The client holds the credentials,
+// TLS files and options of the site.
+type Site struct {
+	Name   string  `yaml:"name" json:"name"`
+	Client *Client `yaml:"client" json:"client"`
+}
+
+// Fleet runs the same API call against several Stratum servers
+// concurrently, for organizations with a Stratum instance per site.
+// Parallelism limits the number of sites called at once, and is
+// unlimited when zero.
+//
+// The remaining fields are shared by the sites when the fleet is
+// opened with Open. The hooks are given to the site clients which
+// have none of their own, and RateLimit and RateBurst limit the calls
+// to all sites together, replacing the limits of the site clients. A
+// 429 Too Many Requests from one site then pauses the calls to all.
+type Fleet struct {
+	Sites       []*Site `yaml:"sites" json:"sites"`
+	Parallelism int     `yaml:"parallelism" json:"parallelism"`
+
+	Logger        *slog.Logger       `yaml:"-" json:"-"`
+	Metrics       MetricsCollector   `yaml:"-" json:"-"`
+	OnDeprecation func(*Deprecation) `yaml:"-" json:"-"`
+	OnConnEvent   func(e *ConnEvent) `yaml:"-" json:"-"`
+	RateLimit     float64            `yaml:"rateLimit" json:"rate_limit"`
+	RateBurst     int                `yaml:"rateBurst" json:"rate_burst"`
+}
+
+// Open applies the shared settings of the fleet to the site clients
+// and opens them concurrently. Clients which are already opened only
+// get the shared rate limit. The function returns the joined errors
+// of the sites which failed to open, or nil if all succeeded.
+func (f *Fleet) Open() error {
+	shared := newLimiter(f.RateLimit, f.RateBurst)
+	errs := make([]error, len(f.Sites))
+	f.each(func(i int, s *Site) {
+		c := s.Client
+		if c == nil {
+			errs[i] = fmt.Errorf("site %s: missing: Client", s.Name)
+			return
+		}
+		if !c.opened {
+			if c.Logger == nil {
+				c.Logger = f.Logger
+			}
+			if c.Metrics == nil {
+				c.Metrics = f.Metrics
+			}
+			if c.OnDeprecation == nil {
+				c.OnDeprecation = f.OnDeprecation
+			}
+			if c.OnConnEvent == nil {
+				c.OnConnEvent = f.OnConnEvent
+			}
+			if err := c.Open(); err != nil {
+				errs[i] = fmt.Errorf("site %s: %w", s.Name, err)
+				return
+			}
+		}
+		if shared != nil {
+			c.setLimiter(shared)
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// SiteResult holds the outcome of an API call against one site of a
+// fleet. Rows is nil if the call failed, with the error in Err.
+type SiteResult[T any] struct {
+	Site string
+	Rows []*T
+	Err  error
+}
+
+// Site returns the site with the given name, or nil if the fleet has
+// none.
+func (f *Fleet) Site(name string) *Site {
+	for _, s := range f.Sites {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// each calls fn with every site of the fleet concurrently, and waits
+// for the calls to return.
+func (f *Fleet) each(fn func(i int, s *Site)) {
+	parallelism := f.Parallelism
+	if parallelism < 1 {
+		parallelism = len(f.Sites)
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, s := range f.Sites {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, s *Site) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i, s)
+		}(i, s)
+	}
+	wg.Wait()
+}
+
+// FleetGetAs will perform a GET API call against every site of the
+// fleet and return the response rows typed as T, tagged with the
+// site they came from.
+//
+//	results, err := stratumclient.FleetGetAs[Host](fleet, "host/?select=id,name")
+func FleetGetAs[T any](f *Fleet, query string, opts ...CallOption) ([]*SiteResult[T], error) {
+	return FleetUnmarshalAs[T](f, "GET", query, nil, opts...)
+}
+
+// FleetUnmarshalAs will perform an API call with the given method
+// against every site of the fleet and return the response rows typed
+// as T, in the order of the fleet's sites. The outcome of each site
+// is stored in its result. The function returns the results and the
+// joined errors of the failed sites, or nil if all succeeded.
+func FleetUnmarshalAs[T any](f *Fleet, method, query string, data interface{}, opts ...CallOption) ([]*SiteResult[T], error) {
+	results := make([]*SiteResult[T], len(f.Sites))
+	f.each(func(i int, s *Site) {
+		r := &SiteResult[T]{Site: s.Name}
+		if s.Client == nil {
+			r.Err = fmt.Errorf("missing: Client")
+		} else {
+			r.Rows, r.Err = UnmarshalAs[T](s.Client, method, query, data, opts...)
+		}
+		results[i] = r
+	})
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("site %s: %w", r.Site, r.Err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// SiteRow is a row of a merged fleet result, tagged with the site it
+// came from.
+type SiteRow[T any] struct {
+	Site string `json:"site"`
+	Row  *T     `json:"row"`
+}
+
+// Concat merges fleet results by concatenating the rows of the sites
+// in result order, each tagged with its site. Failed sites are left
+// out.
+func Concat[T any](results []*SiteResult[T]) []*SiteRow[T] {
+	var rows []*SiteRow[T]
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		for _, row := range r.Rows {
+			rows = append(rows, &SiteRow[T]{Site: r.Site, Row: row})
+		}
+	}
+
+	return rows
+}
+
+// UnionBy merges fleet results into one row per key, like rows
+// replicated between sites. The row of the first site in result order
+// is kept. Failed sites are left out.
+func UnionBy[T any, K comparable](results []*SiteResult[T], key func(row *T) K) []*SiteRow[T] {
+	return DedupBy(Concat(results), func(r *SiteRow[T]) K {
+		return key(r.Row)
+	})
+}
+
+// PreferPrimary merges fleet results like UnionBy, but keeps the row
+// of the primary site for keys found at several sites, so the primary
+// site is the source of truth and the other sites fill in what it
+// lacks. The rows of the primary site come first.
+func PreferPrimary[T any, K comparable](results []*SiteResult[T], primary string, key func(row *T) K) []*SiteRow[T] {
+	ordered := make([]*SiteResult[T], 0, len(results))
+	for _, r := range results {
+		if r.Site == primary {
+			ordered = append(ordered, r)
+		}
+	}
+	for _, r := range results {
+		if r.Site != primary {
+			ordered = append(ordered, r)
+		}
+	}
+
+	return UnionBy(ordered, key)
+}
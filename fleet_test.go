@@ -0,0 +1,134 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFleet(t *testing.T) {
+	oslo, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []*testPlatform{{ID: 1, Name: "Linux"}})
+	})
+	bergen, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []*testPlatform{{ID: 1, Name: "BSD"}, {ID: 2, Name: "Linux"}})
+	})
+	trondheim, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusInternalServerError, &ErrorResponse{Message: "down"})
+	})
+
+	fleet := &Fleet{Sites: []*Site{{"oslo", oslo}, {"bergen", bergen}, {"trondheim", trondheim}}, Parallelism: 2}
+	results, err := FleetGetAs[testPlatform](fleet, "platform/")
+	if err == nil || !strings.Contains(err.Error(), "site trondheim") {
+		t.Fatalf("error: got %v", err)
+	}
+	if len(results) != 3 || results[0].Site != "oslo" || len(results[0].Rows) != 1 || results[1].Rows[0].Name != "BSD" {
+		t.Fatalf("results: got %+v", results)
+	}
+	if results[2].Err == nil || results[2].Rows != nil {
+		t.Fatalf("failed site: got %+v", results[2])
+	}
+
+	if fleet.Site("bergen").Client != bergen || fleet.Site("tromsø") != nil {
+		t.Fatal("site lookup")
+	}
+}
+
+func TestFleetMerge(t *testing.T) {
+	results := []*SiteResult[testPlatform]{
+		{Site: "oslo", Rows: []*testPlatform{{ID: 1, Name: "Linux"}}},
+		{Site: "bergen", Rows: []*testPlatform{{ID: 2, Name: "BSD"}, {ID: 3, Name: "Linux"}}},
+		{Site: "trondheim", Err: fmt.Errorf("down")},
+	}
+	name := func(p *testPlatform) string { return p.Name }
+
+	for _, tt := range []struct {
+		merge string
+		rows  []*SiteRow[testPlatform]
+		want  string
+	}{
+		{"concat", Concat(results), "oslo:1 bergen:2 bergen:3"},
+		{"union", UnionBy(results, name), "oslo:1 bergen:2"},
+		{"primary", PreferPrimary(results, "bergen", name), "bergen:2 bergen:3"},
+	} {
+		var got []string
+		for _, r := range tt.rows {
+			got = append(got, fmt.Sprintf("%s:%d", r.Site, r.Row.ID))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: got %v, want %s", tt.merge, got, tt.want)
+		}
+	}
+}
+
+func TestFleetOpen(t *testing.T) {
+	var users sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); ok {
+			users.Store(user, true)
+		}
+		writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+	}))
+	t.Cleanup(srv.Close)
+
+	metrics := NewPrometheusMetrics()
+	fleet := &Fleet{
+		Sites: []*Site{
+			{"oslo", &Client{Username: "oslo", Password: "secret", BaseURL: srv.URL + "/stratum/v1"}},
+			{"bergen", &Client{Username: "bergen", Password: "secret", BaseURL: srv.URL + "/stratum/v1", RateLimit: 100}},
+		},
+		Metrics:   metrics,
+		RateLimit: 10,
+		RateBurst: 2,
+	}
+	if err := fleet.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	oslo, bergen := fleet.Site("oslo").Client, fleet.Site("bergen").Client
+	for _, user := range []string{"oslo", "bergen"} {
+		if _, ok := users.Load(user); !ok {
+			t.Errorf("site %s: not logged in with its own credentials", user)
+		}
+	}
+	if oslo.Metrics != metrics || bergen.Metrics != metrics {
+		t.Error("metrics: not shared")
+	}
+	if oslo.limiter == nil || oslo.limiter != bergen.limiter {
+		t.Error("rate limit: not shared")
+	}
+
+	fleet.Sites = append(fleet.Sites, &Site{Name: "tromsø"})
+	if err := fleet.Open(); err == nil || !strings.Contains(err.Error(), "site tromsø") {
+		t.Errorf("open without client: got %v", err)
+	}
+}
+
+func TestFleetOpenInUse(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	fleet := &Fleet{Sites: []*Site{{"oslo", cl}}, RateLimit: 1000, RateBurst: 10}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := cl.Get("host/", nil); err != nil {
+				t.Errorf("get: %v", err)
+				return
+			}
+		}
+	}()
+	if err := fleet.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	<-done
+
+	if cl.rateLimiter() == nil {
+		t.Error("rate limit: not set on the opened client")
+	}
+}
@@ -0,0 +1,58 @@
+package stratumclient
+
+import (
+	"encoding/json"
+)
+
+// GetAs will perform a GET API call to stratum and return the
+// response rows typed as T. It takes the client, a query string and
+// optional call options. The function returns the rows and an error.
+//
+//	platforms, err := stratumclient.GetAs[Platform](c, "platform/?select=id,name")
+func GetAs[T any](c *Client, query string, opts ...CallOption) ([]*T, error) {
+	return UnmarshalAs[T](c, "GET", query, nil, opts...)
+}
+
+// PostAs will perform a POST API call to stratum and return the
+// response rows typed as T. The post data should be a map or JSON
+// text when post data is provided, otherwise nil.
+func PostAs[T any](c *Client, query string, post interface{}, opts ...CallOption) ([]*T, error) {
+	return UnmarshalAs[T](c, "POST", query, post, opts...)
+}
+
+// PutAs will perform a PUT API call to stratum and return the
+// response rows typed as T. The post data should be a map or JSON
+// text when post data is provided, otherwise nil.
+func PutAs[T any](c *Client, query string, post interface{}, opts ...CallOption) ([]*T, error) {
+	return UnmarshalAs[T](c, "PUT", query, post, opts...)
+}
+
+// DeleteAs will perform a DELETE API call to stratum and return the
+// response rows typed as T. The post data should be a map or JSON
+// text when post data is provided, otherwise nil.
+func DeleteAs[T any](c *Client, query string, post interface{}, opts ...CallOption) ([]*T, error) {
+	return UnmarshalAs[T](c, "DELETE", query, post, opts...)
+}
+
+// UnmarshalAs will perform an API call to stratum with the given
+// method and return the response rows typed as T.
+func UnmarshalAs[T any](c *Client, method, query string, data interface{}, opts ...CallOption) ([]*T, error) {
+	var rows []*T
+	if err := c.Unmarshal(method, query, data, &rows, opts...); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// StreamAs will perform a GET API call to stratum like GetStream, and
+// call fn with each row decoded as T.
+func StreamAs[T any](c *Client, query string, fn func(row *T) error, opts ...CallOption) error {
+	return c.GetStream(query, func(raw json.RawMessage) error {
+		row := new(T)
+		if err := c.codec().Unmarshal(raw, row); err != nil {
+			return err
+		}
+		return fn(row)
+	}, opts...)
+}
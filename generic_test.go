@@ -0,0 +1,41 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testPlatform struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGenericHelpers(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []*testPlatform{{ID: 1, Name: "Linux"}, {ID: 2, Name: "BSD"}})
+	})
+
+	rows, err := GetAs[testPlatform](cl, "platform/")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(rows) != 2 || rows[1].Name != "BSD" {
+		t.Fatalf("rows: got %v", rows)
+	}
+
+	created, err := PostAs[testPlatform](cl, "platform/?returning=*", map[string]string{"name": "Linux"})
+	if err != nil || len(created) != 2 {
+		t.Fatalf("post: got %v %v", created, err)
+	}
+
+	n := 0
+	if err := StreamAs(cl, "platform/", func(p *testPlatform) error {
+		n += p.ID
+		return nil
+	}); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("stream sum: got %d", n)
+	}
+}
@@ -0,0 +1,46 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Head will perform a HEAD API call to stratum, which is answered like
+// a GET call without the response body, for checking that a query is
+// accepted or reading its X-Total-Count without fetching the rows.
+// The function returns the response metadata and an error.
+func (c *Client) Head(query string, opts ...CallOption) (*ResponseMeta, error) {
+	_, meta, err := c.CallWithMeta("HEAD", query, nil, opts...)
+
+	return meta, err
+}
+
+// Options will perform an OPTIONS API call to stratum. The function
+// returns the methods allowed on the query, as given by the Allow
+// response header, and an error.
+func (c *Client) Options(query string, opts ...CallOption) ([]string, error) {
+	_, meta, err := c.CallWithMeta("OPTIONS", query, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	for _, allow := range meta.Header.Values("Allow") {
+		for _, m := range splitList(allow) {
+			methods = append(methods, strings.ToUpper(strings.TrimSpace(m)))
+		}
+	}
+
+	return methods, nil
+}
+
+// safeMethod reports whether method only reads data, so calls with it
+// are not added to dry run plans and don't purge cached entries.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
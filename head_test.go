@@ -0,0 +1,50 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHeadOptions(t *testing.T) {
+	var methods []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.Method {
+		case "HEAD":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Total-Count", "42")
+			w.Header().Set("Content-Length", "120")
+		case "OPTIONS":
+			w.Header().Set("Allow", "GET, head,POST")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeJSON(w, http.StatusOK, []testPlatform{{ID: 1}})
+		}
+	})
+	cl.Cache = NewMemoryCache(time.Minute)
+	cl.DryRun = &Plan{}
+	cl.Get("platform/", nil)
+
+	meta, err := cl.Head("platform/")
+	if err != nil {
+		t.Fatalf("head: %v", err)
+	}
+	if meta.TotalCount != 42 {
+		t.Errorf("head: got count %d", meta.TotalCount)
+	}
+	allowed, err := cl.Options("platform/")
+	if err != nil {
+		t.Fatalf("options: %v", err)
+	}
+	if len(allowed) != 3 || allowed[1] != "HEAD" {
+		t.Errorf("options: got %q", allowed)
+	}
+
+	if _, ok := cl.Cache.Get("platform/"); !ok {
+		t.Error("cache purged by HEAD or OPTIONS")
+	}
+	if len(methods) != 3 || len(cl.DryRun.Steps) != 0 {
+		t.Errorf("sent %q, planned %d calls", methods, len(cl.DryRun.Steps))
+	}
+}
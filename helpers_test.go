@@ -0,0 +1,41 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testServer starts a local API server which answers login/v1 with a
+// token and hands every other request to handler. It returns an
+// opened client pointed at the server.
+func testServer(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600, TokenType: "bearer"})
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1"}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	return cl, srv
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		panic(fmt.Sprintf("encode: %v", err))
+	}
+}
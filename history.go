@@ -0,0 +1,54 @@
+package stratumclient
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Change is an entry of the change history of a row, as stored in
+// the audit table named by HistoryTable. Data holds the row as it
+// was after the change, and is nil for deletes.
+type Change struct {
+	RowID     string                 `json:"-"`
+	Time      time.Time              `json:"changed_at"`
+	User      string                 `json:"changed_by"`
+	Operation string                 `json:"operation"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// historyTable returns the audit table of table.
+func (c *Client) historyTable(table string) string {
+	format := c.HistoryTable
+	if format == "" {
+		format = "%s_history"
+	}
+
+	return fmt.Sprintf(format, strings.Trim(table, "/"))
+}
+
+// History returns the change history of the row of table with the
+// given id, oldest change first. The audit table is expected to have
+// the columns row_id, changed_at, changed_by, operation and data. The
+// function returns the changes and an error.
+func (c *Client) History(table string, id interface{}, opts ...CallOption) ([]*Change, error) {
+	rowID := valueText(id)
+	if rowID == "" {
+		return nil, fmt.Errorf("missing: id")
+	}
+
+	q := &Query{
+		Table:   c.historyTable(table),
+		Where:   &Cond{Column: "row_id", Op: "=", Value: rowID},
+		OrderBy: []string{"changed_at"},
+	}
+	changes, err := GetAs[Change](c, q.String(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("history of %s %s: %w", table, rowID, err)
+	}
+	for _, ch := range changes {
+		ch.RowID = rowID
+	}
+
+	return changes, nil
+}
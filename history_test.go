@@ -0,0 +1,42 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "//stratum/v1/host_history/" {
+			t.Errorf("path: got %q", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("where"); got != "row_id=42" {
+			t.Errorf("where: got %q, want row_id=42", got)
+		}
+		if got := r.URL.Query().Get("orderby"); got != "changed_at" {
+			t.Errorf("orderby: got %q, want changed_at", got)
+		}
+		writeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"row_id": 42, "changed_at": "2026-01-02T10:00:00Z", "changed_by": "alice", "operation": "insert", "data": map[string]interface{}{"name": "db1"}},
+			{"row_id": 42, "changed_at": "2026-03-04T10:00:00Z", "changed_by": "bob", "operation": "delete"},
+		})
+	})
+
+	changes, err := cl.History("host", 42)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes: got %d, want 2", len(changes))
+	}
+	if ch := changes[0]; ch.RowID != "42" || ch.User != "alice" || ch.Data["name"] != "db1" || ch.Time.Month() != 1 {
+		t.Fatalf("first change: got %+v", ch)
+	}
+	if ch := changes[1]; ch.Operation != "delete" || ch.Data != nil {
+		t.Fatalf("second change: got %+v", ch)
+	}
+
+	if _, err := cl.History("host", nil); err == nil {
+		t.Fatal("history: missing id gave no error")
+	}
+}
@@ -0,0 +1,39 @@
+package stratumclient
+
+import "strings"
+
+// IdempotencyKeyHeader is the request header carrying the idempotency
+// key of POST calls.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey makes a POST call send key as its
+// Idempotency-Key header, like the ID of the job creating the rows,
+// so the server creates them only once however often the call is
+// sent. POST calls with a key are retried and replayed like PUT
+// calls. The key is ignored for other methods.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// idempotencyKey returns the Idempotency-Key of a call: for POST
+// calls the key given with WithIdempotencyKey or WithHeader, or else a
+// random key if the client has IdempotencyKeys set, otherwise "".
+// All attempts of a call share the key.
+func (c *Client) idempotencyKey(method string, o *callOptions) string {
+	if !strings.EqualFold(method, "POST") {
+		return ""
+	}
+	if o.idempotencyKey != "" {
+		return o.idempotencyKey
+	}
+	if key := o.header.Get(IdempotencyKeyHeader); key != "" {
+		return key
+	}
+	if c.IdempotencyKeys {
+		return randomID()
+	}
+
+	return ""
+}
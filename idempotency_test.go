@@ -0,0 +1,47 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	var keys []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if r.Method == "POST" && len(keys)%2 == 1 {
+			writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	if err := cl.Post("platform/", map[string]string{"name": "x"}, nil, WithIdempotencyKey("job-42")); err != nil {
+		t.Fatalf("post with key: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "job-42" || keys[1] != "job-42" {
+		t.Fatalf("post with key: got keys %q", keys)
+	}
+
+	keys = nil
+	cl.IdempotencyKeys = true
+	if err := cl.Post("platform/", map[string]string{"name": "x"}, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("post: got keys %q, want one random key", keys)
+	}
+
+	keys = nil
+	if err := cl.Get("platform/", nil); err != nil || len(keys) != 1 || keys[0] != "" {
+		t.Fatalf("get: got keys %q, %v", keys, err)
+	}
+
+	keys = nil
+	cl.IdempotencyKeys = false
+	if err := cl.Post("platform/", map[string]string{"name": "x"}, nil); err == nil || len(keys) != 1 || keys[0] != "" {
+		t.Fatalf("post without key: got keys %q, %v", keys, err)
+	}
+}
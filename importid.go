@@ -0,0 +1,109 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ImportID is a stable external identifier of a row, made of the
+// table name and the values of the key columns of the row. Its
+// string form, like "host/name=db1" or "interface/host=db1,name=eth0",
+// is meant for tools which keep durable references into Stratum,
+// like Terraform import IDs and CMDB syncs.
+type ImportID struct {
+	Table string
+	Key   []*Param
+}
+
+// NewImportID returns the import ID of a row of table, decoded as a
+// JSON object. The key columns default to id. The function returns
+// the import ID and an error.
+func NewImportID(table string, row map[string]interface{}, keys ...string) (*ImportID, error) {
+	table = strings.Trim(table, "/")
+	if table == "" {
+		return nil, fmt.Errorf("missing: table")
+	}
+	if len(keys) == 0 {
+		keys = []string{"id"}
+	}
+
+	id := &ImportID{Table: table}
+	for _, key := range keys {
+		v, ok := row[key]
+		if !ok || v == nil {
+			return nil, fmt.Errorf("missing: key column %s in %s row", key, table)
+		}
+		id.Key = append(id.Key, &Param{Key: key, Value: valueText(v)})
+	}
+	id.sort()
+
+	return id, nil
+}
+
+// ParseImportID parses the string form of an import ID. The function
+// returns the import ID and an error.
+func ParseImportID(s string) (*ImportID, error) {
+	table, key, ok := strings.Cut(s, "/")
+	if !ok || table == "" || key == "" {
+		return nil, fmt.Errorf("invalid import ID %q: expected table/column=value", s)
+	}
+
+	id := &ImportID{Table: table}
+	for _, part := range strings.Split(key, ",") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid import ID %q: bad key %q", s, part)
+		}
+		k, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import ID %q: %v", s, err)
+		}
+		v, err = url.QueryUnescape(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import ID %q: %v", s, err)
+		}
+		id.Key = append(id.Key, &Param{Key: k, Value: v})
+	}
+	id.sort()
+
+	return id, nil
+}
+
+// sort orders the key columns by name, so equal rows always give the
+// same string form.
+func (id *ImportID) sort() {
+	sort.SliceStable(id.Key, func(i, j int) bool {
+		return id.Key[i].Key < id.Key[j].Key
+	})
+}
+
+// String returns the import ID as table/column=value, with the key
+// columns separated by comma. Names and values are query escaped.
+func (id *ImportID) String() string {
+	parts := make([]string, len(id.Key))
+	for i, p := range id.Key {
+		parts[i] = url.QueryEscape(p.Key) + "=" + url.QueryEscape(p.Value)
+	}
+
+	return id.Table + "/" + strings.Join(parts, ",")
+}
+
+// Where returns the where clause selecting the row.
+func (id *ImportID) Where() Expr {
+	where := make(And, len(id.Key))
+	for i, p := range id.Key {
+		where[i] = &Cond{Column: p.Key, Op: "=", Value: p.Value}
+	}
+
+	return where
+}
+
+// Query returns the API query fetching the row, like
+// "host/?where=name=db1".
+func (id *ImportID) Query() string {
+	q := &Query{Table: id.Table, Where: id.Where()}
+
+	return q.String()
+}
@@ -0,0 +1,39 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestImportID(t *testing.T) {
+	row := map[string]interface{}{"name": "eth0", "host": "db/1,a", "mtu": json.Number("1500")}
+
+	id, err := NewImportID("interface", row, "name", "host")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	s := id.String()
+	if want := "interface/host=db%2F1%2Ca,name=eth0"; s != want {
+		t.Fatalf("string: got %q, want %q", s, want)
+	}
+	if got, want := id.Query(), `interface/?where=host=%22db/1,a%22,name=eth0`; got != want {
+		t.Fatalf("query: got %q, want %q", got, want)
+	}
+
+	parsed, err := ParseImportID(s)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if parsed.String() != s {
+		t.Fatalf("round trip: got %q, want %q", parsed.String(), s)
+	}
+
+	if _, err := NewImportID("interface", row, "id"); err == nil {
+		t.Fatal("new: missing key column gave no error")
+	}
+	for _, bad := range []string{"", "host", "host/", "host/name", "host/=db1"} {
+		if _, err := ParseImportID(bad); err == nil {
+			t.Errorf("parse %q: got nil error", bad)
+		}
+	}
+}
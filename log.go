@@ -0,0 +1,163 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// redacted replaces credentials in logs.
+const redacted = "REDACTED"
+
+// debug logs a message at debug level with the client's Logger, if
+// any.
+func (c *Client) debug(msg string, args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Debug(msg, args...)
+	}
+}
+
+// redactHeader returns a copy of h with credentials masked.
+func redactHeader(h http.Header) http.Header {
+	r := h.Clone()
+	for _, k := range []string{"Authorization", "Cookie", "Proxy-Authorization"} {
+		if r.Get(k) != "" {
+			r.Set(k, redacted)
+		}
+	}
+
+	return r
+}
+
+// sensitiveParams are query parameters masked by redactURL.
+var sensitiveParams = []string{"token", "access_token", "password", "secret", "api_key"}
+
+// redactURL returns u as text with credentials masked: the user info,
+// and the values of query parameters named like tokens and
+// passwords.
+func redactURL(u *url.URL) string {
+	r := *u
+	if r.User != nil {
+		r.User = url.User(redacted)
+	}
+	if r.RawQuery != "" {
+		q := r.Query()
+		changed := false
+		for key := range q {
+			for _, p := range sensitiveParams {
+				if strings.EqualFold(key, p) {
+					q.Set(key, redacted)
+					changed = true
+				}
+			}
+		}
+		if changed {
+			r.RawQuery = q.Encode()
+		}
+	}
+
+	return r.String()
+}
+
+// maxLoggedBody is the number of bytes of a request body logged.
+const maxLoggedBody = 4096
+
+// RedactPayload returns the JSON post data with the values of the
+// fields named in RedactFields, and of fields named like passwords
+// and tokens, masked at any depth, for logging and auditing request
+// bodies. Field names match case-insensitively. Data which isn't
+// valid JSON is masked as a whole.
+func (c *Client) RedactPayload(data []byte) []byte {
+	return redactJSON(data, c.RedactFields)
+}
+
+// redactJSON returns the JSON text data with the values of fields,
+// and of the fields in sensitiveParams, masked.
+func redactJSON(data []byte, fields []string) []byte {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []byte(strconv.Quote(redacted))
+	}
+	masked := func(key string) bool {
+		for _, list := range [][]string{sensitiveParams, fields} {
+			for _, f := range list {
+				if strings.EqualFold(key, f) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, val := range v {
+				if masked(key) {
+					v[key] = redacted
+					continue
+				}
+				walk(val)
+			}
+		case []interface{}:
+			for _, val := range v {
+				walk(val)
+			}
+		}
+	}
+	walk(v)
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return []byte(strconv.Quote(redacted))
+	}
+
+	return out
+}
+
+// loggedBody returns the request body as logged at debug level,
+// masked by RedactPayload and cut at maxLoggedBody bytes, or "" if
+// nothing is logged or the body is streamed.
+func (c *Client) loggedBody(body *payload) string {
+	data := body.plain
+	if data == nil {
+		data = body.data
+	}
+	if c.Logger == nil || body.upload != nil || len(data) == 0 {
+		return ""
+	}
+
+	b := c.RedactPayload(data)
+	if len(b) > maxLoggedBody {
+		return string(b[:maxLoggedBody]) + "..."
+	}
+
+	return string(b)
+}
+
+// LogValue implements slog.LogValuer, so a client can be logged
+// without revealing its password.
+func (c *Client) LogValue() slog.Value {
+	password := ""
+	if c.Password != "" {
+		password = redacted
+	}
+
+	return slog.GroupValue(
+		slog.String("username", c.Username),
+		slog.String("password", password),
+		slog.String("base_url", c.BaseURL),
+		slog.String("user_agent", c.UserAgent),
+		slog.Int("timeout", c.Timeout),
+	)
+}
@@ -0,0 +1,75 @@
+package stratumclient
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	var buf bytes.Buffer
+	cl.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if err := cl.Get("platform/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	cl.Logger.Info("client", "config", cl)
+
+	out := buf.String()
+	for _, want := range []string{"stratum request", "method=GET", "status=200", "latency=", "Authorization:[REDACTED]", "password=REDACTED"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log: missing %q in %s", want, out)
+		}
+	}
+	for _, leak := range []string{"secret", "Bearer token"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("log: leaked %q in %s", leak, out)
+		}
+	}
+}
+
+func TestRedactPayload(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	var buf bytes.Buffer
+	cl.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cl.RedactFields = []string{"ssn"}
+
+	post := []map[string]interface{}{{"name": "alice", "SSN": "123-45-6789", "login": map[string]string{"password": "hunter2"}}}
+	if err := cl.Post("user/", post, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	plan := NewPlan("alice")
+	if err := cl.Post("user/", post, nil, WithDryRun(plan)); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "alice") || !strings.Contains(out, "stratum dry run") {
+		t.Errorf("log: missing body in %s", out)
+	}
+	for _, leak := range []string{"123-45-6789", "hunter2"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("log: leaked %q in %s", leak, out)
+		}
+	}
+
+	redactedPlan := plan.Redacted("ssn")
+	if got := string(redactedPlan.Steps[0].Data); strings.Contains(got, "123-45-6789") || strings.Contains(got, "hunter2") {
+		t.Errorf("plan: got %s", got)
+	}
+	if !strings.Contains(string(plan.Steps[0].Data), "hunter2") {
+		t.Errorf("plan: original changed")
+	}
+	if got := string(cl.RedactPayload([]byte("not json"))); got != `"REDACTED"` {
+		t.Errorf("invalid JSON: got %s", got)
+	}
+}
@@ -0,0 +1,66 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResponseMeta holds metadata of an API response. TotalCount is the
+// row count given by an X-Total-Count or Content-Range header, or -1
+// if the server didn't send one. RequestID is the correlation ID
+// of the request, as echoed by the server in an X-Request-ID header or
+// else as sent by the client.
+type ResponseMeta struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	TotalCount int
+	RequestID  string
+}
+
+// CallWithMeta will perform an API call to stratum like Call, and
+// also return the response metadata. The metadata is returned for
+// error responses too, and is nil if the server never responded. The
+// function returns the response body, the metadata and an error.
+func (c *Client) CallWithMeta(method, query string, data interface{}, opts ...CallOption) ([]byte, *ResponseMeta, error) {
+	var meta *ResponseMeta
+	opts = append(opts, func(o *callOptions) {
+		o.meta = &meta
+	})
+
+	body, err := c.Call(method, query, data, opts...)
+
+	return body, meta, err
+}
+
+// newResponseMeta returns the metadata of resp.
+func newResponseMeta(resp *http.Response) *ResponseMeta {
+	return &ResponseMeta{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header.Clone(),
+		TotalCount: totalCount(resp.Header),
+		RequestID:  responseRequestID(resp),
+	}
+}
+
+// totalCount returns the row count from an X-Total-Count header or
+// the total of a Content-Range header like "rows 0-99/1234". It
+// returns -1 if neither is present.
+func totalCount(h http.Header) int {
+	if v := h.Get("X-Total-Count"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	if v := h.Get("Content-Range"); v != "" {
+		if i := strings.LastIndexByte(v, '/'); i >= 0 {
+			if n, err := strconv.Atoi(strings.TrimSpace(v[i+1:])); err == nil {
+				return n
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,49 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCallWithMeta(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-42")
+		if r.URL.Path == "//stratum/v1/missing/" {
+			writeJSON(w, http.StatusNotFound, &ErrorResponse{Message: "no such table"})
+			return
+		}
+		w.Header().Set("X-Total-Count", "1234")
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	body, meta, err := cl.CallWithMeta("GET", "platform/", nil)
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if string(body) != "[]\n" {
+		t.Errorf("body: got %q", body)
+	}
+	if meta.StatusCode != 200 || meta.TotalCount != 1234 || meta.RequestID != "req-42" {
+		t.Errorf("meta: got %+v", meta)
+	}
+
+	_, meta, err = cl.CallWithMeta("GET", "missing/", nil)
+	if !IsNotFound(err) {
+		t.Fatalf("call missing: got %v", err)
+	}
+	if meta == nil || meta.StatusCode != 404 || meta.TotalCount != -1 || meta.RequestID != "req-42" {
+		t.Errorf("meta: got %+v", meta)
+	}
+}
+
+func TestTotalCount(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Range", "rows 0-99/500")
+	if got := totalCount(h); got != 500 {
+		t.Errorf("content-range: got %d", got)
+	}
+	h.Set("Content-Range", "rows 0-99/*")
+	if got := totalCount(h); got != -1 {
+		t.Errorf("unknown total: got %d", got)
+	}
+}
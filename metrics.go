@@ -0,0 +1,159 @@
+package stratumclient
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsCollector receives measurements of the client's API calls,
+// for exporting them to a monitoring system. Endpoint is the table
+// of the query, or login/v1 for logins. Status is the HTTP status
+// code, or 0 if the request failed without a response. Each retry
+// attempt is observed as a request of its own. Implementations must
+// be safe for concurrent use. PrometheusMetrics is a built-in
+// implementation.
+type MetricsCollector interface {
+	ObserveRequest(method, endpoint string, status int, latency time.Duration)
+	ObserveTokenRefresh(err error)
+}
+
+// Stats holds transfer counters of a client. BytesReceived counts
+// response body bytes as received on the wire and BytesDecoded counts
+// them after decompression, so the difference is the bandwidth saved
+// by compression. TLSHandshakes counts completed TLS handshakes, of
+// which TLSResumed resumed an earlier session.
+type Stats struct {
+	Requests            int64
+	CompressedResponses int64
+	BytesReceived       int64
+	BytesDecoded        int64
+	TLSHandshakes       int64
+	TLSResumed          int64
+}
+
+// CompressionRatio returns BytesDecoded divided by BytesReceived, or
+// 0 if nothing was received.
+func (s Stats) CompressionRatio() float64 {
+	if s.BytesReceived == 0 {
+		return 0
+	}
+
+	return float64(s.BytesDecoded) / float64(s.BytesReceived)
+}
+
+// ResumptionRate returns the fraction of TLS handshakes which resumed
+// a session, or 0 if there were none. A rate near 0 with many
+// handshakes suggests a load balancer which doesn't support session
+// resumption.
+func (s Stats) ResumptionRate() float64 {
+	if s.TLSHandshakes == 0 {
+		return 0
+	}
+
+	return float64(s.TLSResumed) / float64(s.TLSHandshakes)
+}
+
+// statsCounter holds the live counters behind Stats.
+type statsCounter struct {
+	requests   atomic.Int64
+	compressed atomic.Int64
+	received   atomic.Int64
+	decoded    atomic.Int64
+	handshakes atomic.Int64
+	resumed    atomic.Int64
+}
+
+// Stats returns a snapshot of the client's transfer counters.
+func (c *Client) Stats() Stats {
+	if c.stats == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		Requests:            c.stats.requests.Load(),
+		CompressedResponses: c.stats.compressed.Load(),
+		BytesReceived:       c.stats.received.Load(),
+		BytesDecoded:        c.stats.decoded.Load(),
+		TLSHandshakes:       c.stats.handshakes.Load(),
+		TLSResumed:          c.stats.resumed.Load(),
+	}
+}
+
+// countResponse counts the response and replaces its body with one
+// which decompresses gzip encoded content and counts the bytes read.
+func (s *statsCounter) countResponse(resp *http.Response) {
+	if s == nil {
+		return
+	}
+	s.requests.Add(1)
+
+	wire := &countingReader{r: resp.Body, n: &s.received}
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		resp.Body = &countedBody{Reader: &countingReader{r: wire, n: &s.decoded}, Closer: resp.Body}
+		return
+	}
+
+	s.compressed.Add(1)
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	resp.Body = &countedBody{Reader: &countingReader{r: &gzipReader{r: wire}, n: &s.decoded}, Closer: resp.Body}
+}
+
+// countHandshake counts a completed TLS handshake.
+func (s *statsCounter) countHandshake(state tls.ConnectionState, err error) {
+	if s == nil || err != nil {
+		return
+	}
+	s.handshakes.Add(1)
+	if state.DidResume {
+		s.resumed.Add(1)
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+
+	return n, err
+}
+
+// countedBody is a response body reading through counters and
+// closing the original body.
+type countedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// gzipReader decompresses r, reading the gzip header on first read
+// so empty bodies don't fail up front.
+type gzipReader struct {
+	r  io.Reader
+	gz *gzip.Reader
+}
+
+// Read implements io.Reader.
+func (g *gzipReader) Read(p []byte) (int, error) {
+	if g.gz == nil {
+		gz, err := gzip.NewReader(g.r)
+		if err != nil {
+			return 0, err
+		}
+		g.gz = gz
+	}
+
+	return g.gz.Read(p)
+}
@@ -0,0 +1,78 @@
+package stratumclient
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompressionStats(t *testing.T) {
+	rows := "[" + strings.Repeat(`{"name":"Linux"},`, 999) + `{"name":"Linux"}]`
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.Write([]byte(rows))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(rows))
+		gz.Close()
+	})
+
+	before := cl.Stats()
+	var resp []map[string]string
+	if err := cl.Get("platform/", &resp); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(resp) != 1000 {
+		t.Fatalf("rows: got %d", len(resp))
+	}
+
+	s := cl.Stats()
+	if s.Requests-before.Requests != 1 || s.CompressedResponses != 1 {
+		t.Errorf("counts: got %+v", s)
+	}
+	if got := s.BytesDecoded - before.BytesDecoded; got != int64(len(rows)) {
+		t.Errorf("decoded bytes: got %d", got)
+	}
+	if s.BytesReceived-before.BytesReceived >= s.BytesDecoded-before.BytesDecoded || s.CompressionRatio() <= 1 {
+		t.Errorf("no compression gain: %+v", s)
+	}
+}
+
+func TestCompressionHeaders(t *testing.T) {
+	var accept []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		accept = append(accept, r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`[]`))
+		gz.Close()
+	})
+
+	var h http.Header
+	if err := cl.Get("platform/", nil, CaptureHeaders(&h)); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if h.Get("Content-Encoding") != "gzip" {
+		t.Errorf("captured headers: got %v", h)
+	}
+
+	plain := &Client{Username: "user", Password: "secret", BaseURL: cl.BaseURL, DisableCompression: true}
+	if err := plain.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := plain.Get("platform/", nil); err != nil {
+		t.Fatalf("get uncompressed: %v", err)
+	}
+	if accept[len(accept)-1] != "" || plain.Stats().CompressedResponses != 0 {
+		t.Errorf("compression not disabled: accept %q, stats %+v", accept, plain.Stats())
+	}
+}
@@ -0,0 +1,92 @@
+package stratumclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MutationResult holds the outcome of a POST, PUT or DELETE call:
+// the affected rows typed as T, the number of affected rows, and the
+// request ID of the call for correlating it with the server logs.
+type MutationResult[T any] struct {
+	Rows      []*T
+	Count     int
+	RequestID string
+}
+
+// Mutate will perform a POST, PUT or DELETE API call to stratum and
+// return the affected rows typed as T. The query is sent with
+// returning=* unless it asks for other returning columns. Count is
+// the row count given by the server in an X-Total-Count or
+// Content-Range header, or else the number of returned rows. The
+// function returns the mutation result and an error.
+//
+//	res, err := stratumclient.Mutate[Platform](c, "PUT", "platform/?where=id=1", data)
+func Mutate[T any](c *Client, method, query string, data interface{}, opts ...CallOption) (*MutationResult[T], error) {
+	method = strings.ToUpper(method)
+	switch method {
+	case "POST", "PUT", "DELETE":
+	default:
+		return nil, fmt.Errorf("mutate: unsupported method %s", method)
+	}
+
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if q.Get("returning") == "" {
+		q.Set("returning", "*")
+	}
+
+	var meta *ResponseMeta
+	opts = append(opts, func(o *callOptions) {
+		o.meta = &meta
+	})
+
+	rows, err := UnmarshalAs[T](c, method, q.String(), data, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MutationResult[T]{Rows: rows, Count: len(rows)}
+	if meta != nil {
+		res.RequestID = meta.RequestID
+		if meta.TotalCount >= 0 {
+			res.Count = meta.TotalCount
+		}
+	}
+
+	return res, nil
+}
+
+// Insert will insert data as rows of table and return the inserted
+// rows typed as T.
+func Insert[T any](c *Client, table string, data interface{}, opts ...CallOption) (*MutationResult[T], error) {
+	q := &Query{Table: strings.Trim(table, "/")}
+
+	return Mutate[T](c, "POST", q.String(), data, opts...)
+}
+
+// Update will update the rows of table matching where with data and
+// return the updated rows typed as T. A nil where is an error, since
+// it would update every row.
+func Update[T any](c *Client, table string, where Expr, data interface{}, opts ...CallOption) (*MutationResult[T], error) {
+	if where == nil {
+		return nil, fmt.Errorf("missing: where")
+	}
+	q := &Query{Table: strings.Trim(table, "/"), Where: where}
+
+	return Mutate[T](c, "PUT", q.String(), data, opts...)
+}
+
+// Remove will delete the rows of table matching where and return the
+// deleted rows typed as T. A nil where is an error, since it would
+// delete every row.
+func Remove[T any](c *Client, table string, where Expr, opts ...CallOption) (*MutationResult[T], error) {
+	if where == nil {
+		return nil, fmt.Errorf("missing: where")
+	}
+	q := &Query{Table: strings.Trim(table, "/"), Where: where}
+
+	return Mutate[T](c, "DELETE", q.String(), nil, opts...)
+}
@@ -0,0 +1,40 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMutate(t *testing.T) {
+	var method, rawQuery string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, rawQuery = r.Method, r.URL.RawQuery
+		w.Header().Set("X-Request-ID", "req-1")
+		writeJSON(w, http.StatusOK, []*testPlatform{{ID: 1, Name: "Linux"}, {ID: 2, Name: "BSD"}})
+	})
+
+	res, err := Update[testPlatform](cl, "platform", W("name").Like("x"), map[string]string{"vendor": "acme"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if method != "PUT" || rawQuery != "where=name~x&returning=*" {
+		t.Errorf("request: got %s %s", method, rawQuery)
+	}
+	if res.Count != 2 || res.RequestID != "req-1" || res.Rows[1].Name != "BSD" {
+		t.Errorf("result: got %+v", res)
+	}
+
+	if _, err := Mutate[testPlatform](cl, "post", "platform/?returning=id", map[string]string{"name": "Linux"}); err != nil {
+		t.Fatalf("mutate: %v", err)
+	}
+	if method != "POST" || rawQuery != "returning=id" {
+		t.Errorf("request: got %s %s", method, rawQuery)
+	}
+
+	if _, err := Remove[testPlatform](cl, "platform", nil); err == nil {
+		t.Error("remove without where: expected error")
+	}
+	if _, err := Mutate[testPlatform](cl, "GET", "platform/", nil); err == nil {
+		t.Error("mutate with GET: expected error")
+	}
+}
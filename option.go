@@ -0,0 +1,189 @@
+package stratumclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CallOption adjusts a single API call, overriding the client
+// defaults for that call only. Call options are given as the
+// trailing arguments to Get, Post, Put, Delete, Unmarshal and Call.
+type CallOption func(*callOptions)
+
+// callOptions holds the settings of a single API call.
+type callOptions struct {
+	ctx       context.Context
+	headers   *http.Header
+	meta      **ResponseMeta
+	weight    Weight
+	accept    string
+	header    http.Header
+	params    []*Param
+	timeout   time.Duration
+	retry     *RetryPolicy
+	success   []int
+	status    *int
+	dryRun    *Plan
+	requestID string
+	version   string
+	tags      []string
+	decodeTo  []interface{}
+
+	verifyOrder    string
+	idempotencyKey string
+
+	unshadowed bool
+
+	uploadProgress   ProgressFunc
+	downloadProgress ProgressFunc
+}
+
+// newCallOptions applies opts to a fresh set of call settings.
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// context returns the context of the call.
+func (o *callOptions) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+
+	return o.ctx
+}
+
+// WithContext makes the call use ctx. Cancelling ctx aborts the call,
+// including any backoff between retries.
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		o.ctx = ctx
+	}
+}
+
+// CaptureHeaders stores the response headers of the call in h. The
+// headers are stored for error responses too, as long as the server
+// responded.
+func CaptureHeaders(h *http.Header) CallOption {
+	return func(o *callOptions) {
+		o.headers = h
+	}
+}
+
+// CaptureStatus stores the response status code of the call in
+// status, for calls with several success status codes. The status is
+// stored for error responses too, as long as the server responded.
+func CaptureStatus(status *int) CallOption {
+	return func(o *callOptions) {
+		o.status = status
+	}
+}
+
+// WithSuccessStatus sets the response status codes which are
+// decoded as results of the call, overriding the client SuccessStatus,
+// like 207 Multi-Status or a 409 Conflict with the conflicting rows.
+func WithSuccessStatus(codes ...int) CallOption {
+	return func(o *callOptions) {
+		o.success = codes
+	}
+}
+
+// WithAccept asks the server for a response of the given media type,
+// like "text/csv" for exports, instead of JSON. The response content
+// type isn't checked, so the body can be retrieved with GetRaw or
+// GetReader whatever the server sends.
+func WithAccept(mediaType string) CallOption {
+	return func(o *callOptions) {
+		o.accept = mediaType
+	}
+}
+
+// WithHeader adds a request header to the call, replacing any of the
+// client DefaultHeaders with the same key. The Authorization header
+// is always set by the client and can't be overridden.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithQueryParam adds a query parameter to the call, escaped as
+// needed, so values don't have to be spliced into the query string.
+func WithQueryParam(key, value string) CallOption {
+	return func(o *callOptions) {
+		o.params = append(o.params, &Param{Key: key, Value: value})
+	}
+}
+
+// WithTimeout overrides the client Timeout and weight profile timeout
+// of each attempt of the call.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithRetryPolicy overrides the client Retry policy and weight
+// profile policy for the call. A policy with MaxAttempts 1 disables
+// retries.
+func WithRetryPolicy(p *RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = p
+	}
+}
+
+// withParams returns the query with the parameters added by
+// WithQueryParam.
+func (o *callOptions) withParams(query string) string {
+	if len(o.params) == 0 {
+		return query
+	}
+
+	var b strings.Builder
+	b.WriteString(query)
+	sep := "?"
+	if strings.Contains(query, "?") {
+		sep = "&"
+	}
+	for _, p := range o.params {
+		b.WriteString(sep + url.QueryEscape(p.Key) + "=" + url.QueryEscape(p.Value))
+		sep = "&"
+	}
+
+	return b.String()
+}
+
+// DecodeInto makes Unmarshal, and Get, Post, Put and Delete, also
+// decode the response into targets, so one response can be had in
+// several forms without calling twice, like typed rows for processing
+// and the raw JSON for archival. A *json.RawMessage or *[]byte target
+// gets a copy of the response body as is; other targets are decoded
+// like the response parameter. An empty response body leaves the
+// targets unchanged.
+//
+//	var hosts []*Host
+//	var raw json.RawMessage
+//	err := c.Get("host/", &hosts, stratumclient.DecodeInto(&raw))
+func DecodeInto(targets ...interface{}) CallOption {
+	return func(o *callOptions) {
+		o.decodeTo = append(o.decodeTo, targets...)
+	}
+}
+
+// WithDryRun adds the call to plan instead of sending it, if it
+// changes data, like the client DryRun setting.
+func WithDryRun(plan *Plan) CallOption {
+	return func(o *callOptions) {
+		o.dryRun = plan
+	}
+}
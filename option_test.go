@@ -0,0 +1,157 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCaptureHeaders(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Server-Version", "2.1")
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	var hdrs http.Header
+	if err := cl.Get("platform/", nil, CaptureHeaders(&hdrs)); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got := hdrs.Get("X-Server-Version"); got != "2.1" {
+		t.Fatalf("X-Server-Version: got %q, want 2.1", got)
+	}
+}
+
+func TestCallOptions(t *testing.T) {
+	var calls int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if got := r.Header.Get("X-Request-Tag"); got != "nightly" {
+			t.Errorf("X-Request-Tag: got %q, want nightly", got)
+		}
+		if got := r.URL.Query().Get("where"); got != "name=a&b" {
+			t.Errorf("where: got %q, want name=a&b", got)
+		}
+		if got := r.URL.Query().Get("select"); got != "id" {
+			t.Errorf("select: got %q, want id", got)
+		}
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "busy"})
+	})
+
+	err := cl.Get("platform/?select=id", nil,
+		WithHeader("X-Request-Tag", "nightly"),
+		WithQueryParam("where", "name=a&b"),
+		WithTimeout(time.Second),
+		WithRetryPolicy(&RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	if err == nil {
+		t.Fatal("get: got nil error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("attempts: got %d, want 2", got)
+	}
+}
+
+func TestSuccessStatus(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusMultiStatus, []map[string]int{{"status": 201}, {"status": 409}})
+	})
+
+	var status int
+	if err := cl.Get("host/", nil, CaptureStatus(&status)); err == nil {
+		t.Fatal("get: 207 gave no error by default")
+	}
+	if status != http.StatusMultiStatus {
+		t.Fatalf("status: got %d, want 207", status)
+	}
+
+	var rows []map[string]int
+	if err := cl.Get("host/", &rows, WithSuccessStatus(http.StatusOK, http.StatusMultiStatus)); err != nil {
+		t.Fatalf("get with 207 allowed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows: got %d, want 2", len(rows))
+	}
+
+	cl.SuccessStatus = []int{http.StatusMultiStatus}
+	if err := cl.Get("host/", nil); err != nil {
+		t.Fatalf("get with client SuccessStatus: %v", err)
+	}
+}
+
+func TestEmptyResponse(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		case "PUT":
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("hello"))
+		}
+	})
+
+	rows := []*testPlatform{{ID: 1}}
+	var status int
+	if err := cl.Delete("platform/?where=id=1", nil, &rows, CaptureStatus(&status)); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if status != http.StatusNoContent || len(rows) != 1 {
+		t.Errorf("delete: got status %d, rows %v", status, rows)
+	}
+	if err := cl.Put("platform/?where=id=1", map[string]string{"name": "BSD"}, nil); err != nil {
+		t.Fatalf("put with empty body: %v", err)
+	}
+	if err := cl.Get("platform/", nil); err == nil {
+		t.Error("get with text body: expected error")
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	var calls int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"rows": []testPlatform{{ID: 1, Name: "linux"}}})
+	})
+
+	var rows []*testPlatform
+	var raw json.RawMessage
+	var maps []map[string]interface{}
+	if err := cl.Get("platform/", &rows, DecodeInto(&raw, &maps)); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != "linux" || len(maps) != 1 || maps[0]["name"] != "linux" {
+		t.Fatalf("decoded: got %v, %v", rows, maps)
+	}
+	if !strings.HasPrefix(string(raw), `{"rows":`) {
+		t.Fatalf("raw: got %s", raw)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("calls: got %d, want 1", got)
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	var got http.Header
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.DefaultHeaders = map[string]string{"X-Tenant-ID": "acme", "X-Feature": "beta", "Authorization": "Basic x"}
+
+	if err := cl.Get("platform/", nil, WithHeader("X-Feature", "gamma")); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Get("X-Tenant-ID") != "acme" || got.Get("X-Feature") != "gamma" || got.Get("Authorization") != "Bearer token" {
+		t.Fatalf("headers: got %v", got)
+	}
+
+	clone := cl.Clone()
+	clone.DefaultHeaders["X-Tenant-ID"] = "other"
+	if cl.DefaultHeaders["X-Tenant-ID"] != "acme" {
+		t.Fatalf("clone: shares DefaultHeaders")
+	}
+}
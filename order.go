@@ -0,0 +1,137 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnorderedError is returned when the rows of a response aren't
+// sorted by the orderby parameter of the query, see
+// Client.VerifyOrder. Row is the index of the first row out of order,
+// which sorts before the row before it by Column.
+type UnorderedError struct {
+	Query  string
+	Row    int
+	Column string
+}
+
+// Error implements the error interface.
+func (e *UnorderedError) Error() string {
+	return fmt.Sprintf("rows of %s not sorted: row %d sorts before row %d by %s", e.Query, e.Row, e.Row-1, e.Column)
+}
+
+// Order verification modes of Client.VerifyOrder and WithVerifyOrder.
+const (
+	VerifyOrderError = "error"
+	VerifyOrderWarn  = "warn"
+)
+
+// WithVerifyOrder overrides the client VerifyOrder mode for the call:
+// VerifyOrderError, VerifyOrderWarn, or "off" to disable the check.
+func WithVerifyOrder(mode string) CallOption {
+	return func(o *callOptions) {
+		o.verifyOrder = mode
+	}
+}
+
+// checkOrder verifies that the rows of the response body content of
+// query are sorted by the orderby of the query, if order verification
+// is enabled. Columns of the orderby missing from the rows, and the
+// ones after them, aren't checked, nor are pairs of rows where a
+// value is null. Text sorting after the row before it both in byte
+// order and ignoring case is taken to be out of order, so
+// case-insensitive collations aren't reported.
+func (c *Client) checkOrder(query string, content []byte, o *callOptions) error {
+	mode := c.VerifyOrder
+	if o.verifyOrder != "" {
+		mode = o.verifyOrder
+	}
+	if mode != VerifyOrderError && mode != VerifyOrderWarn {
+		return nil
+	}
+	q, err := ParseQuery(o.withParams(query))
+	if err != nil || len(q.OrderBy) == 0 {
+		return nil
+	}
+
+	content, _ = unwrapEnvelope(content)
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var rows []map[string]interface{}
+	if dec.Decode(&rows) != nil || len(rows) < 2 {
+		return nil
+	}
+
+	var columns []string
+	for _, col := range q.OrderBy {
+		name := strings.TrimPrefix(strings.TrimSpace(col), "-")
+		if _, ok := rows[0][name]; !ok {
+			break
+		}
+		columns = append(columns, strings.TrimSpace(col))
+	}
+
+	for i := 1; i < len(rows); i++ {
+		for _, col := range columns {
+			desc := strings.HasPrefix(col, "-")
+			name := strings.TrimPrefix(col, "-")
+			cmp, ok := compareOrder(rows[i-1][name], rows[i][name])
+			if !ok {
+				break
+			}
+			if desc {
+				cmp = -cmp
+			}
+			if cmp < 0 {
+				break
+			}
+			if cmp == 0 {
+				continue
+			}
+
+			e := &UnorderedError{Query: query, Row: i, Column: col}
+			if mode == VerifyOrderWarn {
+				if c.Logger != nil {
+					c.Logger.Warn("stratum rows not sorted", "query", query, "error", e)
+				}
+				return nil
+			}
+			return e
+		}
+	}
+
+	return nil
+}
+
+// compareOrder compares the values of a column in two rows,
+// numerically if both are numbers, otherwise as text. It reports
+// false if the order can't be told, like for nulls, and text which is
+// ordered differently in byte order and ignoring case.
+func compareOrder(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	ta, tb := valueText(a), valueText(b)
+	fa, erra := strconv.ParseFloat(ta, 64)
+	fb, errb := strconv.ParseFloat(tb, 64)
+	if erra == nil && errb == nil {
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	cmp := strings.Compare(ta, tb)
+	if folded := strings.Compare(strings.ToLower(ta), strings.ToLower(tb)); folded != cmp {
+		return 0, false
+	}
+
+	return cmp, true
+}
@@ -0,0 +1,63 @@
+package stratumclient
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVerifyOrder(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"id": 2, "name": "b", "os": "linux"},
+			{"id": 10, "name": "B", "os": nil},
+			{"id": 3, "name": "a", "os": "bsd"},
+		})
+	})
+
+	var rows []*testPlatform
+	if err := cl.Get("host/?orderby=id", &rows); err != nil {
+		t.Fatalf("unchecked: %v", err)
+	}
+
+	cl.VerifyOrder = VerifyOrderError
+	err := cl.Get("host/?orderby=id", &rows)
+	var e *UnorderedError
+	if !errors.As(err, &e) || e.Row != 2 || e.Column != "id" {
+		t.Fatalf("id: got %v", err)
+	}
+	if _, err := cl.GetRows("host/?orderby=-id"); !errors.As(err, &e) || e.Row != 1 {
+		t.Fatalf("-id: got %v", err)
+	}
+
+	for _, query := range []string{
+		"host/",                    // no orderby
+		"host/?orderby=name",       // b, B and a disagree with case ignored
+		"host/?orderby=os",         // nulls aren't compared
+		"host/?orderby=missing,id", // columns not in the rows
+	} {
+		if err := cl.Get(query, &rows); err != nil {
+			t.Errorf("%s: got %v", query, err)
+		}
+	}
+	if err := cl.Get("host/?orderby=id", &rows, WithVerifyOrder("off")); err != nil {
+		t.Fatalf("off: got %v", err)
+	}
+
+	var buf bytes.Buffer
+	cl.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	if err := cl.Get("host/?orderby=id", &rows, WithVerifyOrder(VerifyOrderWarn)); err != nil {
+		t.Fatalf("warn: got %v", err)
+	}
+	if !strings.Contains(buf.String(), "stratum rows not sorted") {
+		t.Fatalf("warn: got log %s", buf.String())
+	}
+
+	cl.VerifyOrder = "sometimes"
+	if err := cl.Validate(); err == nil {
+		t.Fatal("validate: expected error")
+	}
+}
@@ -0,0 +1,230 @@
+package stratumclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+)
+
+// Pages iterates over the rows of a query a page at a time, using
+// the limit and offset parameters, so large tables can be processed
+// without a single huge response. The query should have an orderby
+// parameter, or the server may return the rows in a different order
+// for each page.
+//
+// Rows inserted or deleted while paging shift the rows between
+// pages, so a row may be seen twice. Setting Key drops rows whose
+// key was seen on an earlier page. The keys seen are kept in memory
+// until the iteration ends. Setting Verify detects exports made
+// inconsistent by concurrent writes.
+type Pages[T any] struct {
+	Client *Client
+	Query  string
+	// PageSize is the number of rows per page. Default 100.
+	PageSize int
+	// Key returns the unique key of a row, like its id.
+	Key func(row *T) string
+	// Verify makes Each count the rows of the query after the last
+	// page, and return an *InconsistentExportError if the count
+	// differs from the number of rows iterated over.
+	Verify bool
+	// Envelope is a query parameter, like envelope=true, asking the
+	// server to wrap each page as {"rows": [...], "total": N}.
+	// Verify then uses the total of the last page instead of a
+	// count query. If the server rejects the parameter with 400 Bad
+	// Request, the pages are requested without it.
+	Envelope string
+	Options  []CallOption
+
+	summary *ExportSummary
+}
+
+// ExportSummary holds the number of rows iterated over by Pages.Each
+// and the SHA-256 checksum of their JSON encoding, one row per line,
+// for comparing exports. Count is the row count of the query after
+// the last page if Verify is set, otherwise -1.
+type ExportSummary struct {
+	Rows     int
+	Checksum string
+	Count    int
+}
+
+// InconsistentExportError is returned by Pages.Each when the number
+// of rows iterated over differs from the row count of the query,
+// since rows were inserted or deleted during the iteration.
+type InconsistentExportError struct {
+	Rows  int
+	Count int
+}
+
+// Error implements the error interface.
+func (e *InconsistentExportError) Error() string {
+	return fmt.Sprintf("inconsistent export: got %d rows, but the query counts %d", e.Rows, e.Count)
+}
+
+// Summary returns the summary of the last completed iteration, or
+// nil if there is none.
+func (p *Pages[T]) Summary() *ExportSummary {
+	return p.summary
+}
+
+// Each calls fn with every row of the query, page by page. Returning
+// an error from fn stops the iteration and the error is returned.
+func (p *Pages[T]) Each(fn func(row *T) error) error {
+	q, err := ParseQuery(p.Query)
+	if err != nil {
+		return err
+	}
+	size := p.PageSize
+	if size <= 0 {
+		size = 100
+	}
+
+	p.summary = nil
+	var seen map[string]bool
+	if p.Key != nil {
+		seen = make(map[string]bool)
+	}
+	envelope := p.Envelope
+	total := -1
+	sum := sha256.New()
+	n := 0
+	for offset := 0; ; offset += size {
+		q.Set("limit", strconv.Itoa(size))
+		q.Set("offset", strconv.Itoa(offset))
+		var rows []*T
+		rows, total, err = p.page(q, envelope)
+		if err != nil && envelope != "" && StatusCode(err) == http.StatusBadRequest {
+			envelope = ""
+			rows, total, err = p.page(q, envelope)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if seen != nil {
+				key := p.Key(row)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			if err := checksumRow(sum, row); err != nil {
+				return err
+			}
+			n++
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < size {
+			break
+		}
+	}
+
+	summary := &ExportSummary{Rows: n, Checksum: hex.EncodeToString(sum.Sum(nil)), Count: -1}
+	if p.Verify {
+		summary.Count = total
+		if total < 0 {
+			count, err := p.count(q)
+			if err != nil {
+				return err
+			}
+			summary.Count = count
+		}
+	}
+	p.summary = summary
+	if summary.Count >= 0 && summary.Count != n {
+		return &InconsistentExportError{Rows: n, Count: summary.Count}
+	}
+
+	return nil
+}
+
+// page returns the rows of a page and the total row count given by
+// the server in an envelope, or -1. The envelope parameter is added
+// to the page query unless it is "".
+func (p *Pages[T]) page(q *Query, envelope string) ([]*T, int, error) {
+	query := q.String()
+	if envelope != "" {
+		query += "&" + envelope
+	}
+
+	content, err := p.Client.Call("GET", query, nil, p.Options...)
+	if err != nil {
+		return nil, -1, err
+	}
+	content, total := unwrapEnvelope(content)
+	if err := p.Client.checkOrder(query, content, newCallOptions(p.Options)); err != nil {
+		return nil, -1, err
+	}
+	var rows []*T
+	if err := p.Client.codec().Unmarshal(content, &rows); err != nil {
+		return nil, -1, err
+	}
+	if err := p.Client.checkStrict(query, content, &rows); err != nil {
+		return nil, -1, err
+	}
+
+	return rows, total, nil
+}
+
+// checksumRow adds the JSON encoding of row to the checksum.
+func checksumRow(sum hash.Hash, row interface{}) error {
+	content, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	sum.Write(content)
+	sum.Write([]byte("\n"))
+
+	return nil
+}
+
+// count returns the row count of the paged query.
+func (p *Pages[T]) count(q *Query) (int, error) {
+	cq := &Query{Table: q.Table, Select: []string{"count(*)"}, Where: q.Where}
+	for _, param := range q.Params {
+		if param.Key != "limit" && param.Key != "offset" {
+			cq.Params = append(cq.Params, param)
+		}
+	}
+
+	var rows []map[string]json.RawMessage
+	if err := p.Client.Get(cq.String(), &rows, p.Options...); err != nil {
+		return 0, fmt.Errorf("count %s: %w", q.Table, err)
+	}
+	v, err := aggregateValue(rows)
+	if err != nil {
+		return 0, fmt.Errorf("count %s: %w", q.Table, err)
+	}
+	num, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("count %s: expected a number, got %v", q.Table, v)
+	}
+	count, err := num.Int64()
+
+	return int(count), err
+}
+
+// DedupBy returns the rows with only the first of the rows with the
+// same key, keeping their order.
+func DedupBy[T any, K comparable](rows []*T, key func(row *T) K) []*T {
+	seen := make(map[K]bool, len(rows))
+	ret := make([]*T, 0, len(rows))
+	for _, row := range rows {
+		k := key(row)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		ret = append(ret, row)
+	}
+
+	return ret
+}
@@ -0,0 +1,159 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestPages(t *testing.T) {
+	type host struct {
+		ID int `json:"id"`
+	}
+	ids := []int{1, 2, 3, 4, 5, 6, 7}
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if r.URL.Query().Get("orderby") != "id" {
+			t.Errorf("orderby lost: %s", r.URL.RawQuery)
+		}
+		var rows []*host
+		for i := offset; i < offset+limit && i < len(ids); i++ {
+			rows = append(rows, &host{ID: ids[i]})
+		}
+		if offset == 0 {
+			// a row inserted before the next page shifts the rows
+			ids = append([]int{0}, ids...)
+		}
+		writeJSON(w, http.StatusOK, rows)
+	})
+
+	collect := func(p *Pages[host]) []int {
+		var got []int
+		if err := p.Each(func(h *host) error {
+			got = append(got, h.ID)
+			return nil
+		}); err != nil {
+			t.Fatalf("each: %v", err)
+		}
+		return got
+	}
+
+	got := collect(&Pages[host]{Client: cl, Query: "host/?orderby=id", PageSize: 3, Key: func(h *host) string { return strconv.Itoa(h.ID) }})
+	if want := []int{1, 2, 3, 4, 5, 6, 7}; !slices.Equal(got, want) {
+		t.Fatalf("dedup pages: got %v, want %v", got, want)
+	}
+
+	ids = []int{1, 2, 3, 4, 5, 6, 7}
+	got = collect(&Pages[host]{Client: cl, Query: "host/?orderby=id", PageSize: 3})
+	if want := []int{1, 2, 3, 3, 4, 5, 6, 7}; !slices.Equal(got, want) {
+		t.Fatalf("pages: got %v, want %v", got, want)
+	}
+
+	rows := DedupBy([]*host{{1}, {2}, {1}, {3}, {2}}, func(h *host) int { return h.ID })
+	if len(rows) != 3 || rows[0].ID != 1 || rows[1].ID != 2 || rows[2].ID != 3 {
+		t.Fatalf("dedup: got %v", rows)
+	}
+}
+
+func TestPagesVerify(t *testing.T) {
+	type host struct {
+		ID int `json:"id"`
+	}
+	ids := []int{1, 2, 3, 4, 5}
+	insert := false
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("select") == "count(*)" {
+			if r.URL.Query().Get("limit") != "" || r.URL.Query().Get("where") != "site=osl" {
+				t.Errorf("count query: got %s", r.URL.RawQuery)
+			}
+			writeJSON(w, http.StatusOK, []map[string]int{{"count": len(ids)}})
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var rows []*host
+		for i := offset; i < offset+limit && i < len(ids); i++ {
+			rows = append(rows, &host{ID: ids[i]})
+		}
+		if insert {
+			ids = append(ids, len(ids)+1)
+		}
+		writeJSON(w, http.StatusOK, rows)
+	})
+
+	p := &Pages[host]{Client: cl, Query: "host/?where=site=osl&orderby=id", PageSize: 2, Verify: true}
+	each := func() error {
+		return p.Each(func(*host) error { return nil })
+	}
+	if err := each(); err != nil {
+		t.Fatalf("each: %v", err)
+	}
+	first := p.Summary()
+	if first.Rows != 5 || first.Count != 5 || len(first.Checksum) != 64 {
+		t.Fatalf("summary: got %+v", first)
+	}
+	if err := each(); err != nil || p.Summary().Checksum != first.Checksum {
+		t.Fatalf("second export: got %v, checksum %s, want %s", err, p.Summary().Checksum, first.Checksum)
+	}
+
+	insert = true
+	var ierr *InconsistentExportError
+	if err := each(); !errors.As(err, &ierr) {
+		t.Fatalf("each with inserts: got %v, want InconsistentExportError", err)
+	}
+}
+
+func TestPagesEnvelope(t *testing.T) {
+	type host struct {
+		ID int `json:"id"`
+	}
+	var supported bool
+	var queries []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("select") == "count(*)" {
+			writeJSON(w, http.StatusOK, []map[string]int{{"count": 3}})
+			return
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var rows []*host
+		for i := offset; i < offset+2 && i < 3; i++ {
+			rows = append(rows, &host{ID: i + 1})
+		}
+		switch {
+		case r.URL.Query().Get("envelope") == "":
+			writeJSON(w, http.StatusOK, rows)
+		case supported:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"rows": rows, "total": 3})
+		default:
+			writeJSON(w, http.StatusBadRequest, &ErrorResponse{Message: "unknown parameter envelope"})
+		}
+	})
+
+	for _, tt := range []struct {
+		supported bool
+		queries   int
+	}{{true, 2}, {false, 4}} {
+		supported, queries = tt.supported, nil
+		p := &Pages[host]{Client: cl, Query: "host/?orderby=id", PageSize: 2, Verify: true, Envelope: "envelope=true"}
+		var ids []int
+		if err := p.Each(func(h *host) error {
+			ids = append(ids, h.ID)
+			return nil
+		}); err != nil {
+			t.Fatalf("supported %t: %v", tt.supported, err)
+		}
+		if len(ids) != 3 || p.Summary().Count != 3 || len(queries) != tt.queries {
+			t.Errorf("supported %t: got ids %v, count %d, queries %v", tt.supported, ids, p.Summary().Count, queries)
+		}
+	}
+
+	supported = true
+	var rows []*host
+	if err := cl.Get("host/?envelope=true", &rows); err != nil || len(rows) != 2 {
+		t.Errorf("get enveloped: got %v, %v", rows, err)
+	}
+}
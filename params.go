@@ -0,0 +1,295 @@
+package stratumclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Params holds the parameters of a query as Go values, so queries
+// can be built without splicing strings:
+//
+//	p := &Params{
+//		Select:  []string{"id", "name"},
+//		Where:   W("name").Like("linux"),
+//		OrderBy: []string{"name"},
+//	}
+//	err := c.Get(p.Query("platform"), &platforms)
+//
+// Values are quoted and escaped as needed. Zero fields are left out.
+// Extra holds any other parameters, like returning. Where expressions
+// are built with W, All, Any and Negate, or with the And, Or and Not
+// types directly.
+type Params struct {
+	Select  []string
+	Where   Expr
+	OrderBy []string
+	Limit   int
+	Offset  int
+	Extra   map[string]string
+}
+
+// Query returns the API query of the parameters on table.
+func (p *Params) Query(table string) string {
+	q := &Query{
+		Table:   strings.Trim(table, "/"),
+		Select:  p.Select,
+		Where:   p.Where,
+		OrderBy: p.OrderBy,
+	}
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset > 0 {
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+	for _, key := range sortedKeys(p.Extra) {
+		q.Set(key, p.Extra[key])
+	}
+
+	return q.String()
+}
+
+// Column is a column of a where clause condition, made with W.
+type Column string
+
+// W returns a column for building where clause conditions, like
+// W("name").Eq("db1").
+func W(column string) Column {
+	return Column(column)
+}
+
+// cond returns a condition comparing the column with v. A nil v is
+// written as null, which matches missing values. An Arg v gives a
+// placeholder condition, which is given its value by Bind.
+func (c Column) cond(op string, v interface{}) Expr {
+	if a, ok := v.(Arg); ok {
+		return &argCond{column: string(c), op: op, name: string(a)}
+	}
+
+	if v == nil {
+		return &Cond{Column: string(c), Op: op, Null: true}
+	}
+
+	return &Cond{Column: string(c), Op: op, Value: valueText(v)}
+}
+
+// Eq returns a condition matching rows where the column equals v.
+func (c Column) Eq(v interface{}) Expr {
+	return c.cond("=", v)
+}
+
+// Ne returns a condition matching rows where the column differs from
+// v.
+func (c Column) Ne(v interface{}) Expr {
+	return c.cond("!=", v)
+}
+
+// Lt returns a condition matching rows where the column is less than
+// v.
+func (c Column) Lt(v interface{}) Expr {
+	return c.cond("<", v)
+}
+
+// Lte returns a condition matching rows where the column is less
+// than or equal to v.
+func (c Column) Lte(v interface{}) Expr {
+	return c.cond("<=", v)
+}
+
+// Gt returns a condition matching rows where the column is greater
+// than v.
+func (c Column) Gt(v interface{}) Expr {
+	return c.cond(">", v)
+}
+
+// Gte returns a condition matching rows where the column is greater
+// than or equal to v.
+func (c Column) Gte(v interface{}) Expr {
+	return c.cond(">=", v)
+}
+
+// Like returns a condition matching rows where the column matches the
+// regular expression re.
+func (c Column) Like(re string) Expr {
+	return c.cond("~", re)
+}
+
+// NotLike returns a condition matching rows where the column doesn't
+// match the regular expression re.
+func (c Column) NotLike(re string) Expr {
+	return c.cond("!~", re)
+}
+
+// In returns a condition matching rows where the column equals one of
+// vs. With no values it matches no rows.
+func (c Column) In(vs ...interface{}) Expr {
+	switch len(vs) {
+	case 0:
+		return And{c.IsNull(), c.NotNull()}
+	case 1:
+		return c.Eq(vs[0])
+	}
+
+	in := make(Or, len(vs))
+	for i, v := range vs {
+		in[i] = c.Eq(v)
+	}
+
+	return in
+}
+
+// NotIn returns a condition matching rows where the column equals
+// none of vs.
+func (c Column) NotIn(vs ...interface{}) Expr {
+	return &Not{Expr: c.In(vs...)}
+}
+
+// IsNull returns a condition matching rows where the column is
+// missing.
+func (c Column) IsNull() Expr {
+	return c.cond("=", nil)
+}
+
+// NotNull returns a condition matching rows where the column is set.
+func (c Column) NotNull() Expr {
+	return c.cond("!=", nil)
+}
+
+// All returns an expression matching rows which match all of exprs.
+// Nil expressions are skipped and nested All expressions are
+// flattened, so conditions can be added as needed:
+//
+//	where := All(W("site").Eq(site), filter)
+//
+// All returns nil if no expressions are left.
+func All(exprs ...Expr) Expr {
+	var all And
+	for _, e := range exprs {
+		switch e := e.(type) {
+		case nil:
+		case And:
+			all = append(all, e...)
+		default:
+			all = append(all, e)
+		}
+	}
+
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	}
+
+	return all
+}
+
+// Any returns an expression matching rows which match one of exprs.
+// Nil expressions are skipped and nested Any expressions are
+// flattened. Any returns nil if no expressions are left.
+func Any(exprs ...Expr) Expr {
+	var alts Or
+	for _, e := range exprs {
+		switch e := e.(type) {
+		case nil:
+		case Or:
+			alts = append(alts, e...)
+		default:
+			alts = append(alts, e)
+		}
+	}
+
+	switch len(alts) {
+	case 0:
+		return nil
+	case 1:
+		return alts[0]
+	}
+
+	return alts
+}
+
+// Negate returns an expression matching rows which don't match e.
+// A negated Not gives back its expression.
+func Negate(e Expr) Expr {
+	if n, ok := e.(*Not); ok {
+		return n.Expr
+	}
+
+	return &Not{Expr: e}
+}
+
+// Arg is a named placeholder for a condition value, so an expression
+// can be built once and reused with different values:
+//
+//	byName := W("name").Eq(Arg("name"))
+//	where, err := Bind(byName, map[string]interface{}{"name": "db1"})
+//
+// Expressions holding placeholders must be bound before use.
+type Arg string
+
+// argCond is a condition with a placeholder value.
+type argCond struct {
+	column string
+	op     string
+	name   string
+}
+
+// String returns the condition in query syntax, with the placeholder
+// written as :name.
+func (c *argCond) String() string {
+	return c.column + c.op + ":" + c.name
+}
+
+// Bind returns a copy of e with its placeholders replaced by the
+// values of args, which are written like values given to Column
+// methods. The function returns the bound expression and an error if
+// a placeholder has no value.
+func Bind(e Expr, args map[string]interface{}) (Expr, error) {
+	switch e := e.(type) {
+	case *argCond:
+		v, ok := args[e.name]
+		if !ok {
+			return nil, fmt.Errorf("missing: argument %s", e.name)
+		}
+		return Column(e.column).cond(e.op, v), nil
+	case *Cond:
+		c := *e
+		return &c, nil
+	case And:
+		bound, err := bindList(e, args)
+		if err != nil {
+			return nil, err
+		}
+		return And(bound), nil
+	case Or:
+		bound, err := bindList(e, args)
+		if err != nil {
+			return nil, err
+		}
+		return Or(bound), nil
+	case *Not:
+		n, err := Bind(e.Expr, args)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: n}, nil
+	}
+
+	return e, nil
+}
+
+// bindList binds each expression of a list.
+func bindList(exprs []Expr, args map[string]interface{}) ([]Expr, error) {
+	bound := make([]Expr, len(exprs))
+	for i, e := range exprs {
+		b, err := Bind(e, args)
+		if err != nil {
+			return nil, err
+		}
+		bound[i] = b
+	}
+
+	return bound, nil
+}
@@ -0,0 +1,77 @@
+package stratumclient
+
+import (
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	p := &Params{
+		Select:  []string{"id", "name"},
+		Where:   And{W("name").Like("linux|bsd"), W("note").Eq("a, b"), W("cpus").Gte(4), W("retired").Eq(nil)},
+		OrderBy: []string{"-name"},
+		Limit:   10,
+		Extra:   map[string]string{"returning": "*"},
+	}
+
+	got := p.Query("/platform/")
+	want := `platform/?select=id,name&where=name~%22linux|bsd%22,note=%22a,%20b%22,cpus>=4,retired=null&orderby=-name&limit=10&returning=*`
+	if got != want {
+		t.Fatalf("query:\n got %s\nwant %s", got, want)
+	}
+
+	q, err := ParseQuery(got)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	conds := Conds(q.Where)
+	if len(conds) != 4 || conds[0].Value != "linux|bsd" || conds[1].Value != "a, b" {
+		t.Fatalf("round trip: got %v", q.Where)
+	}
+
+	if got := (&Params{}).Query("host"); got != "host/" {
+		t.Fatalf("empty params: got %q", got)
+	}
+}
+
+func TestWhereBuilder(t *testing.T) {
+	for _, tt := range []struct {
+		expr Expr
+		want string
+	}{
+		{W("os").In("linux", "bsd"), "os=linux|os=bsd"},
+		{W("os").In("linux"), "os=linux"},
+		{W("os").In(), "os=null,os!=null"},
+		{W("os").NotIn("a|b", 1), `!(os="a|b"|os=1)`},
+		{W("note").IsNull(), "note=null"},
+		{W("note").Eq("null"), `note="null"`},
+		{W("note").Eq(nil), "note=null"},
+		{All(W("a").Eq(1), nil, All(W("b").Eq(2), W("c").NotNull())), "a=1,b=2,c!=null"},
+		{All(W("a").Eq(1), Any(W("b").Eq(2), Any(W("c").Lt(3)))), "a=1,(b=2|c<3)"},
+		{Negate(Negate(W("a").Ne("x y"))), "a!=x y"},
+		{Negate(All(W("a").Gt(1), W("b").Lte(2))), "!(a>1,b<=2)"},
+	} {
+		if got := tt.expr.String(); got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+		if _, err := ParseWhere(tt.expr.String()); err != nil {
+			t.Errorf("parse %s: %v", tt.expr, err)
+		}
+	}
+	if All(nil) != nil || Any() != nil {
+		t.Error("empty All or Any: expected nil")
+	}
+
+	tmpl := All(W("name").Eq(Arg("name")), Negate(W("site").In(Arg("site"))))
+	for _, name := range []string{"db1", "db, 2"} {
+		where, err := Bind(tmpl, map[string]interface{}{"name": name, "site": "oslo"})
+		if err != nil {
+			t.Fatalf("bind: %v", err)
+		}
+		if c := Conds(where); len(c) != 2 || c[0].Value != name || c[1].Value != "oslo" {
+			t.Errorf("bind: got %s", where)
+		}
+	}
+	if _, err := Bind(tmpl, map[string]interface{}{"name": "db1"}); err == nil {
+		t.Error("bind without site: expected error")
+	}
+}
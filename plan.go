@@ -0,0 +1,143 @@
+package stratumclient
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Step holds a single API call in a Plan.
+type Step struct {
+	Method string          `json:"method"`
+	Query  string          `json:"query"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Plan holds a serializable list of changes to be applied to the
+// API. A plan can be signed by its author and the signature is
+// verified before the plan is applied, so bulk changes can be
+// reviewed and traced back to the person who authored them.
+type Plan struct {
+	Author    string    `json:"author"`
+	Created   time.Time `json:"created"`
+	Steps     []*Step   `json:"steps"`
+	Signature []byte    `json:"signature,omitempty"`
+}
+
+// NewPlan returns an empty plan authored by author.
+func NewPlan(author string) *Plan {
+	return &Plan{Author: author, Created: time.Now().UTC()}
+}
+
+// Add appends a step to the plan. The data should be a map or JSON
+// text when post data is provided, otherwise nil. Adding a step
+// invalidates any existing signature.
+func (p *Plan) Add(method, query string, data interface{}) error {
+	method = strings.ToUpper(method)
+	if data != nil && method == "GET" {
+		return fmt.Errorf("post data not allowed with method %s", method)
+	}
+
+	step := &Step{Method: method, Query: query}
+	if data != nil {
+		switch data := data.(type) {
+		case []byte:
+			if err := checkPostData(data); err != nil {
+				return err
+			}
+			step.Data = data
+		default:
+			d, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			step.Data = d
+		}
+	}
+
+	p.Steps = append(p.Steps, step)
+	p.Signature = nil
+
+	return nil
+}
+
+// Redacted returns a copy of the plan for review or audit logs, with
+// the values of the post data fields named by fields, and of fields
+// named like passwords and tokens, masked as by
+// Client.RedactPayload. The copy is unsigned, and applying it would
+// write the masks.
+func (p *Plan) Redacted(fields ...string) *Plan {
+	r := *p
+	r.Signature = nil
+	r.Steps = make([]*Step, len(p.Steps))
+	for i, step := range p.Steps {
+		s := *step
+		if len(s.Data) > 0 {
+			s.Data = redactJSON(s.Data, fields)
+		}
+		r.Steps[i] = &s
+	}
+
+	return &r
+}
+
+// Sign signs the plan with the author's private key.
+func (p *Plan) Sign(key ed25519.PrivateKey) error {
+	msg, err := p.signedContent()
+	if err != nil {
+		return err
+	}
+	p.Signature = ed25519.Sign(key, msg)
+
+	return nil
+}
+
+// Verify checks the plan signature against the author's public
+// key. The function returns an error if the plan is unsigned or the
+// signature doesn't match, otherwise nil.
+func (p *Plan) Verify(key ed25519.PublicKey) error {
+	if len(p.Signature) == 0 {
+		return fmt.Errorf("plan is not signed")
+	}
+	msg, err := p.signedContent()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, msg, p.Signature) {
+		return fmt.Errorf("plan signature verification failed for author %q", p.Author)
+	}
+
+	return nil
+}
+
+// signedContent returns the serialized plan without its signature.
+func (p *Plan) signedContent() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signature = nil
+
+	return json.Marshal(&unsigned)
+}
+
+// ApplyPlan verifies the plan signature with the author's public key
+// and performs the steps in order. Applying stops at the first
+// failing step. The function returns an error upon errors otherwise
+// nil.
+func (c *Client) ApplyPlan(p *Plan, key ed25519.PublicKey) error {
+	if err := p.Verify(key); err != nil {
+		return err
+	}
+
+	for i, step := range p.Steps {
+		var data interface{}
+		if len(step.Data) > 0 {
+			data = []byte(step.Data)
+		}
+		if _, err := c.Call(step.Method, step.Query, data); err != nil {
+			return fmt.Errorf("plan step %d: %s %s: %w", i+1, step.Method, step.Query, err)
+		}
+	}
+
+	return nil
+}
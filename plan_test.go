@@ -0,0 +1,97 @@
+package stratumclient
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestPlanSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	p := NewPlan("alice")
+	if err := p.Add("post", "platform/", map[string]string{"name": "Linux"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := p.Verify(pub); err == nil {
+		t.Fatalf("verify unsigned plan: expected error")
+	}
+	if err := p.Sign(priv); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	text, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	loaded := &Plan{}
+	if err := json.Unmarshal(text, loaded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := loaded.Verify(pub); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	loaded.Steps[0].Query = "host/"
+	if err := loaded.Verify(pub); err == nil {
+		t.Fatalf("verify tampered plan: expected error")
+	}
+
+	if err := p.Add("get", "platform/", []byte(`{}`)); err == nil {
+		t.Fatalf("add GET with data: expected error")
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	var got []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	p := NewPlan("alice")
+	if err := p.Add("POST", "platform/", map[string]string{"name": "Linux"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := p.Add("DELETE", "platform/?where=id=1", nil); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	if err := cl.ApplyPlan(p, pub); err == nil {
+		t.Fatalf("apply unsigned plan: expected error")
+	}
+	if len(got) != 0 {
+		t.Fatalf("unsigned plan sent %d requests", len(got))
+	}
+
+	if err := p.Sign(priv); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := cl.ApplyPlan(p, pub); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	want := []string{
+		`POST //stratum/v1/platform/ {"name":"Linux"}`,
+		`DELETE //stratum/v1/platform/?where=id=1 `,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("requests: got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package stratumclient
+
+import (
+	"fmt"
+)
+
+// parseFilters parses the Filters where clauses.
+func (c *Client) parseFilters() error {
+	c.filters = nil
+
+	var and And
+	for _, f := range c.Filters {
+		e, err := ParseWhere(f)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %v", err)
+		}
+		and = append(and, e)
+	}
+	switch len(and) {
+	case 0:
+	case 1:
+		c.filters = and[0]
+	default:
+		c.filters = and
+	}
+
+	return nil
+}
+
+// applyPolicy returns the query with the Filters added and the
+// QueryPolicy applied. Queries of other methods than GET, PUT and
+// DELETE, and all queries when neither is set, are returned as is.
+func (c *Client) applyPolicy(method, query string) (string, error) {
+	if c.filters == nil && c.QueryPolicy == nil {
+		return query, nil
+	}
+	switch method {
+	case "GET", "PUT", "DELETE":
+	default:
+		return query, nil
+	}
+
+	q, err := ParseQuery(query)
+	if err != nil {
+		return "", err
+	}
+	q.Filter(c.filters)
+	if c.QueryPolicy != nil {
+		if err := c.QueryPolicy(method, q); err != nil {
+			return "", fmt.Errorf("query policy: %s %s: %w", method, query, err)
+		}
+	}
+
+	return q.String(), nil
+}
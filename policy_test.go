@@ -0,0 +1,65 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	var got []string
+	base, srv := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Query().Get("where"))
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	cl := &Client{
+		Username: "user",
+		Password: "secret",
+		BaseURL:  base.BaseURL,
+		Filters:  []string{"environment=prod", "owner=team-x|owner=shared"},
+		QueryPolicy: func(method string, q *Query) error {
+			if method == "DELETE" && len(Conds(q.Where)) < 4 {
+				return fmt.Errorf("delete without where clause")
+			}
+			return nil
+		},
+	}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if err := cl.Get("host/?where=name~web|name~db", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if err := cl.Post("host/", map[string]string{"name": "web1"}, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if err := cl.Delete("host/", nil, nil); err == nil {
+		t.Fatalf("delete: expected policy error")
+	}
+	resp, err := cl.HTTPClient().Get(srv.URL + "/stratum/v1/host/")
+	if err != nil {
+		t.Fatalf("http client: %v", err)
+	}
+	resp.Body.Close()
+
+	want := []string{
+		"GET (name~web|name~db),environment=prod,(owner=team-x|owner=shared)",
+		"POST ",
+		"GET environment=prod,(owner=team-x|owner=shared)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("requests: got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	bad := &Client{Username: "user", Password: "secret", BaseURL: base.BaseURL, Filters: []string{"environment"}}
+	if err := bad.Open(); err == nil {
+		t.Fatalf("open with invalid filter: expected error")
+	}
+}
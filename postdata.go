@@ -0,0 +1,47 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// checkPostData validates post data given as JSON text before it is
+// sent, so malformed data fails locally instead of with a 400 Bad
+// Request from the server. The data must be well-formed JSON with an
+// object or an array at the top level. Well-formed data is only
+// scanned once; the error of malformed data holds the byte offset of
+// the problem.
+func checkPostData(data []byte) error {
+	if !json.Valid(data) {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		var serr *json.SyntaxError
+		if errors.As(err, &serr) {
+			return fmt.Errorf("post data is not valid JSON: offset %d: %v", serr.Offset, serr)
+		}
+		return fmt.Errorf("post data is not valid JSON: %v", err)
+	}
+
+	switch t := bytes.TrimLeft(data, " \t\r\n"); t[0] {
+	case '{', '[':
+		return nil
+	default:
+		return fmt.Errorf("post data must be a JSON object or array, got %s", jsonKind(t[0]))
+	}
+}
+
+// jsonKind names the kind of JSON value starting with b.
+func jsonKind(b byte) string {
+	switch b {
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+
+	return "number"
+}
@@ -0,0 +1,49 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckPostData(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"name":"Linux"}`, ""},
+		{` [{"id":1}]`, ""},
+		{`{"name":"Linux",}`, "offset 17"},
+		{`{"name":`, "offset 8"},
+		{`"Linux"`, "got string"},
+		{`42`, "got number"},
+		{``, "not valid JSON"},
+	}
+	for _, tt := range tests {
+		err := checkPostData([]byte(tt.data))
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.data, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestPostInvalidJSON(t *testing.T) {
+	calls := 0
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	if err := cl.Post("platform/", []byte(`{"name":Linux}`), nil); err == nil {
+		t.Fatalf("post: expected error")
+	}
+	if calls != 0 {
+		t.Fatalf("invalid post data was sent")
+	}
+}
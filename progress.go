@@ -0,0 +1,53 @@
+package stratumclient
+
+import (
+	"io"
+)
+
+// ProgressFunc is called as the body of a request or response is
+// transferred, with the number of bytes done so far and the total,
+// or -1 if the total isn't known.
+type ProgressFunc func(done, total int64)
+
+// OnUploadProgress makes fn be called as the post data of the call is
+// sent, for showing progress of large uploads. Retried calls start
+// over from zero.
+func OnUploadProgress(fn ProgressFunc) CallOption {
+	return func(o *callOptions) {
+		o.uploadProgress = fn
+	}
+}
+
+// OnDownloadProgress makes fn be called as the response body of the
+// call is read, for showing progress of large exports. The total is
+// the decompressed length, and is unknown for compressed responses.
+func OnDownloadProgress(fn ProgressFunc) CallOption {
+	return func(o *callOptions) {
+		o.downloadProgress = fn
+	}
+}
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	r     io.Reader
+	fn    ProgressFunc
+	done  int64
+	total int64
+}
+
+// Read implements io.Reader.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.done += int64(n)
+		p.fn(p.done, p.total)
+	}
+
+	return n, err
+}
+
+// progressBody is a response body reporting download progress.
+type progressBody struct {
+	*progressReader
+	io.Closer
+}
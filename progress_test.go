@@ -0,0 +1,43 @@
+package stratumclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	export := "[" + strings.Repeat(`{"id":1},`, 999) + `{"id":1}]`
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != 15 {
+			t.Errorf("Content-Length: got %d, want 15", r.ContentLength)
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(export)))
+		io.WriteString(w, export)
+	})
+	cl.DisableCompression = true
+
+	var up, down [][2]int64
+	var rows []map[string]int
+	err := cl.Post("host/", []byte(`{"name": "db1"}`), &rows,
+		OnUploadProgress(func(done, total int64) { up = append(up, [2]int64{done, total}) }),
+		OnDownloadProgress(func(done, total int64) { down = append(down, [2]int64{done, total}) }))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if len(rows) != 1000 {
+		t.Fatalf("rows: got %d, want 1000", len(rows))
+	}
+
+	if len(up) == 0 || up[len(up)-1] != [2]int64{15, 15} {
+		t.Errorf("upload progress: got %v", up)
+	}
+	n := int64(len(export))
+	if len(down) == 0 || down[len(down)-1] != [2]int64{n, n} {
+		t.Errorf("download progress: got %v, want last %d of %d", down, n, n)
+	}
+}
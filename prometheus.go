@@ -0,0 +1,205 @@
+package stratumclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultBuckets are the upper bounds in seconds of the request
+// latency histogram buckets.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// PrometheusMetrics is a MetricsCollector which serves the collected
+// metrics in the Prometheus text exposition format. It exports
+//
+//	stratum_requests_total{method,endpoint,code}
+//	stratum_request_duration_seconds{method,endpoint}
+//	stratum_token_refreshes_total{result}
+//	stratum_connection_events_total{event,result}
+//
+// where code is the HTTP status code, or "error" for requests which
+// failed without a response, result is "success" or "error", and
+// event is the Kind of a ConnEvent.
+// Register it as a handler, like
+//
+//	m := stratumclient.NewPrometheusMetrics()
+//	c.Metrics = m
+//	http.Handle("/metrics", m)
+//
+// or append its output to an existing exporter with WriteTo.
+type PrometheusMetrics struct {
+	// Buckets are the upper bounds in seconds of the latency
+	// histogram buckets, in increasing order.
+	Buckets []float64
+
+	mu        sync.Mutex
+	requests  map[[3]string]int64
+	latencies map[[2]string]*histogram
+	refreshes map[string]int64
+	conns     map[[2]string]int64
+}
+
+// histogram holds the bucket counts, count and sum of observations.
+type histogram struct {
+	buckets []int64
+	count   int64
+	sum     float64
+}
+
+// NewPrometheusMetrics returns an empty PrometheusMetrics with the
+// default latency buckets.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{Buckets: defaultBuckets}
+}
+
+// init allocates the metrics on first use, so the zero value is
+// ready for use. It must be called with m.mu held.
+func (m *PrometheusMetrics) init() {
+	if m.requests != nil {
+		return
+	}
+	if len(m.Buckets) == 0 {
+		m.Buckets = defaultBuckets
+	}
+	m.requests = make(map[[3]string]int64)
+	m.latencies = make(map[[2]string]*histogram)
+	m.refreshes = make(map[string]int64)
+	m.conns = make(map[[2]string]int64)
+}
+
+// ObserveRequest implements MetricsCollector.
+func (m *PrometheusMetrics) ObserveRequest(method, endpoint string, status int, latency time.Duration) {
+	code := "error"
+	if status > 0 {
+		code = strconv.Itoa(status)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	m.requests[[3]string{method, endpoint, code}]++
+
+	key := [2]string{method, endpoint}
+	h, ok := m.latencies[key]
+	if !ok {
+		h = &histogram{buckets: make([]int64, len(m.Buckets))}
+		m.latencies[key] = h
+	}
+	secs := latency.Seconds()
+	for i, bound := range m.Buckets {
+		if secs <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += secs
+}
+
+// ObserveTokenRefresh implements MetricsCollector.
+func (m *PrometheusMetrics) ObserveTokenRefresh(err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.refreshes[result]++
+}
+
+// ObserveConnEvent implements ConnObserver.
+func (m *PrometheusMetrics) ObserveConnEvent(e *ConnEvent) {
+	result := "success"
+	if e.Err != nil {
+		result = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.conns[[2]string{e.Kind, result}]++
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format to w. The
+// function returns the number of bytes written and an error.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	var b strings.Builder
+	b.WriteString("# HELP stratum_requests_total Number of Stratum API requests.\n")
+	b.WriteString("# TYPE stratum_requests_total counter\n")
+	reqKeys := make([][3]string, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		return strings.Join(reqKeys[i][:], "\x00") < strings.Join(reqKeys[j][:], "\x00")
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(&b, "stratum_requests_total{method=%s,endpoint=%s,code=%s} %d\n", labelValue(k[0]), labelValue(k[1]), labelValue(k[2]), m.requests[k])
+	}
+
+	b.WriteString("# HELP stratum_request_duration_seconds Latency of Stratum API requests.\n")
+	b.WriteString("# TYPE stratum_request_duration_seconds histogram\n")
+	latKeys := make([][2]string, 0, len(m.latencies))
+	for k := range m.latencies {
+		latKeys = append(latKeys, k)
+	}
+	sort.Slice(latKeys, func(i, j int) bool {
+		return latKeys[i][0]+"\x00"+latKeys[i][1] < latKeys[j][0]+"\x00"+latKeys[j][1]
+	})
+	for _, k := range latKeys {
+		h := m.latencies[k]
+		labels := fmt.Sprintf("method=%s,endpoint=%s", labelValue(k[0]), labelValue(k[1]))
+		for i, bound := range m.Buckets {
+			fmt.Fprintf(&b, "stratum_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(&b, "stratum_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "stratum_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "stratum_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	b.WriteString("# HELP stratum_token_refreshes_total Number of Stratum token refreshes.\n")
+	b.WriteString("# TYPE stratum_token_refreshes_total counter\n")
+	for _, result := range sortedKeys(m.refreshes) {
+		fmt.Fprintf(&b, "stratum_token_refreshes_total{result=%s} %d\n", labelValue(result), m.refreshes[result])
+	}
+
+	b.WriteString("# HELP stratum_connection_events_total Number of Stratum connection events.\n")
+	b.WriteString("# TYPE stratum_connection_events_total counter\n")
+	connKeys := make([][2]string, 0, len(m.conns))
+	for k := range m.conns {
+		connKeys = append(connKeys, k)
+	}
+	sort.Slice(connKeys, func(i, j int) bool {
+		return connKeys[i][0]+"\x00"+connKeys[i][1] < connKeys[j][0]+"\x00"+connKeys[j][1]
+	})
+	for _, k := range connKeys {
+		fmt.Fprintf(&b, "stratum_connection_events_total{event=%s,result=%s} %d\n", labelValue(k[0]), labelValue(k[1]), m.conns[k])
+	}
+
+	n, err := w.Write([]byte(b.String()))
+
+	return int64(n), err
+}
+
+// labelValue quotes a Prometheus label value.
+func labelValue(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
@@ -0,0 +1,43 @@
+package stratumclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	m := NewPrometheusMetrics()
+	base, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing") {
+			writeJSON(w, http.StatusNotFound, &ErrorResponse{Message: "no such table"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl := &Client{Username: "user", Password: "secret", BaseURL: base.BaseURL, Metrics: m}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	cl.Get("platform/?where=id=1", nil)
+	cl.Get("platform/", nil)
+	cl.Get("missing/", nil)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	text := rec.Body.String()
+	for _, want := range []string{
+		`stratum_requests_total{method="GET",endpoint="login/v1",code="200"} 1`,
+		`stratum_requests_total{method="GET",endpoint="platform",code="200"} 2`,
+		`stratum_requests_total{method="GET",endpoint="missing",code="404"} 1`,
+		`stratum_request_duration_seconds_bucket{method="GET",endpoint="platform",le="+Inf"} 2`,
+		`stratum_request_duration_seconds_count{method="GET",endpoint="missing"} 1`,
+		`stratum_token_refreshes_total{result="success"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %s in:\n%s", want, text)
+		}
+	}
+}
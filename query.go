@@ -0,0 +1,448 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Query holds a parsed API query, like
+// "platform/?select=id,name&where=name~linux&orderby=name", so
+// middleware can inspect and modify queries given by callers before
+// they are sent. Parameters other than select, where and orderby are
+// kept in Params in their original order.
+type Query struct {
+	Table   string
+	Select  []string
+	Where   Expr
+	OrderBy []string
+	Params  []*Param
+}
+
+// Param holds a query parameter.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Expr is a where clause expression. It is one of *Cond, And, Or
+// and *Not.
+//
+// Where clauses are conditions separated by comma, which must all
+// match (And), or by |, of which one must match (Or). Comma binds
+// tighter than |. Conditions can be grouped with parentheses and
+// negated with !( ). A condition compares a column to a value with
+// one of the operators =, !=, <, <=, >, >=, ~ (regular expression
+// match) and !~. Values holding any of ,|()" are double quoted, with
+// \" and \\ escaping quotes and backslashes. The unquoted value null
+// matches missing values, and is parsed as a Cond with Null set,
+// while the quoted "null" is the text null.
+type Expr interface {
+	String() string
+}
+
+// Cond is a single condition of a where clause. Null makes the
+// condition compare the column with null, written unquoted, which
+// matches missing values, and Value is then ignored. A Value of "null"
+// is the text null, and is written quoted.
+type Cond struct {
+	Column string
+	Op     string
+	Value  string
+	Null   bool
+}
+
+// And is a list of expressions which must all match.
+type And []Expr
+
+// Or is a list of expressions of which one must match.
+type Or []Expr
+
+// Not negates an expression.
+type Not struct {
+	Expr Expr
+}
+
+// operators lists the condition operators, two character operators
+// first so they are matched before their prefixes.
+var operators = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+// String returns the condition in query syntax.
+func (c *Cond) String() string {
+	if c.Null {
+		return c.Column + c.Op + "null"
+	}
+
+	return c.Column + c.Op + quoteValue(c.Value)
+}
+
+// String returns the expressions in query syntax.
+func (a And) String() string {
+	parts := make([]string, len(a))
+	for i, e := range a {
+		parts[i] = e.String()
+		if _, ok := e.(Or); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// String returns the expressions in query syntax.
+func (o Or) String() string {
+	parts := make([]string, len(o))
+	for i, e := range o {
+		parts[i] = e.String()
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// String returns the expression in query syntax.
+func (n *Not) String() string {
+	return "!(" + n.Expr.String() + ")"
+}
+
+// quoteValue quotes a condition value if it holds characters with a
+// meaning in where clauses, or is the text null.
+func quoteValue(v string) string {
+	if v != "" && v != "null" && !strings.ContainsAny(v, `,|()"\`) && !strings.HasPrefix(v, " ") {
+		return v
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
+
+// ParseQuery parses an API query. The function returns the query and
+// an error.
+func ParseQuery(query string) (*Query, error) {
+	q := &Query{}
+	path, rawParams, _ := strings.Cut(query, "?")
+	q.Table = strings.Trim(path, "/")
+
+	if rawParams == "" {
+		return q, nil
+	}
+	for _, kv := range strings.Split(rawParams, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query parameter %q: %v", kv, err)
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query parameter %q: %v", kv, err)
+		}
+
+		switch key {
+		case "select":
+			q.Select = splitList(value)
+		case "orderby":
+			q.OrderBy = splitList(value)
+		case "where":
+			e, err := ParseWhere(value)
+			if err != nil {
+				return nil, err
+			}
+			q.Filter(e)
+		default:
+			q.Params = append(q.Params, &Param{Key: key, Value: value})
+		}
+	}
+
+	return q, nil
+}
+
+// Filter adds a where expression which rows must match in addition
+// to the existing where clause.
+func (q *Query) Filter(e Expr) {
+	if e == nil {
+		return
+	}
+	if q.Where == nil {
+		q.Where = e
+		return
+	}
+
+	var and And
+	for _, x := range []Expr{q.Where, e} {
+		if a, ok := x.(And); ok {
+			and = append(and, a...)
+		} else {
+			and = append(and, x)
+		}
+	}
+	q.Where = and
+}
+
+// Get returns the value of a parameter other than select, where and
+// orderby, or "" if it isn't set.
+func (q *Query) Get(key string) string {
+	for _, p := range q.Params {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+
+	return ""
+}
+
+// Set sets a parameter other than select, where and orderby,
+// replacing any existing value.
+func (q *Query) Set(key, value string) {
+	for _, p := range q.Params {
+		if p.Key == key {
+			p.Value = value
+			return
+		}
+	}
+	q.Params = append(q.Params, &Param{Key: key, Value: value})
+}
+
+// String returns the query in the form taken by Get and Call. The
+// parameters are given in the order select, where, orderby and the
+// remaining parameters.
+func (q *Query) String() string {
+	var params []string
+	if len(q.Select) > 0 {
+		params = append(params, "select="+escapeParam(strings.Join(q.Select, ",")))
+	}
+	if q.Where != nil {
+		params = append(params, "where="+escapeParam(q.Where.String()))
+	}
+	if len(q.OrderBy) > 0 {
+		params = append(params, "orderby="+escapeParam(strings.Join(q.OrderBy, ",")))
+	}
+	for _, p := range q.Params {
+		params = append(params, escapeParam(p.Key)+"="+escapeParam(p.Value))
+	}
+
+	s := q.Table + "/"
+	if len(params) > 0 {
+		s += "?" + strings.Join(params, "&")
+	}
+
+	return s
+}
+
+// Conds returns the conditions of a where expression in the order
+// they appear. The conditions can be modified in place.
+func Conds(e Expr) []*Cond {
+	var conds []*Cond
+	var walk func(Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case *Cond:
+			conds = append(conds, e)
+		case And:
+			for _, x := range e {
+				walk(x)
+			}
+		case Or:
+			for _, x := range e {
+				walk(x)
+			}
+		case *Not:
+			walk(e.Expr)
+		}
+	}
+	walk(e)
+
+	return conds
+}
+
+// escapeParam escapes a query parameter value, leaving the
+// characters of the where syntax readable.
+func escapeParam(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || strings.IndexByte("-_.*,=~!<>|()^$:/", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+
+	return b.String()
+}
+
+// splitList splits a comma separated list, returning nil for an
+// empty one.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// ParseWhere parses a where clause. The function returns the
+// expression and an error.
+func ParseWhere(where string) (Expr, error) {
+	p := &whereParser{s: where}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+
+	return e, nil
+}
+
+// whereParser is a recursive descent parser of where clauses.
+type whereParser struct {
+	s   string
+	pos int
+}
+
+// errorf returns a parse error at the current position.
+func (p *whereParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid where clause %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek returns the next byte, or 0 at the end.
+func (p *whereParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+
+	return 0
+}
+
+// parseOr parses expressions separated by |.
+func (p *whereParser) parseOr() (Expr, error) {
+	var or Or
+	for {
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, e)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+
+	return or, nil
+}
+
+// parseAnd parses expressions separated by comma.
+func (p *whereParser) parseAnd() (Expr, error) {
+	var and And
+	for {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, e)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+
+	return and, nil
+}
+
+// parseUnary parses a negation, a parenthesized group or a
+// condition.
+func (p *whereParser) parseUnary() (Expr, error) {
+	switch {
+	case strings.HasPrefix(p.s[p.pos:], "!("):
+		p.pos++
+		e, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: e}, nil
+	case p.peek() == '(':
+		return p.parseGroup()
+	}
+
+	return p.parseCond()
+}
+
+// parseGroup parses an expression in parentheses.
+func (p *whereParser) parseGroup() (Expr, error) {
+	p.pos++
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ')' {
+		return nil, p.errorf("missing )")
+	}
+	p.pos++
+
+	return e, nil
+}
+
+// parseCond parses a single condition.
+func (p *whereParser) parseCond() (*Cond, error) {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("=!<>~,|()", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	c := &Cond{Column: strings.TrimSpace(p.s[start:p.pos])}
+	if c.Column == "" {
+		return nil, p.errorf("missing column")
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			c.Op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if c.Op == "" {
+		return nil, p.errorf("missing operator after %q", c.Column)
+	}
+
+	if p.peek() != '"' {
+		start = p.pos
+		for p.pos < len(p.s) && strings.IndexByte(",|()", p.s[p.pos]) < 0 {
+			p.pos++
+		}
+		c.Value = p.s[start:p.pos]
+		if c.Value == "null" {
+			c.Value, c.Null = "", true
+		}
+		return c, nil
+	}
+
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("unterminated quoted value")
+		}
+		ch := p.s[p.pos]
+		p.pos++
+		switch ch {
+		case '"':
+			c.Value = b.String()
+			return c, nil
+		case '\\':
+			if p.pos < len(p.s) {
+				ch = p.s[p.pos]
+				p.pos++
+			}
+		}
+		b.WriteByte(ch)
+	}
+}
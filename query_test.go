@@ -0,0 +1,101 @@
+package stratumclient
+
+import (
+	"testing"
+)
+
+func TestParseWhere(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{"name~linux", "name~linux"},
+		{"a=1,b!=2|c>=3", "a=1,b!=2|c>=3"},
+		{"a=1,(b=2|c=3)", "a=1,(b=2|c=3)"},
+		{"!(a=null),b!~^x", "!(a=null),b!~^x"},
+		{`name="a,b (c)",x<"say \"hi\""`, `name="a,b (c)",x<"say \"hi\""`},
+		{"((a=1))", "a=1"},
+		{`name="null",note=null`, `name="null",note=null`},
+	}
+	for _, tt := range tests {
+		e, err := ParseWhere(tt.where)
+		if err != nil {
+			t.Errorf("parse %s: %v", tt.where, err)
+			continue
+		}
+		if got := e.String(); got != tt.want {
+			t.Errorf("parse %s: got %s, want %s", tt.where, got, tt.want)
+		}
+	}
+
+	for _, where := range []string{"", "name", "=1", "(a=1", "a=1)", `a="x`} {
+		if _, err := ParseWhere(where); err == nil {
+			t.Errorf("parse %q: expected error", where)
+		}
+	}
+
+	e, _ := ParseWhere("a=1|b=2,c=3")
+	or, ok := e.(Or)
+	if !ok || len(or) != 2 {
+		t.Fatalf("precedence: got %#v", e)
+	}
+	if and, ok := or[1].(And); !ok || len(and) != 2 {
+		t.Fatalf("precedence: got %#v", or[1])
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery("platform/?orderby=name&select=id,name&where=name~%22linux%7Cbsd%22&limit=10")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if q.Table != "platform" || len(q.Select) != 2 || len(q.OrderBy) != 1 || q.Get("limit") != "10" {
+		t.Fatalf("parse: got %+v", q)
+	}
+
+	// scope the query to a tenant, whatever the caller asked for
+	q.Filter(&Cond{Column: "tenant", Op: "=", Value: "acme"})
+	for _, c := range Conds(q.Where) {
+		if c.Column == "name" {
+			c.Op = "="
+		}
+	}
+	q.Set("limit", "5")
+
+	want := "platform/?select=id,name&where=name=%22linux|bsd%22,tenant=acme&orderby=name&limit=5"
+	if got := q.String(); got != want {
+		t.Fatalf("string: got %s, want %s", got, want)
+	}
+
+	q, err = ParseQuery(want)
+	if err != nil {
+		t.Fatalf("parse rendered: %v", err)
+	}
+	if got := q.String(); got != want {
+		t.Fatalf("round trip: got %s, want %s", got, want)
+	}
+
+	q, _ = ParseQuery("host/")
+	q.Filter(&Cond{Column: "name", Op: "=", Value: "a b&c"})
+	if got := q.String(); got != `host/?where=name=a%20b%26c` {
+		t.Fatalf("escape: got %s", got)
+	}
+}
+
+func TestParseQueryNull(t *testing.T) {
+	for _, query := range []string{`host/?where=name=%22null%22`, `host/?where=name=null`} {
+		q, err := ParseQuery(query)
+		if err != nil {
+			t.Fatalf("parse %s: %v", query, err)
+		}
+		if got := q.String(); got != query {
+			t.Errorf("round trip %s: got %s", query, got)
+		}
+	}
+
+	e, _ := ParseWhere(`a="null",b=null`)
+	conds := Conds(e)
+	if conds[0].Null || conds[0].Value != "null" || !conds[1].Null {
+		t.Fatalf("conds: got %+v, %+v", conds[0], conds[1])
+	}
+}
@@ -0,0 +1,125 @@
+package stratumclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitRetryPolicy retries calls rejected with 429 Too Many
+// Requests when rate limiting is enabled without a Retry policy.
+var rateLimitRetryPolicy = &RetryPolicy{
+	MaxAttempts:       3,
+	RetryableStatus:   []int{http.StatusTooManyRequests},
+	RespectRetryAfter: true,
+}
+
+// limiter is a token bucket rate limiter shared by all calls of a
+// client. A nil limiter never blocks.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	until  time.Time
+}
+
+// newLimiter returns a limiter allowing rate requests per second
+// with bursts of burst requests, or nil if rate is zero.
+func newLimiter(rate float64, burst int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// rateLimiter returns the limiter of the client. It is read under
+// limiterMu, since Fleet.Open replaces the limiter of clients which
+// may be in use. The lock isn't c.mu, which is held while logging in.
+func (c *Client) rateLimiter() *limiter {
+	if c.limiterMu == nil {
+		return c.limiter
+	}
+	c.limiterMu.Lock()
+	defer c.limiterMu.Unlock()
+
+	return c.limiter
+}
+
+// setLimiter replaces the limiter of the client, see rateLimiter.
+func (c *Client) setLimiter(l *limiter) {
+	if c.limiterMu == nil {
+		c.limiter = l
+		return
+	}
+	c.limiterMu.Lock()
+	defer c.limiterMu.Unlock()
+
+	c.limiter = l
+}
+
+// wait blocks until a request may be sent or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if now.Before(l.until) {
+			d := l.until.Sub(now)
+			l.mu.Unlock()
+			if err := sleep(ctx, d); err != nil {
+				return err
+			}
+			continue
+		}
+
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		d := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// throttle pauses all requests for the duration given by the
+// Retry-After header of a 429 response, or one second if the header
+// is missing. The bucket is emptied and starts refilling when the
+// pause ends, so the pause isn't followed by a full burst.
+func (l *limiter) throttle(resp *http.Response) {
+	if l == nil {
+		return
+	}
+
+	d, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		d = time.Second
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+	l.tokens = 0
+	l.last = l.until
+}
@@ -0,0 +1,78 @@
+package stratumclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	base, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl := &Client{Username: "user", Password: "secret", BaseURL: base.BaseURL, RateLimit: 50, RateBurst: 2}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	started := time.Now()
+	for i := 0; i < 7; i++ {
+		if err := cl.Get("platform/", nil); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	// the login and 1 request in the burst, then 6 at 50 per second
+	if elapsed := time.Since(started); elapsed < 110*time.Millisecond {
+		t.Fatalf("7 requests took %v, want at least 120ms", elapsed)
+	}
+}
+
+func TestRateLimitTooManyRequests(t *testing.T) {
+	calls := 0
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.limiter = newLimiter(1000, 10)
+
+	started := time.Now()
+	if err := cl.Get("platform/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if calls != 2 || time.Since(started) < time.Second {
+		t.Fatalf("calls %d after %v", calls, time.Since(started))
+	}
+}
+
+func TestLimiterContext(t *testing.T) {
+	l := newLimiter(0.1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); err == nil {
+		t.Fatalf("wait: expected context error")
+	}
+	if newLimiter(0, 0) != nil {
+		t.Fatalf("zero rate: expected nil limiter")
+	}
+}
+
+func TestLimiterThrottle(t *testing.T) {
+	l := newLimiter(1000, 10)
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+	l.throttle(resp)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens != 0 || !l.last.Equal(l.until) || time.Until(l.until) <= time.Second {
+		t.Fatalf("throttle: tokens %v, last %v, until %v", l.tokens, l.last, l.until)
+	}
+}
@@ -0,0 +1,30 @@
+package stratumclient
+
+import (
+	"io"
+)
+
+// GetRaw will perform a GET API call to stratum and return the
+// response body as is. Combined with WithAccept it retrieves
+// non-JSON representations, like CSV exports:
+//
+//	csv, err := c.GetRaw("host/?select=name,ram", WithAccept("text/csv"))
+//
+// The function returns the response body and an error.
+func (c *Client) GetRaw(query string, opts ...CallOption) ([]byte, error) {
+	return c.Call("GET", query, nil, opts...)
+}
+
+// GetReader will perform a GET API call to stratum like GetRaw, but
+// returns the response body as it is read from the network, so large
+// exports can be copied to a file without being held in memory. The
+// caller must close the returned body. The function returns the
+// response body and an error.
+func (c *Client) GetReader(query string, opts ...CallOption) (io.ReadCloser, error) {
+	resp, err := c.do("GET", query, nil, newCallOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
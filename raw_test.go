@@ -0,0 +1,43 @@
+package stratumclient
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGetRaw(t *testing.T) {
+	const csv = "name,ram\nweb1,8\n"
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			io.WriteString(w, csv)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		io.WriteString(w, `[{"name":"web1","ram":8}]`)
+	})
+
+	body, err := cl.GetRaw("host/?select=name,ram", WithAccept("text/csv"))
+	if err != nil {
+		t.Fatalf("get raw: %v", err)
+	}
+	if string(body) != csv {
+		t.Fatalf("get raw: got %q", body)
+	}
+
+	rc, err := cl.GetReader("host/", WithAccept("text/csv"))
+	if err != nil {
+		t.Fatalf("get reader: %v", err)
+	}
+	body, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(body) != csv {
+		t.Fatalf("get reader: got %q, %v", body, err)
+	}
+
+	var rows []map[string]interface{}
+	if err := cl.Get("host/", &rows); err != nil || len(rows) != 1 {
+		t.Fatalf("get with charset: got %v, %v", rows, err)
+	}
+}
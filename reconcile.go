@@ -0,0 +1,200 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Record holds a single inventory row as column names mapped to
+// their values in text form.
+type Record map[string]string
+
+// FieldDiff holds a field whose value differs between the inventory
+// source and Stratum.
+type FieldDiff struct {
+	Field   string
+	Source  string
+	Stratum string
+}
+
+// Mismatch holds a row found in both the inventory source and
+// Stratum with differing fields.
+type Mismatch struct {
+	Key   string
+	Diffs []*FieldDiff
+}
+
+// Reconciler compares the rows of a Stratum query with an external
+// inventory source. Rows are matched on the Key column and the
+// Fields columns are compared. If Fields is empty, all columns of
+// the source records except the key are compared.
+type Reconciler struct {
+	Query  string
+	Key    string
+	Fields []string
+	Source func() ([]Record, error)
+}
+
+// ReconcileReport holds the outcome of a reconciliation. Missing
+// holds source rows not found in Stratum, Stale holds Stratum rows
+// not found in the source, and Mismatched holds rows with differing
+// fields. All lists are sorted by key.
+type ReconcileReport struct {
+	Missing    []Record
+	Stale      []Record
+	Mismatched []*Mismatch
+}
+
+// Reconcile runs the reconciler query and compares the result with
+// the inventory source. No changes are applied to Stratum. The
+// function returns the report and an error.
+func (c *Client) Reconcile(r *Reconciler) (*ReconcileReport, error) {
+	if r.Key == "" {
+		return nil, fmt.Errorf("missing: Key")
+	}
+	if r.Source == nil {
+		return nil, fmt.Errorf("missing: Source")
+	}
+
+	source, err := r.Source()
+	if err != nil {
+		return nil, fmt.Errorf("inventory source: %w", err)
+	}
+
+	content, err := c.Call("GET", r.Query, nil)
+	if err != nil {
+		return nil, err
+	}
+	stratum, err := decodeRecords(content)
+	if err != nil {
+		return nil, err
+	}
+
+	want := make(map[string]Record)
+	for _, rec := range source {
+		want[rec[r.Key]] = rec
+	}
+	have := make(map[string]Record)
+	for _, rec := range stratum {
+		have[rec[r.Key]] = rec
+	}
+
+	report := &ReconcileReport{}
+	for _, key := range sortedKeys(want) {
+		src := want[key]
+		cur, ok := have[key]
+		if !ok {
+			report.Missing = append(report.Missing, src)
+			continue
+		}
+
+		fields := r.Fields
+		if len(fields) == 0 {
+			for _, f := range sortedKeys(src) {
+				if f != r.Key {
+					fields = append(fields, f)
+				}
+			}
+		}
+		m := &Mismatch{Key: key}
+		for _, f := range fields {
+			if src[f] != cur[f] {
+				m.Diffs = append(m.Diffs, &FieldDiff{Field: f, Source: src[f], Stratum: cur[f]})
+			}
+		}
+		if len(m.Diffs) > 0 {
+			report.Mismatched = append(report.Mismatched, m)
+		}
+	}
+	for _, key := range sortedKeys(have) {
+		if _, ok := want[key]; !ok {
+			report.Stale = append(report.Stale, have[key])
+		}
+	}
+
+	return report, nil
+}
+
+// ReadCSV reads inventory records from CSV text. The first line
+// holds the column names.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	lines, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	header := lines[0]
+	var records []Record
+	for _, line := range lines[1:] {
+		rec := make(Record, len(header))
+		for i, col := range header {
+			if i < len(line) {
+				rec[col] = line[i]
+			}
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// decodeRecords decodes a JSON array of rows into records.
+func decodeRecords(content []byte) ([]Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		rec := make(Record, len(row))
+		for k, v := range row {
+			rec[k] = valueText(v)
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// valueText returns the text form of a decoded JSON value. Null
+// becomes the empty string.
+func valueText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	default:
+		text, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(text)
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
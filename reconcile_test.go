@@ -0,0 +1,43 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"name": "alpha", "ip": "10.0.0.1", "rack": 1},
+			{"name": "beta", "ip": "10.0.0.2", "rack": 2},
+			{"name": "gamma", "ip": nil, "rack": 3},
+		})
+	})
+
+	csv := "name,ip,rack\nalpha,10.0.0.1,1\nbeta,10.0.0.9,2\ndelta,10.0.0.4,4\n"
+	report, err := cl.Reconcile(&Reconciler{
+		Query: "host/?select=name,ip,rack",
+		Key:   "name",
+		Source: func() ([]Record, error) {
+			return ReadCSV(strings.NewReader(csv))
+		},
+	})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	if len(report.Missing) != 1 || report.Missing[0]["name"] != "delta" {
+		t.Errorf("missing: got %v", report.Missing)
+	}
+	if len(report.Stale) != 1 || report.Stale[0]["name"] != "gamma" || report.Stale[0]["ip"] != "" {
+		t.Errorf("stale: got %v", report.Stale)
+	}
+	if len(report.Mismatched) != 1 || report.Mismatched[0].Key != "beta" {
+		t.Fatalf("mismatched: got %v", report.Mismatched)
+	}
+	diff := report.Mismatched[0].Diffs
+	if len(diff) != 1 || diff[0].Field != "ip" || diff[0].Source != "10.0.0.9" || diff[0].Stratum != "10.0.0.2" {
+		t.Errorf("diff: got %+v", diff[0])
+	}
+}
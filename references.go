@@ -0,0 +1,136 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// referenceChunk is the number of referenced values looked up per
+// call by CheckReferences.
+const referenceChunk = 100
+
+// Reference is a foreign key of rows to insert: Column of the rows
+// holds a value of the Key column of Table, like platform_id
+// referencing the id of platform. Key defaults to id.
+type Reference struct {
+	Column string
+	Table  string
+	Key    string
+}
+
+// MissingReference is a referenced value which doesn't exist, with
+// the indexes of the rows referencing it.
+type MissingReference struct {
+	Reference *Reference
+	Value     string
+	Rows      []int
+}
+
+// MissingReferencesError is returned by CheckReferences with the
+// referenced values which don't exist.
+type MissingReferencesError struct {
+	Missing []*MissingReference
+}
+
+// Error implements the error interface.
+func (e *MissingReferencesError) Error() string {
+	const max = 10
+	var parts []string
+	for i, m := range e.Missing {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("and %d more", len(e.Missing)-max))
+			break
+		}
+		rows := make([]string, len(m.Rows))
+		for j, r := range m.Rows {
+			rows[j] = fmt.Sprint(r)
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s (row %s)", m.Reference.Column, m.Value, strings.Join(rows, ", ")))
+	}
+
+	return "missing references: " + strings.Join(parts, ", ")
+}
+
+// CheckReferences verifies that the values the rows to insert give
+// for each reference exist in the referenced table, so an import can
+// report all dangling foreign keys up front instead of failing on the
+// first one partway through. The rows are given like post data: a
+// slice of structs or maps, or a JSON array of objects. Rows without
+// a value for a reference column are skipped. The referenced values
+// are looked up with one GET call per table for up to 100 distinct
+// values. The function returns a *MissingReferencesError listing the
+// values which don't exist, with the 0-based indexes of the rows
+// referencing them, or another error if a lookup failed, otherwise
+// nil.
+func (c *Client) CheckReferences(data interface{}, refs []*Reference, opts ...CallOption) error {
+	content, ok := data.([]byte)
+	if !ok {
+		var err error
+		if content, err = c.codec().Marshal(data); err != nil {
+			return err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return fmt.Errorf("rows must be an array of objects: %v", err)
+	}
+
+	e := &MissingReferencesError{}
+	for _, ref := range refs {
+		if ref.Column == "" || ref.Table == "" {
+			return fmt.Errorf("missing: reference Column or Table")
+		}
+		key := ref.Key
+		if key == "" {
+			key = "id"
+		}
+
+		// the rows referencing each distinct value, in order of
+		// appearance
+		var values []string
+		rowsOf := make(map[string][]int)
+		for i, row := range rows {
+			v, ok := row[ref.Column]
+			if !ok || v == nil {
+				continue
+			}
+			text := valueText(v)
+			if _, seen := rowsOf[text]; !seen {
+				values = append(values, text)
+			}
+			rowsOf[text] = append(rowsOf[text], i)
+		}
+
+		found := make(map[string]bool, len(values))
+		for start := 0; start < len(values); start += referenceChunk {
+			chunk := values[start:min(start+referenceChunk, len(values))]
+			in := make([]interface{}, len(chunk))
+			for i, v := range chunk {
+				in[i] = v
+			}
+			q := &Query{Table: strings.Trim(ref.Table, "/"), Select: []string{key}, Where: W(key).In(in...)}
+			existing, err := c.GetRows(q.String(), opts...)
+			if err != nil {
+				return fmt.Errorf("check references of %s: %w", ref.Column, err)
+			}
+			for _, row := range existing {
+				found[valueText(row[key])] = true
+			}
+		}
+
+		for _, v := range values {
+			if !found[v] {
+				e.Missing = append(e.Missing, &MissingReference{Reference: ref, Value: v, Rows: rowsOf[v]})
+			}
+		}
+	}
+	if len(e.Missing) > 0 {
+		return e
+	}
+
+	return nil
+}
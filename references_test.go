@@ -0,0 +1,65 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckReferences(t *testing.T) {
+	var queries []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		q, _ := ParseQuery("platform/?" + r.URL.RawQuery)
+		var rows []map[string]interface{}
+		for _, cond := range Conds(q.Where) {
+			if cond.Value != "7" && cond.Value != "Bergen" {
+				rows = append(rows, map[string]interface{}{cond.Column: cond.Value})
+			}
+		}
+		writeJSON(w, http.StatusOK, rows)
+	})
+
+	type host struct {
+		Name       string `json:"name"`
+		PlatformID int    `json:"platform_id,omitempty"`
+		Site       string `json:"site"`
+	}
+	var hosts []*host
+	for i := 0; i < 150; i++ {
+		hosts = append(hosts, &host{Name: "h", PlatformID: i%120 + 1, Site: "Oslo"})
+	}
+	hosts[3].Site = "Bergen"
+	hosts[4].PlatformID = 0
+
+	refs := []*Reference{
+		{Column: "platform_id", Table: "platform"},
+		{Column: "site", Table: "site", Key: "name"},
+	}
+	err := cl.CheckReferences(hosts, refs)
+	var missing *MissingReferencesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("check: got %v", err)
+	}
+	if len(missing.Missing) != 2 {
+		t.Fatalf("missing: got %d", len(missing.Missing))
+	}
+	if m := missing.Missing[0]; m.Value != "7" || len(m.Rows) != 2 || m.Rows[0] != 6 || m.Rows[1] != 126 {
+		t.Errorf("missing platform: got %+v", m)
+	}
+	if m := missing.Missing[1]; m.Reference != refs[1] || m.Value != "Bergen" || len(m.Rows) != 1 || m.Rows[0] != 3 {
+		t.Errorf("missing site: got %+v", m)
+	}
+	if !strings.HasPrefix(err.Error(), "missing references: platform_id=7 (row 6, 126), site=Bergen (row 3)") {
+		t.Errorf("error: got %q", err)
+	}
+	// 119 distinct platform ids in two chunks, and one site
+	if len(queries) != 3 || !strings.HasPrefix(queries[0], "select=id&where=") {
+		t.Errorf("queries: got %d, first %q", len(queries), queries[0])
+	}
+
+	if err := cl.CheckReferences([]byte(`[{"platform_id": 1}]`), refs[:1]); err != nil {
+		t.Errorf("no missing: got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package stratumclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// requestIDKey is the context key of request IDs.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context which makes API calls made
+// with WithContext send id as their X-Request-ID header, so calls
+// can be correlated with the request of a service calling the API.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx set with
+// ContextWithRequestID, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// requestID returns the X-Request-ID of a call: the header given with
+// WithHeader, the ID of the call context, or else a random ID. All
+// attempts of a call share the ID.
+func requestID(o *callOptions) string {
+	if id := o.header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+	if id := RequestIDFromContext(o.context()); id != "" {
+		return id
+	}
+
+	return randomID()
+}
+
+// randomID returns a random hex encoded 128-bit ID, or "" if the
+// system has no randomness to offer.
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// responseRequestID returns the request ID echoed in the response, or
+// else the one sent with the request.
+func responseRequestID(resp *http.Response) string {
+	if id := resp.Header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+	if resp.Request != nil {
+		return resp.Request.Header.Get("X-Request-ID")
+	}
+
+	return ""
+}
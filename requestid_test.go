@@ -0,0 +1,39 @@
+package stratumclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("X-Request-ID"))
+		writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+
+	ctx := ContextWithRequestID(context.Background(), "req-42")
+	err := cl.Get("host/", nil, WithContext(ctx))
+	var eresp *ErrorResponse
+	if !errors.As(err, &eresp) || eresp.RequestID != "req-42" {
+		t.Fatalf("get: got %v, want error with request ID req-42", err)
+	}
+	if len(seen) != 2 || seen[0] != "req-42" || seen[1] != "req-42" {
+		t.Fatalf("sent IDs: got %q", seen)
+	}
+
+	seen = nil
+	var meta *ResponseMeta
+	_, meta, _ = cl.CallWithMeta("GET", "host/", nil)
+	cl.Get("host/", nil)
+	if len(seen) != 4 || seen[0] == "" || seen[0] != seen[1] || seen[0] == seen[2] {
+		t.Fatalf("generated IDs: got %q", seen)
+	}
+	if meta.RequestID != seen[0] {
+		t.Fatalf("meta: got %q, want %q", meta.RequestID, seen[0])
+	}
+}
@@ -0,0 +1,223 @@
+package stratumclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy holds the configuration for retrying failed API
+// calls. Zero valued fields are replaced by their defaults, except
+// Jitter which is disabled when zero.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the
+	// first one. Default 3.
+	MaxAttempts int `yaml:"maxAttempts" json:"max_attempts"`
+	// InitialBackoff is the delay before the first retry. Default
+	// 500ms.
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initial_backoff"`
+	// MaxBackoff caps the delay between attempts. Default 30s.
+	MaxBackoff time.Duration `yaml:"maxBackoff" json:"max_backoff"`
+	// Multiplier is the factor the delay grows with for each
+	// retry. Default 2.
+	Multiplier float64 `yaml:"multiplier" json:"multiplier"`
+	// Jitter randomizes the delay by up to the given fraction
+	// (0.0-1.0) in either direction.
+	Jitter float64 `yaml:"jitter" json:"jitter"`
+	// RetryableStatus lists the HTTP status codes to retry.
+	// Default 429, 502, 503 and 504.
+	RetryableStatus []int `yaml:"retryableStatus" json:"retryable_status"`
+	// RespectRetryAfter makes the client wait for the duration
+	// given in a Retry-After response header when it is longer
+	// than the computed backoff.
+	RespectRetryAfter bool `yaml:"respectRetryAfter" json:"respect_retry_after"`
+	// RetryPost allows retrying POST calls. POST is not
+	// idempotent and is by default only retried on 429 Too Many
+	// Requests, since the server didn't process the request, or
+	// when the call has an idempotency key, see IdempotencyKeys.
+	RetryPost bool `yaml:"retryPost" json:"retry_post"`
+}
+
+// Attempt holds the outcome of a single failed attempt of an API
+// call.
+type Attempt struct {
+	Time       time.Time
+	StatusCode int
+	Err        error
+	Backoff    time.Duration
+}
+
+// Stringer function for Attempt fmt.String() compliant.
+func (a *Attempt) String() string {
+	return fmt.Sprintf("%s: %v (backoff %s)", a.Time.Format(time.RFC3339Nano), a.Err, a.Backoff)
+}
+
+// RetryError is returned when an API call failed after being
+// retried. It holds the history of all attempts, with Err being the
+// error of the last attempt.
+type RetryError struct {
+	Attempts []*Attempt
+	Err      error
+}
+
+// Error function for RetryError in compliance with the Error
+// interface.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (gave up after %d attempts)", e.Err, len(e.Attempts))
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// defaultRetryableStatus lists the status codes retried when the
+// policy doesn't name any.
+var defaultRetryableStatus = []int{429, 502, 503, 504}
+
+// DefaultRetryPolicy returns a retry policy with default values,
+// 20% jitter, and Retry-After headers respected.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:       3,
+		InitialBackoff:    500 * time.Millisecond,
+		MaxBackoff:        30 * time.Second,
+		Multiplier:        2,
+		Jitter:            0.2,
+		RetryableStatus:   defaultRetryableStatus,
+		RespectRetryAfter: true,
+	}
+}
+
+// attempts returns the maximum number of attempts. A nil policy
+// allows a single attempt.
+func (p *RetryPolicy) attempts() int {
+	if p == nil {
+		return 1
+	}
+	if p.MaxAttempts <= 0 {
+		return 3
+	}
+
+	return p.MaxAttempts
+}
+
+// retryable reports whether a failed attempt should be retried. The
+// response is nil when the request failed before a response was
+// received. Keyed tells whether the request has an idempotency key,
+// which makes POST safe to retry.
+func (p *RetryPolicy) retryable(method string, keyed bool, resp *http.Response, err error) bool {
+	if p == nil {
+		return false
+	}
+
+	if resp == nil {
+		if !isTransient(err) {
+			return false
+		}
+		return method != "POST" || p.RetryPost || keyed
+	}
+
+	codes := p.RetryableStatus
+	if len(codes) == 0 {
+		codes = defaultRetryableStatus
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return method != "POST" || p.RetryPost || keyed || code == http.StatusTooManyRequests
+		}
+	}
+
+	return false
+}
+
+// isTransient reports whether err is a network error which may
+// succeed when retried: timeouts, refused or reset connections, and
+// connections closed before the response was read. Other errors,
+// like certificate and TLS handshake failures or malformed URLs, are
+// permanent. The error may or may not be wrapped in a *url.Error,
+// since the transport behind HTTPClient returns them unwrapped.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns the delay before the next attempt, made at now.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response, now time.Time) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	mult := p.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+
+	d := time.Duration(float64(initial) * math.Pow(mult, float64(attempt-1)))
+	if d > max || d <= 0 {
+		d = max
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+
+	if p.RespectRetryAfter && resp != nil {
+		if ra, ok := retryAfter(resp.Header.Get("Retry-After"), now); ok && ra > d {
+			d = ra
+		}
+	}
+
+	return d
+}
+
+// retryAfter parses the value of a Retry-After header, which is
+// either a number of seconds or an HTTP date, received at now.
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done, in which case the context
+// error is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,141 @@
+package stratumclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryStatus(t *testing.T) {
+	calls := 0
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []map[string]int{{"id": 1}})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	var rows []map[string]int
+	if err := cl.Get("platform/", &rows); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if calls != 3 || len(rows) != 1 {
+		t.Fatalf("calls %d, rows %d", calls, len(rows))
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	calls := 0
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeJSON(w, http.StatusBadGateway, &ErrorResponse{Message: "down"})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+
+	if err := cl.Get("platform/", nil); err == nil {
+		t.Fatalf("get: expected error")
+	}
+	if calls != 2 {
+		t.Fatalf("calls: got %d, want 2", calls)
+	}
+}
+
+func TestRetryPost(t *testing.T) {
+	calls := 0
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	if err := cl.Post("platform/", map[string]string{"name": "x"}, nil); err == nil {
+		t.Fatalf("post: expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("calls: got %d, want 1", calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, RespectRetryAfter: true}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if got := p.backoff(attempt+1, nil, time.Now()); got != want {
+			t.Errorf("attempt %d: got %v, want %v", attempt+1, got, want)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"10"}}}
+	if got := p.backoff(1, resp, time.Now()); got != 10*time.Second {
+		t.Errorf("retry-after: got %v, want 10s", got)
+	}
+}
+
+func TestRetryError(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	err := cl.Get("platform/", nil)
+	var rerr *RetryError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("get: got %v, want RetryError", err)
+	}
+	if len(rerr.Attempts) != 3 {
+		t.Fatalf("attempts: got %d, want 3", len(rerr.Attempts))
+	}
+	for i, a := range rerr.Attempts {
+		if a.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d: status %d", i, a.StatusCode)
+		}
+	}
+	if rerr.Attempts[0].Backoff != time.Millisecond || rerr.Attempts[2].Backoff != 0 {
+		t.Errorf("backoff: got %v and %v", rerr.Attempts[0].Backoff, rerr.Attempts[2].Backoff)
+	}
+	var eresp *ErrorResponse
+	if !errors.As(err, &eresp) || eresp.Message != "busy" {
+		t.Fatalf("unwrap: got %v", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// The test server's certificate isn't trusted by the default
+	// client, so the handshake fails permanently.
+	_, err := http.Get(srv.URL)
+	if err == nil || isTransient(err) {
+		t.Errorf("untrusted certificate: got transient %v for %v", isTransient(err), err)
+	}
+
+	addr := srv.Listener.Addr().String()
+	srv.Close()
+	_, err = http.Get("http://" + addr)
+	if !isTransient(err) {
+		t.Errorf("refused connection: got permanent for %v", err)
+	}
+
+	_, err = http.Get("ftp://" + addr)
+	if isTransient(err) {
+		t.Errorf("unsupported scheme: got transient for %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://"+addr, nil)
+	_, err = http.DefaultClient.Do(req)
+	if !isTransient(err) {
+		t.Errorf("timeout: got permanent for %v", err)
+	}
+	if isTransient(context.Canceled) {
+		t.Errorf("canceled: got transient")
+	}
+}
@@ -0,0 +1,155 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Row is a result row decoded as a map, for ad-hoc queries where
+// defining a struct is overkill. The typed accessors return the zero
+// value for missing, null and unconvertible columns; use Has and
+// IsNull to tell them apart.
+type Row map[string]interface{}
+
+// Rows is a list of result rows, as returned by GetRows.
+type Rows []Row
+
+// GetRows will perform a GET API call to stratum and return the
+// response rows as maps, with numbers kept as json.Number so large
+// IDs keep all their digits. The function returns the rows and an
+// error.
+//
+//	rows, err := c.GetRows("host/?select=id,name")
+//	for _, row := range rows {
+//		fmt.Println(row.Int("id"), row.String("name"))
+//	}
+func (c *Client) GetRows(query string, opts ...CallOption) (Rows, error) {
+	content, err := c.Call("GET", query, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	content, _ = unwrapEnvelope(content)
+	if err := c.checkOrder(query, content, newCallOptions(opts)); err != nil {
+		return nil, err
+	}
+
+	rows := Rows{}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return rows, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Has reports whether the row has the column, null or not.
+func (r Row) Has(column string) bool {
+	_, ok := r[column]
+
+	return ok
+}
+
+// IsNull reports whether the column is null or missing.
+func (r Row) IsNull(column string) bool {
+	return r[column] == nil
+}
+
+// String returns the column as text. Numbers and booleans are
+// formatted, and objects and arrays are returned as JSON.
+func (r Row) String(column string) string {
+	v := r[column]
+	if v == nil {
+		return ""
+	}
+
+	return valueText(v)
+}
+
+// Int returns the column as an integer. Numbers given as text are
+// parsed.
+func (r Row) Int(column string) int64 {
+	switch v := r[column].(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return int64(f)
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+
+	return 0
+}
+
+// Float returns the column as a floating point number. Numbers given
+// as text are parsed.
+func (r Row) Float(column string) float64 {
+	switch v := r[column].(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+
+	return 0
+}
+
+// Bool returns the column as a boolean. Text like "true" and "1" is
+// parsed.
+func (r Row) Bool(column string) bool {
+	switch v := r[column].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+
+	return false
+}
+
+// Time returns the column as a time, parsed from RFC 3339 text.
+func (r Row) Time(column string) time.Time {
+	s, _ := r[column].(string)
+	t, _ := time.Parse(time.RFC3339Nano, s)
+
+	return t
+}
+
+// Columns returns the names of the columns found in any of the rows,
+// in sorted order.
+func (rs Rows) Columns() []string {
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		for k := range r {
+			seen[k] = true
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// Column returns the values of a column, one per row, with nil for
+// rows lacking it, for column oriented processing.
+func (rs Rows) Column(name string) []interface{} {
+	values := make([]interface{}, len(rs))
+	for i, r := range rs {
+		values[i] = r[name]
+	}
+
+	return values
+}
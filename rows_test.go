@@ -0,0 +1,42 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetRows(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"id": 9007199254740993, "name": "db1", "cpus": "8", "load": 0.5, "active": true, "created": "2024-05-01T10:00:00Z", "note": null},
+			{"id": 2, "name": "db2", "tags": ["a", "b"]}
+		]`))
+	})
+
+	rows, err := cl.GetRows("host/")
+	if err != nil {
+		t.Fatalf("get rows: %v", err)
+	}
+	r := rows[0]
+	if r.Int("id") != 9007199254740993 || r.String("name") != "db1" || r.Int("cpus") != 8 || r.Float("load") != 0.5 {
+		t.Errorf("accessors: got %v", r)
+	}
+	if !r.Bool("active") || !r.Time("created").Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("bool and time: got %v", r)
+	}
+	if !r.Has("note") || !r.IsNull("note") || r.Has("tags") || r.String("note") != "" || r.Int("missing") != 0 {
+		t.Errorf("null and missing: got %v", r)
+	}
+	if got := rows[1].String("tags"); got != `["a","b"]` {
+		t.Errorf("array as text: got %s", got)
+	}
+
+	if cols := rows.Columns(); len(cols) != 8 || cols[0] != "active" {
+		t.Errorf("columns: got %v", cols)
+	}
+	if names := rows.Column("name"); len(names) != 2 || names[1] != "db2" {
+		t.Errorf("column: got %v", names)
+	}
+}
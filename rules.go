@@ -0,0 +1,181 @@
+package stratumclient
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Rule holds a declarative data quality rule for a table column. A
+// rule can require the column to be set, to match a regular
+// expression, and to reference an existing value in another table
+// given as "table.column". The rows checked can be narrowed with a
+// where clause. Key names the column identifying the rows in the
+// violation report, default id.
+//
+//	rules:
+//	  - name: host platform exists
+//	    table: host
+//	    column: platform_id
+//	    references: platform.id
+//	  - name: image url is https
+//	    table: platform
+//	    column: image_url
+//	    match: ^https://
+type Rule struct {
+	Name       string `yaml:"name" json:"name"`
+	Table      string `yaml:"table" json:"table"`
+	Key        string `yaml:"key" json:"key"`
+	Column     string `yaml:"column" json:"column"`
+	Where      string `yaml:"where" json:"where"`
+	Required   bool   `yaml:"required" json:"required"`
+	Match      string `yaml:"match" json:"match"`
+	References string `yaml:"references" json:"references"`
+}
+
+// Violation holds a row breaking a rule.
+type Violation struct {
+	Rule    string
+	Table   string
+	Key     string
+	Column  string
+	Value   string
+	Message string
+}
+
+// Stringer function for Violation fmt.String() compliant.
+func (v *Violation) String() string {
+	return fmt.Sprintf("%s: %s[%s].%s: %s", v.Rule, v.Table, v.Key, v.Column, v.Message)
+}
+
+// RuleReport holds the outcome of checking a set of rules.
+type RuleReport struct {
+	Rules      int
+	Rows       int
+	Violations []*Violation
+}
+
+// CheckRules evaluates the rules against the API and reports the
+// rows violating them. Each rule is checked with a select query on
+// its table, and referenced tables are fetched once and shared
+// between rules. The function returns the report and an error.
+func (c *Client) CheckRules(rules []*Rule) (*RuleReport, error) {
+	report := &RuleReport{}
+	refs := make(map[string]map[string]bool)
+
+	for _, rule := range rules {
+		if err := c.checkRule(rule, refs, report); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		report.Rules++
+	}
+
+	return report, nil
+}
+
+// checkRule evaluates a single rule and adds its violations to the
+// report.
+func (c *Client) checkRule(rule *Rule, refs map[string]map[string]bool, report *RuleReport) error {
+	if rule.Table == "" || rule.Column == "" {
+		return fmt.Errorf("missing: table or column")
+	}
+	key := rule.Key
+	if key == "" {
+		key = "id"
+	}
+
+	var match *regexp.Regexp
+	if rule.Match != "" {
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return err
+		}
+		match = re
+	}
+
+	var refSet map[string]bool
+	if rule.References != "" {
+		set, err := c.referenceSet(rule.References, refs)
+		if err != nil {
+			return err
+		}
+		refSet = set
+	}
+
+	query := fmt.Sprintf("%s/?select=%s,%s", strings.Trim(rule.Table, "/"), url.QueryEscape(key), url.QueryEscape(rule.Column))
+	if rule.Where != "" {
+		query += "&where=" + url.QueryEscape(rule.Where)
+	}
+	content, err := c.Call("GET", query, nil)
+	if err != nil {
+		return err
+	}
+	rows, err := decodeRecords(content)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		report.Rows++
+		value := row[rule.Column]
+		violation := func(msg string) {
+			report.Violations = append(report.Violations, &Violation{
+				Rule:    rule.Name,
+				Table:   rule.Table,
+				Key:     row[key],
+				Column:  rule.Column,
+				Value:   value,
+				Message: msg,
+			})
+		}
+
+		if value == "" {
+			if rule.Required {
+				violation("value is missing")
+			}
+			continue
+		}
+		if match != nil && !match.MatchString(value) {
+			violation(fmt.Sprintf("value %q does not match %s", value, rule.Match))
+		}
+		if refSet != nil && !refSet[value] {
+			violation(fmt.Sprintf("value %q does not reference an existing %s", value, rule.References))
+		}
+	}
+
+	return nil
+}
+
+// referenceSet returns the set of values of a "table.column"
+// reference, fetching it once.
+func (c *Client) referenceSet(ref string, refs map[string]map[string]bool) (map[string]bool, error) {
+	if set, ok := refs[ref]; ok {
+		return set, nil
+	}
+
+	i := strings.LastIndexByte(ref, '.')
+	if i <= 0 || i == len(ref)-1 {
+		return nil, fmt.Errorf("invalid reference %q, want table.column", ref)
+	}
+	table, column := ref[:i], ref[i+1:]
+
+	content, err := c.Call("GET", fmt.Sprintf("%s/?select=%s", table, url.QueryEscape(column)), nil)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := decodeRecords(content)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if v := row[column]; v != "" {
+			set[v] = true
+		}
+	}
+	refs[ref] = set
+
+	return set, nil
+}
@@ -0,0 +1,70 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCheckRules(t *testing.T) {
+	fetched := make(map[string]int)
+	var where string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fetched[r.URL.Path]++
+		if v := r.URL.Query().Get("where"); v != "" {
+			where = v
+		}
+		switch r.URL.Path {
+		case "//stratum/v1/platform/":
+			writeJSON(w, http.StatusOK, []map[string]interface{}{
+				{"id": 1, "image_url": "https://img/1"},
+				{"id": 2, "image_url": "http://img/2"},
+				{"id": 3, "image_url": nil},
+			})
+		case "//stratum/v1/host/":
+			writeJSON(w, http.StatusOK, []map[string]interface{}{
+				{"id": 10, "platform_id": 1},
+				{"id": 11, "platform_id": 7},
+				{"id": 12, "platform_id": nil},
+			})
+		}
+	})
+
+	var rules []*Rule
+	err := json.Unmarshal([]byte(`[
+		{"name": "platform exists", "table": "host", "column": "platform_id", "references": "platform.id", "required": true},
+		{"name": "platform exists again", "table": "host", "column": "platform_id", "references": "platform.id"},
+		{"name": "https image", "table": "platform", "column": "image_url", "match": "^https://", "where": "name~red hat & co #1"}
+	]`), &rules)
+	if err != nil {
+		t.Fatalf("unmarshal rules: %v", err)
+	}
+
+	report, err := cl.CheckRules(rules)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if report.Rules != 3 || report.Rows != 9 {
+		t.Errorf("counts: got %d rules, %d rows", report.Rules, report.Rows)
+	}
+
+	var got []string
+	for _, v := range report.Violations {
+		got = append(got, v.Rule+":"+v.Key)
+	}
+	want := []string{"platform exists:11", "platform exists:12", "platform exists again:11", "https image:2"}
+	if len(got) != len(want) {
+		t.Fatalf("violations: got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("violation %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+	if where != "name~red hat & co #1" {
+		t.Errorf("where: got %q", where)
+	}
+	if fetched["//stratum/v1/platform/"] != 2 {
+		t.Errorf("platform fetched %d times, want 2", fetched["//stratum/v1/platform/"])
+	}
+}
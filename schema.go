@@ -0,0 +1,253 @@
+package stratumclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema holds the tables and columns served by the API.
+type Schema struct {
+	Tables map[string]*TableSchema
+}
+
+// TableSchema holds the columns of a table.
+type TableSchema struct {
+	Name    string
+	Columns map[string]*ColumnSchema
+}
+
+// ColumnSchema holds the name and JSON type of a column, like
+// "integer", "number", "string" or "boolean". Required columns must
+// be given when rows are created, and read-only columns, like
+// generated IDs, can't be written.
+type ColumnSchema struct {
+	Name     string
+	Type     string
+	Format   string
+	Nullable bool
+	Required bool
+	ReadOnly bool
+}
+
+// TableNames returns the table names in sorted order.
+func (s *Schema) TableNames() []string {
+	return sortedKeys(s.Tables)
+}
+
+// ColumnNames returns the column names of the table in sorted order.
+func (t *TableSchema) ColumnNames() []string {
+	return sortedKeys(t.Columns)
+}
+
+// schemaCache holds the schema once loaded. Loading is retried on
+// the next use if it failed.
+type schemaCache struct {
+	mu     sync.Mutex
+	schema *Schema
+	caps   *Capabilities
+}
+
+// Schema returns the API schema, loading it from the server's
+// OpenAPI document on first use. The document is fetched from
+// SchemaURL, or from openapi.json in the docs directory next to the
+// API path, like https://server/stratum/docs/openapi.json. The
+// schema is loaded once and shared by concurrent callers. The
+// function returns the schema and an error.
+func (c *Client) Schema() (*Schema, error) {
+	if !c.opened {
+		return nil, fmt.Errorf("config not opened with Open()")
+	}
+
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	if c.schema.schema != nil {
+		return c.schema.schema, nil
+	}
+
+	s, err := c.loadSchema()
+	if err != nil {
+		return nil, err
+	}
+	c.schema.schema = s
+
+	return s, nil
+}
+
+// SetSchema sets the schema used by the client instead of loading it
+// from the server, for servers which don't publish an OpenAPI
+// document. It may be called before Open.
+func (c *Client) SetSchema(s *Schema) {
+	if c.schema == nil {
+		c.schema = &schemaCache{}
+	}
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+
+	c.schema.schema = s
+}
+
+// schemaURL returns the URL of the OpenAPI document.
+func (c *Client) schemaURL() string {
+	if c.SchemaURL != "" {
+		return c.SchemaURL
+	}
+
+	return c.url.String() + path.Join(path.Dir(strings.TrimSuffix(c.prefix, "/")), "docs", "openapi.json")
+}
+
+// loadSchema fetches and parses the OpenAPI document.
+func (c *Client) loadSchema() (*Schema, error) {
+	doc, err := c.fetchOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseOpenAPISchema(doc)
+}
+
+// fetchOpenAPI fetches the OpenAPI document.
+func (c *Client) fetchOpenAPI() ([]byte, error) {
+	token, err := c.bearer()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout)*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", c.schemaURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema: %s: %s", c.schemaURL(), resp.Status)
+	}
+
+	return body, nil
+}
+
+// openAPISchema is the part of an OpenAPI schema object the client
+// uses.
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       interface{}               `json:"type"`
+	Format     string                    `json:"format"`
+	Nullable   bool                      `json:"nullable"`
+	ReadOnly   bool                      `json:"readOnly"`
+	Items      *openAPISchema            `json:"items"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+// ParseOpenAPISchema returns the schema described by an OpenAPI 3
+// document. Each path with a GET operation is a table, whose columns
+// are the properties of the items of its JSON response. The function
+// returns the schema and an error.
+func ParseOpenAPISchema(doc []byte) (*Schema, error) {
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema *openAPISchema `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]*openAPISchema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("schema: %v", err)
+	}
+
+	resolve := func(s *openAPISchema) *openAPISchema {
+		for i := 0; s != nil && s.Ref != "" && i < 8; i++ {
+			s = spec.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		}
+		return s
+	}
+
+	schema := &Schema{Tables: make(map[string]*TableSchema)}
+	for p, ops := range spec.Paths {
+		get, ok := ops["get"]
+		if !ok {
+			continue
+		}
+		content, ok := get.Responses["200"].Content["application/json"]
+		if !ok {
+			continue
+		}
+		rows := resolve(content.Schema)
+		if rows != nil && rows.Items != nil {
+			rows = resolve(rows.Items)
+		}
+		if rows == nil || len(rows.Properties) == 0 {
+			continue
+		}
+
+		name := strings.Trim(p, "/")
+		t := &TableSchema{Name: name, Columns: make(map[string]*ColumnSchema)}
+		for col, prop := range rows.Properties {
+			prop = resolve(prop)
+			if prop == nil {
+				prop = &openAPISchema{}
+			}
+			typ, nullable := schemaType(prop.Type)
+			t.Columns[col] = &ColumnSchema{Name: col, Type: typ, Format: prop.Format, Nullable: nullable || prop.Nullable, ReadOnly: prop.ReadOnly}
+		}
+		for _, col := range rows.Required {
+			if cs, ok := t.Columns[col]; ok {
+				cs.Required = true
+			}
+		}
+		schema.Tables[name] = t
+	}
+
+	return schema, nil
+}
+
+// schemaType returns the type of an OpenAPI schema, which is either
+// a type name or, in OpenAPI 3.1, a list of names which may include
+// "null".
+func schemaType(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, false
+	case []interface{}:
+		var types []string
+		nullable := false
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				if s == "null" {
+					nullable = true
+				} else {
+					types = append(types, s)
+				}
+			}
+		}
+		sort.Strings(types)
+		return strings.Join(types, ","), nullable
+	}
+
+	return "", false
+}
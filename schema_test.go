@@ -0,0 +1,127 @@
+package stratumclient
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const testOpenAPI = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/platform/": {
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Platform"}}}}}}},
+      "post": {}
+    },
+    "/host/": {
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Hosts"}}}}}}
+    },
+    "/login/v1": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "object"}}}}}}}
+  },
+  "components": {
+    "schemas": {
+      "Platform": {"type": "object", "required": ["name"], "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}},
+      "Hosts": {"type": "array", "items": {"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}, "ram": {"type": ["integer", "null"]}, "platform_id": {"type": "integer", "nullable": true}}}}
+    }
+  }
+}`
+
+func TestSchema(t *testing.T) {
+	var loads int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stratum/docs/openapi.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&loads, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testOpenAPI))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cl.Schema(); err != nil {
+				t.Errorf("schema: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if loads != 1 {
+		t.Fatalf("schema loaded %d times, want 1", loads)
+	}
+
+	s, _ := cl.Schema()
+	if got := s.TableNames(); len(got) != 2 || got[0] != "host" || got[1] != "platform" {
+		t.Fatalf("tables: got %q", got)
+	}
+	host := s.Tables["host"]
+	if len(host.Columns) != 4 || !host.Columns["ram"].Nullable || host.Columns["ram"].Type != "integer" || !host.Columns["platform_id"].Nullable {
+		t.Fatalf("host columns: got %+v", host.Columns)
+	}
+	if !s.Tables["platform"].Columns["name"].Required {
+		t.Fatalf("platform name not required")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s, err := ParseOpenAPISchema([]byte(testOpenAPI))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		{"", []string{"host/", "platform/"}},
+		{"pl", []string{"platform/"}},
+		{"host", []string{"host/"}},
+		{"host/", []string{"host/?"}},
+		{"host/?s", []string{"host/?select="}},
+		{"host/?select=id,na", []string{"host/?select=id,name"}},
+		{"host/?select=id&orderby=-r", []string{"host/?select=id&orderby=-ram"}},
+		{"host/?where=name~web,p", []string{"host/?where=name~web,platform_id"}},
+		{"host/?where=(ram<", []string{"host/?where=(ram<=", "host/?where=(ram<"}},
+		{"host/?where=ram=", nil},
+		{"host/?where=ram>=4", nil},
+		{"nohost/?s", nil},
+	}
+	for _, tt := range tests {
+		got := s.Complete(tt.partial)
+		if len(got) != len(tt.want) {
+			t.Errorf("complete %q: got %q, want %q", tt.partial, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("complete %q: got %q, want %q", tt.partial, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if got := s.Complete("platform/?where=name"); len(got) != 1+len(operators) {
+		t.Errorf("complete column: got %q", got)
+	}
+}
+
+func TestWarmupSchema(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stratum/docs/openapi.json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testOpenAPI))
+		}
+	})
+	cl.PreloadSchema = true
+
+	if err := cl.Warmup(1); err != nil {
+		t.Fatalf("warmup: %v", err)
+	}
+	if cl.schema.schema == nil {
+		t.Fatalf("schema not loaded by warmup")
+	}
+}
@@ -0,0 +1,70 @@
+package stratumclient
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretProvider returns a secret, like a password, when it is
+// needed, so it doesn't have to be kept in the client for its
+// lifetime.
+type SecretProvider interface {
+	Secret() (string, error)
+}
+
+// EnvSecret is a SecretProvider reading the environment variable of
+// the given name.
+type EnvSecret string
+
+// Secret implements SecretProvider.
+func (e EnvSecret) Secret() (string, error) {
+	s, ok := os.LookupEnv(string(e))
+	if !ok || s == "" {
+		return "", fmt.Errorf("missing: environment variable %s", string(e))
+	}
+
+	return s, nil
+}
+
+// FileSecret is a SecretProvider reading the file of the given name,
+// like a mounted Kubernetes secret. Trailing newlines are removed.
+type FileSecret string
+
+// Secret implements SecretProvider.
+func (f FileSecret) Secret() (string, error) {
+	content, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", err
+	}
+	defer clear(content)
+
+	s := strings.TrimRight(string(content), "\r\n")
+	if s == "" {
+		return "", fmt.Errorf("missing: secret in %s", string(f))
+	}
+
+	return s, nil
+}
+
+// SecretFunc is a SecretProvider calling the function, like a lookup
+// in a vault.
+type SecretFunc func() (string, error)
+
+// Secret implements SecretProvider.
+func (f SecretFunc) Secret() (string, error) {
+	return f()
+}
+
+// passwordProvider returns the provider of the login password, or
+// nil if the client has none.
+func (c *Client) passwordProvider() SecretProvider {
+	switch {
+	case c.PasswordProvider != nil:
+		return c.PasswordProvider
+	case c.PasswordFile != "":
+		return FileSecret(c.PasswordFile)
+	}
+
+	return nil
+}
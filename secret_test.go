@@ -0,0 +1,68 @@
+package stratumclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretProviders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Setenv("TEST_STRATUM_PASSWORD", "from-env")
+
+	for _, tt := range []struct {
+		p    SecretProvider
+		want string
+	}{
+		{FileSecret(file), "from-file"},
+		{EnvSecret("TEST_STRATUM_PASSWORD"), "from-env"},
+		{SecretFunc(func() (string, error) { return "from-func", nil }), "from-func"},
+	} {
+		if got, err := tt.p.Secret(); err != nil || got != tt.want {
+			t.Errorf("secret: got %q, %v, want %q", got, err, tt.want)
+		}
+	}
+	if _, err := EnvSecret("TEST_STRATUM_UNSET").Secret(); err == nil {
+		t.Error("unset environment variable: expected error")
+	}
+}
+
+func TestPasswordProvider(t *testing.T) {
+	var logins []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			_, password, _ := r.BasicAuth()
+			logins = append(logins, password)
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+
+	calls := 0
+	cl := &Client{Username: "user", BaseURL: srv.URL + "/stratum/v1", Password: "initial",
+		PasswordProvider: SecretFunc(func() (string, error) {
+			calls++
+			return "rotated", nil
+		})}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if cl.Password != "" {
+		t.Error("password kept after login")
+	}
+
+	cl.revokeToken("Bearer token")
+	if err := cl.Get("host/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(logins) != 2 || logins[0] != "initial" || logins[1] != "rotated" || calls != 1 {
+		t.Errorf("logins: got %v, %d provider calls", logins, calls)
+	}
+}
@@ -0,0 +1,232 @@
+package stratumclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SelfTestCheck holds the outcome of a single check of SelfTest.
+// Skipped checks weren't run, since an earlier check failed or the
+// check doesn't apply to the client configuration.
+type SelfTestCheck struct {
+	Name     string
+	Detail   string
+	Duration time.Duration
+	Err      error
+	Skipped  bool
+}
+
+// SelfTestReport holds the checks run by SelfTest, in order.
+type SelfTestReport struct {
+	BaseURL string
+	Checks  []*SelfTestCheck
+}
+
+// OK reports whether none of the checks failed.
+func (r *SelfTestReport) OK() bool {
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Stringer function for SelfTestReport fmt.String() compliant.
+func (r *SelfTestReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "self-test of %s\n", r.BaseURL)
+	for _, check := range r.Checks {
+		switch {
+		case check.Skipped:
+			fmt.Fprintf(&b, "skip  %-8s %s\n", check.Name, check.Detail)
+		case check.Err != nil:
+			fmt.Fprintf(&b, "FAIL  %-8s %s: %v\n", check.Name, check.Duration.Round(time.Millisecond), check.Err)
+		default:
+			fmt.Fprintf(&b, "ok    %-8s %s: %s\n", check.Name, check.Duration.Round(time.Millisecond), check.Detail)
+		}
+	}
+
+	return b.String()
+}
+
+// SelfTest diagnoses the connection to the API step by step: name
+// resolution of the server, a TCP connection, the TLS handshake and
+// certificate chain for https, a login, a token refresh and, if query
+// isn't "", a GET call with query. Once a check fails the remaining
+// checks are skipped, so the report points at the first problem. The
+// client may be opened or not, and is left as is: the checks run on
+// a clone which logs in without the TokenStore. The function returns
+// the report.
+func (c *Client) SelfTest(query string) *SelfTestReport {
+	r := &SelfTestReport{BaseURL: c.BaseURL}
+	failed := false
+	run := func(name string, fn func() (string, error)) {
+		check := &SelfTestCheck{Name: name}
+		r.Checks = append(r.Checks, check)
+		if failed {
+			check.Skipped = true
+			check.Detail = "an earlier check failed"
+			return
+		}
+		start := time.Now()
+		check.Detail, check.Err = fn()
+		check.Duration = time.Since(start)
+		failed = check.Err != nil
+	}
+	skip := func(name, reason string) {
+		r.Checks = append(r.Checks, &SelfTestCheck{Name: name, Detail: reason, Skipped: true})
+	}
+
+	timeout := time.Duration(c.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	var u *url.URL
+	run("config", func() (string, error) {
+		if err := c.Validate(); err != nil {
+			return "", err
+		}
+		var err error
+		if u, err = url.Parse(c.BaseURL); err != nil {
+			return "", err
+		}
+		return "valid", nil
+	})
+
+	var host, port string
+	run("dns", func() (string, error) {
+		host, port = u.Hostname(), u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+		if net.ParseIP(host) != nil {
+			return host + " is an IP address", nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		return host + " resolves to " + strings.Join(addrs, ", "), nil
+	})
+
+	addr := net.JoinHostPort(host, port)
+	run("tcp", func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		conn, err := c.dialer()(ctx, "tcp", addr)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		return "connected to " + conn.RemoteAddr().String(), nil
+	})
+
+	if u == nil || u.Scheme == "https" {
+		run("tls", func() (string, error) {
+			return c.checkTLS(addr, host, timeout)
+		})
+	} else {
+		skip("tls", "BaseURL isn't https")
+	}
+
+	n := c.Clone()
+	n.TokenStore = nil
+	run("login", func() (string, error) {
+		if !n.opened {
+			if err := n.Open(); err != nil {
+				return "", err
+			}
+		} else if n.Token == "" {
+			n.mu.Lock()
+			err := n.login()
+			n.mu.Unlock()
+			if err != nil {
+				return "", err
+			}
+		}
+		if n.Token != "" {
+			return "using the configured Token, valid until " + n.TokenValidUntil().Format(time.RFC3339), nil
+		}
+		return fmt.Sprintf("logged in as %s, token valid until %s", n.authMethod().Name(), n.TokenValidUntil().Format(time.RFC3339)), nil
+	})
+
+	if n.Token == "" {
+		run("refresh", func() (string, error) {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			prev := n.token
+			n.token = ""
+			n.validUntil = time.Time{}
+			if err := n.login(); err != nil {
+				return "", err
+			}
+			if n.token == prev {
+				return "got the same token again, valid until " + n.validUntil.Format(time.RFC3339), nil
+			}
+			return "got a new token, valid until " + n.validUntil.Format(time.RFC3339), nil
+		})
+	} else {
+		skip("refresh", "a configured Token can't be refreshed")
+	}
+
+	if query != "" {
+		run("query", func() (string, error) {
+			body, err := n.Call("GET", query, nil, WithRetryPolicy(&RetryPolicy{MaxAttempts: 1}))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("GET %s returned %d bytes", query, len(body)), nil
+		})
+	} else {
+		skip("query", "no query given")
+	}
+
+	return r
+}
+
+// checkTLS performs a TLS handshake with the server at addr, verifying
+// its certificate chain like API calls do, and describes the
+// certificate.
+func (c *Client) checkTLS(addr, host string, timeout time.Duration) (string, error) {
+	cfg, err := c.tlsConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	raw, err := c.dialer()(ctx, "tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	conn := tls.Client(raw, cfg)
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return "", err
+	}
+
+	state := conn.ConnectionState()
+	cert := state.PeerCertificates[0]
+	detail := fmt.Sprintf("%s, certificate %s issued by %s, valid until %s",
+		tls.VersionName(state.Version), cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.Format(time.RFC3339))
+	if left := time.Until(cert.NotAfter); left < 14*24*time.Hour {
+		detail += fmt.Sprintf(" (expires in %s)", left.Round(time.Hour))
+	}
+
+	return detail, nil
+}
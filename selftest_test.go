@@ -0,0 +1,71 @@
+package stratumclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	logins := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			if _, password, _ := r.BasicAuth(); password != "secret" {
+				writeJSON(w, http.StatusUnauthorized, &ErrorResponse{Message: "bad credentials"})
+				return
+			}
+			logins++
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: fmt.Sprintf("token-%d", logins), ExpiresIn: 3600})
+			return
+		}
+		writeJSON(w, http.StatusOK, []testPlatform{{ID: 1}})
+	}))
+	t.Cleanup(srv.Close)
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", TLSConfig: &tls.Config{RootCAs: pool}}
+	report := cl.SelfTest("platform/?limit=1")
+	if !report.OK() {
+		t.Fatalf("report:\n%s", report)
+	}
+	var names []string
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+		if check.Skipped {
+			t.Errorf("%s skipped: %s", check.Name, check.Detail)
+		}
+	}
+	if got := strings.Join(names, ","); got != "config,dns,tcp,tls,login,refresh,query" {
+		t.Errorf("checks: got %s", got)
+	}
+	if logins != 2 || cl.opened {
+		t.Errorf("got %d logins, client opened %v", logins, cl.opened)
+	}
+
+	cl.Password = "wrong"
+	report = cl.SelfTest("")
+	if report.OK() {
+		t.Fatalf("bad password: report:\n%s", report)
+	}
+	for _, check := range report.Checks {
+		failed := check.Name == "login"
+		if failed != (check.Err != nil) || check.Skipped != (check.Name == "refresh" || check.Name == "query") {
+			t.Errorf("bad password: %s: skipped %v, error %v", check.Name, check.Skipped, check.Err)
+		}
+	}
+	if !strings.Contains(report.String(), "FAIL  login") {
+		t.Errorf("report:\n%s", report)
+	}
+
+	cl.Password = "secret"
+	cl.TLSConfig = nil
+	report = cl.SelfTest("")
+	if report.OK() || report.Checks[3].Name != "tls" || report.Checks[3].Err == nil {
+		t.Errorf("untrusted certificate: report:\n%s", report)
+	}
+}
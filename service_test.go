@@ -0,0 +1,72 @@
+package stratumclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTableService(t *testing.T) {
+	var got []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.RequestURI())
+		if r.URL.Path == "//stratum/v1/slow/" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		writeJSON(w, http.StatusOK, []*testPlatform{{ID: 1, Name: "Linux"}})
+	})
+
+	var svc Service[testPlatform] = NewTable[testPlatform](cl, "platform")
+	ctx := context.Background()
+
+	if rows, err := svc.List(ctx, "select=id,name"); err != nil || len(rows) != 1 {
+		t.Fatalf("list: got %v %v", rows, err)
+	}
+	if _, err := svc.Create(ctx, map[string]string{"name": "Linux"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.Update(ctx, "id=1", map[string]string{"name": "BSD"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := svc.Delete(ctx, ""); err == nil {
+		t.Fatalf("delete without where: expected error")
+	}
+
+	want := []string{
+		"GET //stratum/v1/platform/?select=id,name",
+		"POST //stratum/v1/platform/?returning=*",
+		"PUT //stratum/v1/platform/?returning=*&where=id%3D1",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("requests: got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := NewTable[testPlatform](cl, "slow").List(ctx, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("list with deadline: got %v", err)
+	}
+}
+
+func TestMockService(t *testing.T) {
+	var svc Service[testPlatform] = &MockService[testPlatform]{
+		ListFunc: func(ctx context.Context, params string) ([]*testPlatform, error) {
+			return []*testPlatform{{ID: 7}}, nil
+		},
+	}
+
+	rows, err := svc.List(context.Background(), "")
+	if err != nil || len(rows) != 1 || rows[0].ID != 7 {
+		t.Fatalf("list: got %v %v", rows, err)
+	}
+	if rows, err := svc.Delete(context.Background(), "id=1"); rows != nil || err != nil {
+		t.Fatalf("delete: got %v %v", rows, err)
+	}
+}
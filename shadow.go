@@ -0,0 +1,91 @@
+package stratumclient
+
+import (
+	"fmt"
+	"io"
+)
+
+// Shadow mirrors the mutations of a client to a secondary server,
+// like a staging copy refreshed from production, for rehearsing risky
+// bulk changes. Client is the opened client of the secondary server.
+//
+// In mirror mode, the default, mutations are sent to the primary
+// server as usual, and then to the shadow server if they succeeded.
+// Errors of the shadow server don't fail the call. With Rehearse set,
+// mutations are sent to the shadow server only, and its response is
+// returned, so a job can be run unchanged against the staging copy
+// while its reads still come from the primary server. Setting DryRun
+// on the shadow client records the mutations in a plan instead.
+//
+// Report is called with the outcome of each mutation sent to the
+// shadow server. If Report is nil, failures are logged as warnings
+// with the Logger of the primary client.
+type Shadow struct {
+	Client   *Client
+	Rehearse bool
+	Report   func(r *ShadowResult)
+}
+
+// ShadowResult holds the outcome of a mutation sent to a shadow
+// server. Err is nil if the shadow server accepted it.
+type ShadowResult struct {
+	Method string
+	Query  string
+	Err    error
+}
+
+// shadowed reports whether a call is a mutation to send to the shadow
+// server.
+func (c *Client) shadowed(method, query string, o *callOptions) bool {
+	if c.Shadow == nil || c.Shadow.Client == nil || o.unshadowed || query == "login/v1" {
+		return false
+	}
+
+	return method == "POST" || method == "PUT" || method == "PATCH" || method == "DELETE"
+}
+
+// shadowCall performs a mutation in the mode of the client Shadow.
+func (c *Client) shadowCall(method, query string, data interface{}, opts []CallOption) ([]byte, error) {
+	s := c.Shadow
+	if s.Rehearse {
+		body, err := s.Client.Call(method, query, data, opts...)
+		c.reportShadow(&ShadowResult{Method: method, Query: query, Err: err})
+		return body, err
+	}
+
+	// streamed post data can only be sent once
+	var mirrorErr error
+	switch data.(type) {
+	case io.Reader, *Upload:
+		mirrorErr = fmt.Errorf("shadow: streamed post data can't be mirrored")
+	}
+
+	body, err := c.Call(method, query, data, append(opts, func(o *callOptions) {
+		o.unshadowed = true
+	})...)
+	if err != nil {
+		return body, err
+	}
+
+	if mirrorErr == nil {
+		// only the context applies, the other options concern the
+		// primary call
+		ctx := newCallOptions(opts).context()
+		_, mirrorErr = s.Client.Call(method, query, data, WithContext(ctx))
+	}
+	c.reportShadow(&ShadowResult{Method: method, Query: query, Err: mirrorErr})
+
+	return body, nil
+}
+
+// reportShadow hands the outcome of a shadow mutation to Report, or
+// logs it if it failed.
+func (c *Client) reportShadow(r *ShadowResult) {
+	if c.Shadow.Report != nil {
+		c.Shadow.Report(r)
+		return
+	}
+	if r.Err != nil && c.Logger != nil {
+		c.Logger.Warn("stratum shadow mutation failed", "method", r.Method, "query", r.Query, "error", r.Err)
+	}
+}
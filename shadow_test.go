@@ -0,0 +1,52 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestShadow(t *testing.T) {
+	var primary, staging []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		primary = append(primary, r.Method)
+		writeJSON(w, http.StatusOK, []map[string]string{{"server": "primary"}})
+	})
+	shadow, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		staging = append(staging, r.Method)
+		if r.Method == "DELETE" {
+			writeJSON(w, http.StatusConflict, &ErrorResponse{Message: "referenced"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []map[string]string{{"server": "staging"}})
+	})
+
+	var results []*ShadowResult
+	cl.Shadow = &Shadow{Client: shadow, Report: func(r *ShadowResult) { results = append(results, r) }}
+
+	var rows []map[string]string
+	if err := cl.Post("host/", map[string]string{"name": "db1"}, &rows); err != nil || rows[0]["server"] != "primary" {
+		t.Fatalf("mirrored post: got %v, %v", rows, err)
+	}
+	if err := cl.Delete("host/?where=id=1", nil, nil); err != nil {
+		t.Fatalf("mirrored delete: %v", err)
+	}
+	if err := cl.Get("host/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if strings.Join(primary, " ") != "POST DELETE GET" || strings.Join(staging, " ") != "POST DELETE" {
+		t.Errorf("mirror: got primary %v, staging %v", primary, staging)
+	}
+	if len(results) != 2 || results[0].Err != nil || !IsConflict(results[1].Err) {
+		t.Errorf("results: got %+v", results)
+	}
+
+	primary, staging = nil, nil
+	cl.Shadow.Rehearse = true
+	if err := cl.Put("host/?where=id=1", map[string]string{"name": "db2"}, &rows); err != nil || rows[0]["server"] != "staging" {
+		t.Fatalf("rehearsed put: got %v, %v", rows, err)
+	}
+	if len(primary) != 0 || len(staging) != 1 {
+		t.Errorf("rehearse: got primary %v, staging %v", primary, staging)
+	}
+}
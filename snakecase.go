@@ -0,0 +1,254 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// snakeName returns the snake_case column name of a Go field name,
+// like guest_os for GuestOS and host_id for HostID.
+func snakeName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// squash folds a column or field name for matching snake_case to
+// CamelCase: lower case without underscores.
+func squash(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+}
+
+// snakeField is a field of a struct as seen by encoding/json. Name is
+// the JSON name, and Tagged tells whether it is given by a json tag.
+type snakeField struct {
+	Name   string
+	Tagged bool
+	Type   reflect.Type
+}
+
+// snakeFields returns the fields of struct type t, including the
+// fields of embedded structs.
+func snakeFields(t reflect.Type) []snakeField {
+	var fields []snakeField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, snakeFields(ft)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			fields = append(fields, snakeField{Name: f.Name, Type: f.Type})
+			continue
+		}
+		fields = append(fields, snakeField{Name: name, Tagged: true, Type: f.Type})
+	}
+
+	return fields
+}
+
+// customJSON reports whether values of type t encode or decode
+// themselves, like time.Time, and so are left alone.
+func customJSON(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	for _, it := range []reflect.Type{jsonMarshalerType, jsonUnmarshalerType, textMarshalerType, textUnmarshalerType} {
+		if t.Implements(it) || p.Implements(it) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// snakeDecode returns the JSON text data with the snake_case keys of
+// objects to be decoded into struct fields without json tags renamed
+// to the field names, following the type of v.
+func snakeDecode(data []byte, v interface{}) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return data, nil
+	}
+
+	return json.Marshal(snakeToFields(tree, reflect.TypeOf(v)))
+}
+
+// snakeToFields renames the keys of the decoded JSON value v for
+// decoding into type t, see snakeDecode.
+func snakeToFields(v interface{}, t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || customJSON(t) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		fields := snakeFields(t)
+		out := make(map[string]interface{}, len(obj))
+		for key, val := range obj {
+			name, ft := key, reflect.Type(nil)
+			for _, f := range fields {
+				if f.Name == key || !f.Tagged && squash(f.Name) == squash(key) {
+					name, ft = f.Name, f.Type
+					break
+				}
+			}
+			out[name] = snakeToFields(val, ft)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if list, ok := v.([]interface{}); ok {
+			for i := range list {
+				list[i] = snakeToFields(list[i], t.Elem())
+			}
+		}
+	case reflect.Map:
+		if obj, ok := v.(map[string]interface{}); ok {
+			for key, val := range obj {
+				obj[key] = snakeToFields(val, t.Elem())
+			}
+		}
+	}
+
+	return v
+}
+
+// snakeEncode returns v encoded as JSON with the names of struct
+// fields without json tags in snake_case.
+func snakeEncode(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fieldsToSnake(tree, reflect.ValueOf(v)))
+}
+
+// fieldsToSnake renames the keys of the JSON value j encoded from rv,
+// see snakeEncode.
+func fieldsToSnake(j interface{}, rv reflect.Value) interface{} {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return j
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || customJSON(rv.Type()) {
+		return j
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		obj, ok := j.(map[string]interface{})
+		if !ok {
+			return j
+		}
+		fieldsToSnakeStruct(obj, rv)
+	case reflect.Slice, reflect.Array:
+		if list, ok := j.([]interface{}); ok && len(list) == rv.Len() {
+			for i := range list {
+				list[i] = fieldsToSnake(list[i], rv.Index(i))
+			}
+		}
+	case reflect.Map:
+		obj, ok := j.(map[string]interface{})
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			return j
+		}
+		for key, val := range obj {
+			obj[key] = fieldsToSnake(val, rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())))
+		}
+	}
+
+	return j
+}
+
+// fieldsToSnakeStruct renames the keys of obj encoded from the struct
+// value rv in place, including the fields of embedded structs.
+func fieldsToSnakeStruct(obj map[string]interface{}, rv reflect.Value) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+		if f.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fieldsToSnakeStruct(obj, fv)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = f.Name
+		}
+		val, ok := obj[name]
+		if !ok {
+			continue
+		}
+		if !tagged {
+			delete(obj, name)
+			name = snakeName(name)
+		}
+		obj[name] = fieldsToSnake(val, fv)
+	}
+}
@@ -37,28 +37,256 @@ package stratumclient
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Client holds client config and token data.
 type Client struct {
-	Username   string    `yaml:"username" json:"username"`
-	Password   string    `yaml:"password" json:"password"`
-	BaseURL    string    `yaml:"baseURL" json:"base_url"`
-	UserAgent  string    `yaml:"userAgent" json:"user_agent"`
-	Timeout    int       `yaml:"timeout" json:"timeout"`
-	prefix     string    `yaml:"-" json:"-"`
-	url        *url.URL  `yaml:"-" json:"-"`
-	token      string    `yaml:"-" json:"-"`
-	validUntil time.Time `yaml:"-" json:"-"`
-	opened     bool      `yaml:"-" json:"-"`
+	Username  string       `yaml:"username" json:"username"`
+	Password  string       `yaml:"password" json:"password"`
+	BaseURL   string       `yaml:"baseURL" json:"base_url"`
+	UserAgent string       `yaml:"userAgent" json:"user_agent"`
+	Timeout   int          `yaml:"timeout" json:"timeout"`
+	Retry     *RetryPolicy `yaml:"retry" json:"retry"`
+	// DefaultHeaders are request headers sent with every call,
+	// like a tenant ID, feature flag or tracing header. Headers
+	// given with WithHeader replace them for a call. Headers set by
+	// the client, like Authorization, can't be overridden.
+	DefaultHeaders map[string]string `yaml:"defaultHeaders" json:"default_headers"`
+	// LightProfile and HeavyProfile override the timeout and retry
+	// policy for calls annotated with WithWeight.
+	LightProfile *Profile `yaml:"lightProfile" json:"light_profile"`
+	HeavyProfile *Profile `yaml:"heavyProfile" json:"heavy_profile"`
+	// CACertFile is a PEM file with CA certificates to trust in
+	// addition to the system pool.
+	CACertFile string `yaml:"caCertFile" json:"ca_cert_file"`
+	// ClientCertFile and ClientKeyFile are PEM files with the
+	// client certificate and key used for mutual TLS.
+	ClientCertFile string `yaml:"clientCertFile" json:"client_cert_file"`
+	ClientKeyFile  string `yaml:"clientKeyFile" json:"client_key_file"`
+	// TLSConfig overrides the TLS configuration built from the
+	// file fields above.
+	TLSConfig *tls.Config `yaml:"-" json:"-"`
+	// OnDeprecation is called once per endpoint when the server
+	// flags the endpoint with Warning, Deprecation or Sunset
+	// headers. The report is logged as a warning with Logger if
+	// OnDeprecation is nil, and dropped if both are nil.
+	OnDeprecation func(*Deprecation) `yaml:"-" json:"-"`
+	// Logger receives debug logs of requests, retries and token
+	// refreshes. Credentials are redacted. Nothing is logged if
+	// Logger is nil.
+	Logger *slog.Logger `yaml:"-" json:"-"`
+	// RedactFields names post data fields, like "ssn", whose values
+	// are masked in the logged request bodies, see RedactPayload.
+	RedactFields []string `yaml:"redactFields" json:"redact_fields"`
+	// TokenStore persists tokens between client instances, so
+	// short-lived processes can reuse a valid token instead of
+	// logging in on every run.
+	TokenStore TokenStore `yaml:"-" json:"-"`
+	// Token is a pre-issued bearer token or API key. When set, Open
+	// skips the login and all API calls are authorized with the
+	// token, for service accounts which aren't allowed Basic
+	// authentication.
+	Token string `yaml:"token" json:"token"`
+	// RateLimit limits the client to the given number of requests
+	// per second, allowing bursts of RateBurst requests. Zero means
+	// no limit. When the server responds 429 Too Many Requests,
+	// all calls pause for the time given by Retry-After.
+	RateLimit float64 `yaml:"rateLimit" json:"rate_limit"`
+	RateBurst int     `yaml:"rateBurst" json:"rate_burst"`
+	// DisableCompression stops the client from asking the server
+	// for gzip compressed responses. CompressThreshold is the size in
+	// bytes from which post data is sent gzip compressed, for servers
+	// taking compressed requests. Zero, the default, sends post data
+	// uncompressed. A server rejecting the compressed body with 415
+	// Unsupported Media Type gets it uncompressed.
+	DisableCompression bool `yaml:"disableCompression" json:"disable_compression"`
+	CompressThreshold  int  `yaml:"compressThreshold" json:"compress_threshold"`
+	// Filters are where clauses added to every GET, PUT and DELETE
+	// query, like "environment=prod", so a shared account can only
+	// read and change the rows it is scoped to.
+	Filters []string `yaml:"filters" json:"filters"`
+	// QueryPolicy is called with the parsed query of every GET,
+	// PUT and DELETE call after Filters are added. It may modify
+	// the query, or reject the call by returning an error.
+	QueryPolicy func(method string, q *Query) error `yaml:"-" json:"-"`
+	// LogoutQuery is the query of a logout endpoint which Close
+	// calls with POST to revoke the token, like "logout/v1". The
+	// API has no such endpoint yet, so it is empty by default.
+	LogoutQuery string `yaml:"logoutQuery" json:"logout_query"`
+	// TxQuery is the query of a transactional batch endpoint which
+	// applies the calls of a Tx atomically, see Begin. The API has no
+	// such endpoint yet, so it is empty by default.
+	TxQuery string `yaml:"txQuery" json:"tx_query"`
+	// Cache stores the responses of GET calls made with Get,
+	// Unmarshal and Call, keyed by the normalized query, so
+	// semantically equal queries share an entry. Calls capturing
+	// headers, status or metadata bypass the cache. Successful PUT, POST and
+	// DELETE calls purge the entries of their table. Stale entries
+	// are revalidated with the ETag or Last-Modified header of the
+	// cached response, in the background if the cache allows it, see
+	// MemoryCache.StaleWhileRevalidate.
+	Cache Cache `yaml:"-" json:"-"`
+	// SchemaURL is the URL of the OpenAPI document the schema is
+	// loaded from. Default is openapi.json in the docs directory
+	// next to the API path. PreloadSchema makes Warmup load it.
+	SchemaURL     string `yaml:"schemaURL" json:"schema_url"`
+	PreloadSchema bool   `yaml:"preloadSchema" json:"preload_schema"`
+	// Metrics receives the count and latency of requests and token
+	// refreshes. Nothing is collected if Metrics is nil.
+	Metrics MetricsCollector `yaml:"-" json:"-"`
+	// HistoryTable is the name of the audit table holding the change
+	// history of rows, used by History, with %s replaced by the name
+	// of the table. Default "%s_history".
+	HistoryTable string `yaml:"historyTable" json:"history_table"`
+	// ReplayPost makes POST calls rejected with 401 Unauthorized be
+	// replayed after logging in again. GET, PUT and DELETE calls
+	// are always replayed once, when the client has credentials.
+	// POST is not idempotent, so the server must not have acted on
+	// the rejected request.
+	ReplayPost bool `yaml:"replayPost" json:"replay_post"`
+	// IdempotencyKeys makes POST calls send a random
+	// Idempotency-Key header, shared by all attempts of the call,
+	// so the server can drop repeated creates, and POST calls are
+	// then retried and replayed like PUT calls. The server must
+	// support idempotency keys. See also WithIdempotencyKey.
+	IdempotencyKeys bool `yaml:"idempotencyKeys" json:"idempotency_keys"`
+	// SuccessStatus lists the response status codes which are
+	// decoded as results rather than returned as errors. Default
+	// 200, 201 and 204. WithSuccessStatus overrides it per call, and
+	// CaptureStatus tells which status a call got.
+	SuccessStatus []int `yaml:"successStatus" json:"success_status"`
+	// MaxErrorBody limits how many bytes of an error response body
+	// are read, so a proxy error page of megabytes doesn't end up in
+	// memory or logs. Larger JSON error bodies give an error telling
+	// the limit was exceeded. Default 64 KiB.
+	MaxErrorBody int `yaml:"maxErrorBody" json:"max_error_body"`
+	// DryRun makes POST, PUT and DELETE calls be added to the plan
+	// instead of being sent, so bulk changes can be previewed, and
+	// applied later with ApplyPlan. The calls return an empty
+	// result. WithDryRun sets a plan per call.
+	DryRun *Plan `yaml:"-" json:"-"`
+	// TLSSessionCacheSize is the number of TLS sessions cached for
+	// resumption, sparing short-lived jobs full handshakes when
+	// connections are reopened. Default 64, and a negative size
+	// disables resumption. A ClientSessionCache set in TLSConfig is
+	// used as is. Stats tells how many handshakes were resumed.
+	TLSSessionCacheSize int `yaml:"tlsSessionCacheSize" json:"tls_session_cache_size"`
+	// OnConnEvent is called with the connection events of requests:
+	// DNS lookups, connects, TLS handshakes and reused connections.
+	// The events are also passed to Metrics if it implements
+	// ConnObserver, and logged to Logger at debug level. It must
+	// be safe for concurrent use.
+	OnConnEvent func(e *ConnEvent) `yaml:"-" json:"-"`
+	// FallbackDelay is how long a connection attempt over IPv6
+	// gets before IPv4 is tried in parallel, for servers with both
+	// address families. Default 300ms, and a negative delay
+	// disables the fallback. DisableIPv6 makes the client only
+	// connect over IPv4, for deployments with broken AAAA records.
+	FallbackDelay time.Duration `yaml:"fallbackDelay" json:"fallback_delay"`
+	DisableIPv6   bool          `yaml:"disableIPv6" json:"disable_ipv6"`
+	// Breaker makes calls fail fast with ErrCircuitOpen while the
+	// server keeps failing. There is no breaker by default.
+	Breaker *CircuitBreaker `yaml:"breaker" json:"breaker"`
+	// Transport tuning for clients making many concurrent calls.
+	// MaxIdleConnsPerHost is the number of idle connections kept
+	// for reuse, default 16. IdleConnTimeout is how long they are
+	// kept, default 90s. DialTimeout and TLSHandshakeTimeout bound
+	// connection setup, default 30s and 10s. DisableHTTP2 makes the
+	// client use HTTP/1.1 only.
+	MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost" json:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `yaml:"idleConnTimeout" json:"idle_conn_timeout"`
+	DialTimeout         time.Duration `yaml:"dialTimeout" json:"dial_timeout"`
+	TLSHandshakeTimeout time.Duration `yaml:"tlsHandshakeTimeout" json:"tls_handshake_timeout"`
+	DisableHTTP2        bool          `yaml:"disableHTTP2" json:"disable_http2"`
+	// Codec encodes post data and decodes response bodies.
+	// Default encoding/json, see JSONCodec.
+	Codec Codec `yaml:"-" json:"-"`
+	// StrictDecode makes calls decoding rows into structs return a
+	// *DecodeMismatchError if the rows have columns the struct lacks,
+	// or lack fields of the struct without omitempty, to catch drift
+	// between client structs and the API schema.
+	StrictDecode bool `yaml:"strictDecode" json:"strict_decode"`
+	// VerifyOrder makes calls decoding rows check that the rows
+	// are sorted by the orderby of the query, catching queries the
+	// server silently doesn't sort, like when paging. With
+	// VerifyOrderError calls return an *UnorderedError, and with
+	// VerifyOrderWarn it is logged as a warning with Logger.
+	// Default "", no check. See also WithVerifyOrder.
+	VerifyOrder string `yaml:"verifyOrder" json:"verify_order"`
+	// MaxURLLength is the longest request URL sent, default 8000
+	// bytes, so proxies don't reject long queries with 414 URI Too
+	// Long. GET calls with longer URLs are split in several calls
+	// when the where clause is a list of values of one column, like
+	// from Column.In, and fail with *URLTooLongError otherwise. A
+	// negative length disables the check.
+	MaxURLLength int `yaml:"maxURLLength" json:"max_url_length"`
+	// ValidatePosts makes POST and PUT calls check their post data
+	// with ValidatePayload before it is sent.
+	ValidatePosts bool `yaml:"validatePosts" json:"validate_posts"`
+	// Auth authenticates the login request, for deployments using
+	// single sign-on, see AuthMethod. OIDC configures login with
+	// the OpenID Connect client credentials flow and is used when
+	// Auth is nil. Username and Password are used when both are nil.
+	Auth AuthMethod             `yaml:"-" json:"-"`
+	OIDC *OIDCClientCredentials `yaml:"oidc" json:"oidc"`
+	// PasswordProvider gives the login password when it is needed,
+	// see SecretProvider, and PasswordFile names a file holding it.
+	// With either set, Password may be left empty, and is cleared
+	// after the first login so the client doesn't keep it. Go
+	// strings can't be overwritten, so clearing drops the client's
+	// reference rather than zeroing the memory.
+	PasswordProvider SecretProvider `yaml:"-" json:"-"`
+	PasswordFile     string         `yaml:"passwordFile" json:"password_file"`
+	// Shadow mirrors mutations to a secondary server, or sends
+	// them there only, for rehearsing changes, see Shadow.
+	Shadow *Shadow `yaml:"-" json:"-"`
+	// RefreshWindow is how long before its expiry the token is
+	// refreshed, so long-running jobs don't stall on an expired
+	// token. Default 30s, and a negative window refreshes the token
+	// only once it has expired. TokenValidUntil tells the expiry.
+	RefreshWindow time.Duration `yaml:"refreshWindow" json:"refresh_window"`
+	// Clock tells the time for token expiry, retry backoff and the
+	// age of cache entries, default the system clock. Give the Clock
+	// of a MemoryCache Cache the same clock.
+	Clock Clock `yaml:"-" json:"-"`
+	// Tags label every call of the client, like the name of the job
+	// using it, see Tag. TagParam is the query parameter the tags are
+	// sent in too, like "comment", for servers logging the request
+	// line but not headers.
+	Tags     []string `yaml:"tags" json:"tags"`
+	TagParam string   `yaml:"tagParam" json:"tag_param"`
+
+	deprecations *deprecationLog `yaml:"-" json:"-"`
+	mu           *sync.Mutex     `yaml:"-" json:"-"`
+	stats        *statsCounter   `yaml:"-" json:"-"`
+	limiter      *limiter        `yaml:"-" json:"-"`
+	limiterMu    *sync.Mutex     `yaml:"-" json:"-"`
+	filters      Expr            `yaml:"-" json:"-"`
+	schema       *schemaCache    `yaml:"-" json:"-"`
+	client       *http.Client    `yaml:"-" json:"-"`
+	prefix       string          `yaml:"-" json:"-"`
+	version      string          `yaml:"-" json:"-"`
+	url          *url.URL        `yaml:"-" json:"-"`
+	token        string          `yaml:"-" json:"-"`
+	revoked      string          `yaml:"-" json:"-"`
+	validUntil   time.Time       `yaml:"-" json:"-"`
+	noPreRefresh time.Time       `yaml:"-" json:"-"`
+	opened       bool            `yaml:"-" json:"-"`
+	closed       bool            `yaml:"-" json:"-"`
+	refreshing   *sync.Map       `yaml:"-" json:"-"`
 }
 
 // LoginResponse holds the response from a successful login
@@ -73,12 +301,20 @@ func (l *LoginResponse) String() string {
 	return fmt.Sprintf("%s %d %s", l.AccessToken, l.ExpiresIn, l.TokenType)
 }
 
-// ErrorResponse holds connection and/or API errors.
+// ErrorResponse holds connection and/or API errors. RequestID is the
+// correlation ID of the failed request, for finding it in the server
+// logs. Method and URL tell the request, with credentials in the URL
+// redacted. Body holds the raw response body, up to MaxErrorBody
+// bytes, when it isn't a JSON error, like the error page of a proxy.
 type ErrorResponse struct {
 	Backend    *BackendError `json:"backend,omitempty"`
 	Message    string        `json:"error,omitempty"`
 	Status     string
 	StatusCode int
+	RequestID  string `json:"-"`
+	Method     string `json:"-"`
+	URL        string `json:"-"`
+	Body       []byte `json:"-"`
 }
 
 // BackendError holds errors from the API backend (PostgreSQL). In
@@ -125,23 +361,57 @@ func (e *ErrorResponse) Error() string {
 
 // Open makes sure all the necessary configuration fields are set,
 // sets default values for missing fields, and logs on to the API
-// using Basic authentication. Any further API calls will use the JWT
-// token for authorization. The library will transparently refresh the
-// JWT token when necessary.
+// using Basic authentication, or the AuthMethod given by Auth or
+// OIDC. Any further API calls will use the JWT token for
+// authorization. The library will transparently refresh the JWT
+// token when necessary. If Token is set, the login is skipped and
+// the token is used for all API calls, and Username and Password are
+// not required.
 func (c *Client) Open() error {
-	if c.Username == "" {
-		return fmt.Errorf("missing: Username")
-	}
-	if c.Password == "" {
-		return fmt.Errorf("missing: Password")
-	}
-	if c.BaseURL == "" {
-		return fmt.Errorf("missing: BaseURL")
+	if err := c.Validate(); err != nil {
+		return err
 	}
 	if c.Timeout == 0 {
 		c.Timeout = 30
 	}
 
+	if err := c.parseBaseURL(); err != nil {
+		return err
+	}
+
+	client, err := c.newHTTPClient()
+	if err != nil {
+		return err
+	}
+	c.client = client
+	c.deprecations = &deprecationLog{seen: make(map[string]bool)}
+	c.mu = &sync.Mutex{}
+	c.stats = &statsCounter{}
+	c.limiter = newLimiter(c.RateLimit, c.RateBurst)
+	c.limiterMu = &sync.Mutex{}
+	c.refreshing = &sync.Map{}
+	if c.schema == nil {
+		c.schema = &schemaCache{}
+	}
+	if err := c.parseFilters(); err != nil {
+		return err
+	}
+
+	if c.Token != "" {
+		c.token = c.Token
+		c.validUntil = tokenExpiry(c.Token)
+	} else if err := c.login(); err != nil {
+		return err
+	}
+
+	c.opened = true
+
+	return nil
+}
+
+// parseBaseURL splits the BaseURL into the server URL and the API
+// path prefix.
+func (c *Client) parseBaseURL() error {
 	u, err := url.Parse(c.BaseURL)
 	if err != nil {
 		return err
@@ -153,12 +423,6 @@ func (c *Client) Open() error {
 	c.prefix = c.url.Path
 	c.url.Path = ""
 
-	if err := c.login(); err != nil {
-		return err
-	}
-
-	c.opened = true
-
 	return nil
 }
 
@@ -166,10 +430,10 @@ func (c *Client) Open() error {
 // and a response parameter. If the response parameter is nil, no data
 // will be returned. Otherwise the response parameter should be a
 // pointer to a slice of struct pointers which the response will be
-// unmarshalled into. The function returns an error upon errors
-// otherwise nil.
-func (c *Client) Get(query string, resp interface{}) error {
-	return c.Unmarshal("GET", query, nil, resp)
+// unmarshalled into. Call options may be given to adjust the
+// call. The function returns an error upon errors otherwise nil.
+func (c *Client) Get(query string, resp interface{}, opts ...CallOption) error {
+	return c.Unmarshal("GET", query, nil, resp, opts...)
 }
 
 // Delete will perform a DELETE API call to stratum. It takes a query
@@ -177,10 +441,11 @@ func (c *Client) Get(query string, resp interface{}) error {
 // be a map or JSON text when post data is provided, otherwise nil. If
 // the response parameter is nil, no data will be returned. Otherwise
 // the response parameter should be a pointer to a slice of struct
-// pointers which the response will be unmarshalled into. The
-// function returns an error upon errors otherwise nil.
-func (c *Client) Delete(query string, post, resp interface{}) error {
-	return c.Unmarshal("DELETE", query, post, resp)
+// pointers which the response will be unmarshalled into. Call
+// options may be given to adjust the call. The function returns an
+// error upon errors otherwise nil.
+func (c *Client) Delete(query string, post, resp interface{}, opts ...CallOption) error {
+	return c.Unmarshal("DELETE", query, post, resp, opts...)
 }
 
 // Put will perform a PUT API call to stratum. It takes a query
@@ -188,10 +453,11 @@ func (c *Client) Delete(query string, post, resp interface{}) error {
 // be a map or JSON text when post data is provided, otherwise nil. If
 // the response parameter is nil, no data will be returned. Otherwise
 // the response parameter should be a pointer to a slice of struct
-// pointers which the response will be unmarshalled into. The
-// function returns an error upon errors otherwise nil.
-func (c *Client) Put(query string, post, resp interface{}) error {
-	return c.Unmarshal("PUT", query, post, resp)
+// pointers which the response will be unmarshalled into. Call
+// options may be given to adjust the call. The function returns an
+// error upon errors otherwise nil.
+func (c *Client) Put(query string, post, resp interface{}, opts ...CallOption) error {
+	return c.Unmarshal("PUT", query, post, resp, opts...)
 }
 
 // Post will perform a POST API call to stratum. It takes a query
@@ -199,10 +465,11 @@ func (c *Client) Put(query string, post, resp interface{}) error {
 // be a map or JSON text when post data is provided, otherwise nil. If
 // the response parameter is nil, no data will be returned. Otherwise
 // the response parameter should be a pointer to a slice of struct
-// pointers which the response will be unmarshalled into. The
-// function returns an error upon errors otherwise nil.
-func (c *Client) Post(query string, post, resp interface{}) error {
-	return c.Unmarshal("POST", query, post, resp)
+// pointers which the response will be unmarshalled into. Call
+// options may be given to adjust the call. The function returns an
+// error upon errors otherwise nil.
+func (c *Client) Post(query string, post, resp interface{}, opts ...CallOption) error {
+	return c.Unmarshal("POST", query, post, resp, opts...)
 }
 
 // Unmarshal will perform an API call to stratum. It takes a method,
@@ -211,36 +478,133 @@ func (c *Client) Post(query string, post, resp interface{}) error {
 // nil. If the response parameter is nil, no data will be
 // returned. Otherwise the response parameter should be a pointer to a
 // slice of struct pointers which the response will be unmarshalled
-// into. The function returns an error upon errors otherwise nil.
-func (c *Client) Unmarshal(method, query string, data, resp interface{}) error {
-	content, err := c.Call(method, query, data)
+// into. Rows wrapped in an envelope like {"rows": [...]} are
+// unwrapped. An empty response body, like of 204 No Content, leaves
+// the response parameter unchanged; use CaptureStatus to tell the
+// status. The response is also decoded into the targets of any
+// DecodeInto option. Call options may be given to adjust the
+// call. The function returns an error upon errors otherwise nil.
+func (c *Client) Unmarshal(method, query string, data, resp interface{}, opts ...CallOption) error {
+	content, err := c.Call(method, query, data, opts...)
 	if err != nil {
 		return err
 	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil
+	}
 
+	o := newCallOptions(opts)
+	if err := c.checkOrder(query, content, o); err != nil {
+		return err
+	}
 	if resp != nil {
-		return json.Unmarshal(content, resp)
+		if err := c.decode(query, content, resp); err != nil {
+			return err
+		}
+	}
+	for _, target := range o.decodeTo {
+		if err := c.decode(query, content, target); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// decode decodes the response body content of query into resp. A
+// *json.RawMessage or *[]byte resp gets a copy of content.
+func (c *Client) decode(query string, content []byte, resp interface{}) error {
+	switch raw := resp.(type) {
+	case *json.RawMessage:
+		*raw = append(json.RawMessage(nil), content...)
+		return nil
+	case *[]byte:
+		*raw = append([]byte(nil), content...)
+		return nil
+	}
+
+	if wantsRows(resp) {
+		content, _ = unwrapEnvelope(content)
+	}
+	if err := c.codec().Unmarshal(content, resp); err != nil {
+		return err
+	}
+
+	return c.checkStrict(query, content, resp)
+}
+
 // Call will perform an API call to stratum. It takes a method, query
 // string, and post data. The post data should be a map or JSON text
-// when post data is provided, otherwise nil. The function returns the
-// response body and an error.
-func (c *Client) Call(method, query string, data interface{}) ([]byte, error) {
+// when post data is provided, otherwise nil. JSON text must hold an
+// object or an array and is validated before it is sent. Large
+// payloads can be streamed from an io.Reader, which is sent chunked
+// as JSON, or an Upload. Failed calls are retried
+// according to the client's Retry policy, and calls rejected because
+// the token was revoked are replayed once after logging in again,
+// see ReplayPost. GET calls with URLs longer than MaxURLLength are
+// split, see MaxURLLength. Call options may be given to adjust the
+// call. The function returns the response body and an error.
+func (c *Client) Call(method, query string, data interface{}, opts ...CallOption) ([]byte, error) {
+	o := newCallOptions(opts)
+	if c.shadowed(strings.ToUpper(method), query, o) {
+		return c.shadowCall(strings.ToUpper(method), query, data, opts)
+	}
+	if max := c.maxURLLength(); max > 0 && c.opened && strings.EqualFold(method, "GET") && c.urlLength(query, o) > max {
+		return c.splitCall(query, max, opts)
+	}
+	if c.Cache != nil && query != "login/v1" {
+		return c.cachedCall(strings.ToUpper(method), query, data, o)
+	}
+
+	resp, err := c.do(method, query, data, o)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// do performs an API call and returns the response with its body
+// unread. The caller must close the response body. Failed calls are
+// retried according to the client's Retry policy.
+func (c *Client) do(method, query string, data interface{}, o *callOptions) (*http.Response, error) {
 	method = strings.ToUpper(method)
+	if r, ok := data.(io.Reader); ok {
+		data = &Upload{Body: r}
+	}
+	if up, ok := data.(*Upload); ok {
+		defer up.close()
+	}
 
 	if data != nil && method == "GET" {
 		return nil, fmt.Errorf("post data not allowed with method %s", method)
 	}
 
-	prefix := c.prefix + "/"
+	o.requestID = requestID(o)
+	o.idempotencyKey = c.idempotencyKey(method, o)
+	tags, err := c.callTags(o)
+	if err != nil {
+		return nil, err
+	}
+	o.tags = tags
+	if len(tags) > 0 && c.TagParam != "" {
+		o.params = append(o.params, &Param{Key: c.TagParam, Value: strings.Join(tags, ",")})
+	}
+	planned := o.withParams(query)
+	prefix := c.apiPrefix(o) + "/"
 	if query == "login/v1" {
 		prefix = ""
 	} else if !c.opened {
 		return nil, fmt.Errorf("config not opened with Open()")
+	} else if c.isClosed() {
+		return nil, ErrClosed
+	} else {
+		q, err := c.applyPolicy(method, planned)
+		if err != nil {
+			return nil, err
+		}
+		query = q
 	}
 
 	u, err := url.Parse(c.url.String() + "/" + prefix + query)
@@ -248,89 +612,410 @@ func (c *Client) Call(method, query string, data interface{}) ([]byte, error) {
 		return nil, err
 	}
 
-	var post []byte
+	body := &payload{}
 	if data != nil {
 		switch data := data.(type) {
 		case []byte:
-			post = data
+			if err := checkPostData(data); err != nil {
+				return nil, err
+			}
+			body.data = data
+		case *Upload:
+			if data.Body == nil {
+				return nil, fmt.Errorf("missing: upload body")
+			}
+			body.upload = data
 		default:
-			d, err := json.Marshal(data)
+			d, err := c.codec().Marshal(data)
 			if err != nil {
 				return nil, err
 			}
-			post = d
+			body.data = d
+		}
+	}
+	if body.data != nil && c.ValidatePosts && (method == "POST" || method == "PUT") {
+		if err := c.ValidatePayload(endpoint(query), body.data); err != nil {
+			return nil, err
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), bytes.NewReader(post))
-	if err != nil {
-		return nil, err
+	if plan := c.dryRunPlan(o); plan != nil && !safeMethod(method) {
+		return c.dryRun(plan, method, planned, body)
+	}
+	if c.CompressThreshold > 0 && len(body.data) >= c.CompressThreshold {
+		if err := body.compress(); err != nil {
+			return nil, err
+		}
 	}
 
-	agent := "StratumClient/1.0"
-	if c.UserAgent != "" {
-		agent = agent + " (" + c.UserAgent + ")"
+	timeout, policy := c.profile(o.weight)
+	if o.timeout > 0 {
+		timeout = o.timeout
 	}
-	req.Header.Set("User-Agent", agent)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	if o.retry != nil {
+		policy = o.retry
+	}
+	limiter := c.rateLimiter()
+	if policy == nil && limiter != nil {
+		policy = rateLimitRetryPolicy
+	}
+	var attempts []*Attempt
+	replayed := false
+	for attempt := 1; ; attempt++ {
+		if err := limiter.wait(o.context()); err != nil {
+			return nil, err
+		}
 
-	if query == "login/v1" && method == "GET" {
-		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password)))
-	} else {
-		if c.token == "" || time.Now().After(c.validUntil) {
-			// token expired or missing: get a fresh one
-			c.token = ""
-			c.validUntil = time.Time{}
-			if err := c.login(); err != nil {
-				return nil, err
+		breaker := c.Breaker
+		if query == "login/v1" {
+			// logins run within the call needing the token
+			breaker = nil
+		}
+		if err := breaker.allow(); err != nil {
+			if len(attempts) > 0 {
+				return nil, &RetryError{Attempts: attempts, Err: err}
+			}
+			return nil, err
+		}
+
+		started := time.Now()
+		resp, err := c.send(method, u.String(), query, body, timeout, o)
+		breaker.record(resp, err)
+		if err == nil {
+			return resp, nil
+		}
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			limiter.throttle(resp)
+		}
+		if resp != nil && resp.StatusCode == http.StatusUnsupportedMediaType && body.encoding != "" {
+			// the server doesn't take compressed bodies: send it
+			// uncompressed instead
+			body.uncompress()
+			c.debug("stratum compressed body rejected", "method", method, "url", u.String())
+			attempt--
+			continue
+		}
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized && !replayed && c.replayable(method, query, o.idempotencyKey != "") && body.replayable() {
+			// the token was rejected before it expired: log in
+			// again and replay the request once
+			replayed = true
+			c.revokeToken(resp.Request.Header.Get("Authorization"))
+			c.debug("stratum token rejected", "method", method, "url", u.String())
+			attempt--
+			continue
+		}
+
+		a := &Attempt{Time: started, Err: err}
+		if resp != nil {
+			a.StatusCode = resp.StatusCode
+		}
+		attempts = append(attempts, a)
+
+		if !policy.retryable(method, o.idempotencyKey != "", resp, err) || attempt >= policy.attempts() || !body.replayable() {
+			if len(attempts) > 1 {
+				return nil, &RetryError{Attempts: attempts, Err: err}
 			}
+			return nil, err
+		}
+		a.Backoff = policy.backoff(attempt, resp, c.clock().Now())
+		c.debug("stratum retry", "method", method, "url", u.String(), "attempt", attempt, "backoff", a.Backoff, "error", err)
+		if err := c.clock().Sleep(o.context(), a.Backoff); err != nil {
+			return nil, &RetryError{Attempts: attempts, Err: err}
 		}
-		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
+}
 
-	client := http.Client{
-		Timeout: time.Duration(c.Timeout) * time.Second,
+// defaultMaxErrorBody is the MaxErrorBody used when it isn't set.
+const defaultMaxErrorBody = 64 << 10
+
+// readErrorBody reads an error response body up to the client's
+// MaxErrorBody limit. It reports whether the body was cut at the
+// limit, and the rest of the body is left unread.
+func (c *Client) readErrorBody(r io.Reader) ([]byte, bool, error) {
+	limit := c.MaxErrorBody
+	if limit <= 0 {
+		limit = defaultMaxErrorBody
 	}
 
-	resp, err := client.Do(req)
+	body, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
 	if err != nil {
+		return nil, false, err
+	}
+	if len(body) > limit {
+		return body[:limit], true, nil
+	}
+
+	return body, false, nil
+}
+
+// successCodes returns the response status codes which are results
+// of the call rather than errors.
+func (c *Client) successCodes(o *callOptions) []int {
+	if o.success != nil {
+		return o.success
+	}
+	if c.SuccessStatus != nil {
+		return c.SuccessStatus
+	}
+
+	return []int{http.StatusOK, http.StatusCreated, http.StatusNoContent}
+}
+
+// successful reports whether a response status code is a result of
+// the call rather than an error.
+func (c *Client) successful(code int, o *callOptions) bool {
+	for _, ok := range c.successCodes(o) {
+		if code == ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// send performs a single HTTP request. On success it returns the
+// response with its body unread, and the call timeout keeps running
+// until the body is closed. On failure the response is returned
+// along with the status error, with its body consumed, so the caller
+// can decide whether to retry.
+func (c *Client) send(method, u, query string, body *payload, timeout time.Duration, o *callOptions) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(o.context(), timeout)
+	ctx = httptrace.WithClientTrace(ctx, c.clientTrace())
+
+	r, err := body.reader()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if o.uploadProgress != nil && body.length() != 0 {
+		r = &progressReader{r: r, fn: o.uploadProgress, total: body.length()}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		cancel()
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	for key, value := range c.DefaultHeaders {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	req.Header.Set("Content-Type", body.contentType())
+	if body.encoding != "" {
+		req.Header.Set("Content-Encoding", body.encoding)
+	}
+	if body.upload != nil {
+		req.ContentLength = body.upload.ContentLength
+	} else {
+		req.ContentLength = int64(len(body.data))
+	}
+	if o.accept != "" {
+		req.Header.Set("Accept", o.accept)
+	} else {
+		req.Header.Set("Accept", "application/json")
+	}
+	if !c.DisableCompression {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if o.requestID != "" {
+		req.Header.Set("X-Request-ID", o.requestID)
+	}
+	if o.idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, o.idempotencyKey)
+	}
+	if len(o.tags) > 0 {
+		req.Header.Set(TagHeader, strings.Join(o.tags, ","))
+	}
+	for key, values := range o.header {
+		req.Header[key] = values
+	}
+
+	if query == "login/v1" && method == "GET" {
+		auth := c.authMethod()
+		if auth == nil {
+			cancel()
+			return nil, fmt.Errorf("missing: credentials to log in with")
+		}
+		if err := auth.Authorize(req); err != nil {
+			cancel()
+			return nil, err
+		}
+	} else {
+		token, err := c.bearer()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	started := time.Now()
+	resp, err := c.client.Do(req)
+	if c.Metrics != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.Metrics.ObserveRequest(method, endpoint(query), status, time.Since(started))
+	}
+	logged := []interface{}{"method", method, "url", u, "request_id", o.requestID}
+	if b := c.loggedBody(body); b != "" {
+		logged = append(logged, "body", b)
+	}
 	if err != nil {
+		cancel()
+		c.debug("stratum request", append(logged, "latency", time.Since(started), "headers", redactHeader(req.Header), "error", err)...)
 		return nil, err
 	}
+	c.debug("stratum request", append(logged, "status", resp.StatusCode, "latency", time.Since(started), "headers", redactHeader(req.Header))...)
+
+	// capture the headers as sent, before the body is decoded
+	if o.headers != nil {
+		*o.headers = resp.Header.Clone()
+	}
+	if o.meta != nil {
+		*o.meta = newResponseMeta(resp)
+	}
+	if o.status != nil {
+		*o.status = resp.StatusCode
+	}
+	c.stats.countResponse(resp)
+	c.checkDeprecation(query, resp.Header)
 
 	ct := resp.Header.Get("Content-Type")
-	if !(resp.StatusCode == 200 || resp.StatusCode == 201) {
-		if ct == "application/json" {
-			eresp := &ErrorResponse{}
+	isJSON := mediaType(ct) == "application/json"
+	if !c.successful(resp.StatusCode, o) {
+		defer cancel()
+		defer resp.Body.Close()
+
+		body, truncated, err := c.readErrorBody(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		eresp := &ErrorResponse{}
+		switch {
+		case isJSON && truncated:
+			eresp.Message = fmt.Sprintf("error response exceeds %d bytes", len(body))
+			eresp.Body = body
+		case isJSON:
 			if err := json.Unmarshal(body, &eresp); err != nil {
-				return nil, err
+				eresp = &ErrorResponse{Message: fmt.Sprintf("invalid JSON error response: %v", err), Body: body}
 			}
-			eresp.Status = resp.Status
-			eresp.StatusCode = resp.StatusCode
+		default:
+			eresp.Message = errorSummary(body)
+			eresp.Body = body
+		}
+
+		return resp, c.errorResponse(eresp, req, resp)
+	}
+
+	if !isJSON && o.accept == "" && !emptyResponse(resp) {
+		defer cancel()
+		defer resp.Body.Close()
 
-			return nil, eresp
+		body, _, err := c.readErrorBody(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		eresp := &ErrorResponse{Message: "server responded with unknown Content-Type: " + ct, Body: body}
+		if summary := errorSummary(body); summary != "" {
+			eresp.Message += ": " + summary
+		}
+
+		return resp, c.errorResponse(eresp, req, resp)
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	if o.downloadProgress != nil {
+		resp.Body = &progressBody{
+			progressReader: &progressReader{r: resp.Body, fn: o.downloadProgress, total: resp.ContentLength},
+			Closer:         resp.Body,
 		}
-		return nil, fmt.Errorf("%s", resp.Status)
 	}
 
-	if ct != "application/json" {
-		return nil, fmt.Errorf("server responded with unknown Content-Type: %s", ct)
+	return resp, nil
+}
+
+// errorResponse completes e with the request and response it is
+// about, and returns it.
+func (c *Client) errorResponse(e *ErrorResponse, req *http.Request, resp *http.Response) *ErrorResponse {
+	e.Status = resp.Status
+	e.StatusCode = resp.StatusCode
+	e.RequestID = responseRequestID(resp)
+	e.Method = req.Method
+	e.URL = redactURL(req.URL)
+
+	return e
+}
+
+// emptyResponse reports whether a successful response carries no
+// body, like 204 No Content, 304 Not Modified, responses to HEAD and
+// mutations without returning, so it needs no Content-Type.
+func emptyResponse(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return true
+	}
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return true
 	}
 
-	return body, nil
+	return resp.ContentLength == 0
+}
+
+// userAgent returns the User-Agent header value sent with all API
+// calls.
+func (c *Client) userAgent() string {
+	agent := "StratumClient/1.0"
+	if c.UserAgent != "" {
+		agent = agent + " (" + c.UserAgent + ")"
+	}
+
+	return agent
+}
+
+// mediaType returns the media type of a Content-Type header value
+// without its parameters, like charset, in lower case.
+func mediaType(ct string) string {
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(ct))
+}
+
+// cancelBody releases the call context when the response body is
+// closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the call context.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
 }
 
 // login will perform the initial login API call. The login is using
 // Basic authentication to retrieve a Bearer token (JWT). The function
 // returns an error if any.
 func (c *Client) login() error {
+	auth := c.authMethod()
+	if auth == nil {
+		return fmt.Errorf("token expired or missing: no credentials to log in with")
+	}
+
+	if c.loadToken() {
+		return nil
+	}
+
 	body, err := c.Call("GET", "login/v1", nil)
+	if c.Metrics != nil {
+		c.Metrics.ObserveTokenRefresh(err)
+	}
 	if err != nil {
 		return err
 	}
@@ -341,7 +1026,13 @@ func (c *Client) login() error {
 	}
 
 	c.token = resp.AccessToken
-	c.validUntil = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	c.validUntil = c.clock().Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if c.passwordProvider() != nil {
+		// the provider gives the password for the next login
+		c.Password = ""
+	}
+	c.debug("stratum token refreshed", "username", auth.Name(), "valid_until", c.validUntil)
+	c.saveToken()
 
 	return nil
 }
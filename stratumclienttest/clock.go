@@ -0,0 +1,66 @@
+package stratumclienttest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is a manual stratumclient.Clock, which only moves when told
+// to, so token expiry, retry backoff and cache lifetimes can be tested
+// without sleeping. Sleep returns at once, moving the clock forward by
+// the duration slept, and records the duration.
+//
+//	clock := stratumclienttest.NewClock(time.Now())
+//	c.Clock = clock
+//	clock.Advance(time.Hour) // the token has expired
+type Clock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements stratumclient.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Sleep implements stratumclient.Clock.
+func (c *Clock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slept = append(c.slept, d)
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+
+	return nil
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Slept returns the durations slept, in order.
+func (c *Clock) Slept() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]time.Duration(nil), c.slept...)
+}
@@ -0,0 +1,82 @@
+package stratumclienttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stianwa/stratumclient"
+)
+
+func TestClock(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddTable("platform", map[string]interface{}{"id": 1, "name": "Linux"})
+
+	clock := NewClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := &stratumclient.Client{Username: srv.Username, Password: srv.Password, BaseURL: srv.BaseURL(), Clock: clock}
+	c.Cache = &stratumclient.MemoryCache{TTL: time.Minute, Clock: clock}
+	if err := c.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if got, want := c.TokenValidUntil(), clock.Now().Add(time.Hour); !got.Equal(want) {
+		t.Fatalf("valid until: got %s, want %s", got, want)
+	}
+
+	var got []*platform
+	c.Get("platform/", &got)
+	srv.AddTable("platform", map[string]interface{}{"id": 1, "name": "BSD"})
+	clock.Advance(30 * time.Second)
+	if err := c.Get("platform/", &got); err != nil || got[0].Name != "Linux" {
+		t.Fatalf("cached: got %+v, %v", got, err)
+	}
+
+	// the token and the cache entry expire, without sleeping
+	clock.Advance(2 * time.Hour)
+	if err := c.Get("platform/", &got); err != nil || got[0].Name != "BSD" {
+		t.Fatalf("expired: got %+v, %v", got, err)
+	}
+	if got, want := c.TokenValidUntil(), clock.Now().Add(time.Hour); !got.Equal(want) {
+		t.Fatalf("refreshed token valid until: got %s, want %s", got, want)
+	}
+}
+
+func TestClockBackoff(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			writeJSON(w, http.StatusOK, &stratumclient.LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		if calls++; calls < 3 {
+			w.Header().Set("Retry-After", "60")
+			writeError(w, http.StatusServiceUnavailable, "busy")
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	defer srv.Close()
+
+	clock := NewClock(time.Now())
+	start := clock.Now()
+	c := &stratumclient.Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", Clock: clock}
+	c.Retry = &stratumclient.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, RespectRetryAfter: true}
+	if err := c.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	began := time.Now()
+	if err := c.Get("platform/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if time.Since(began) > 10*time.Second {
+		t.Errorf("backoff slept for real")
+	}
+	if slept := clock.Slept(); len(slept) != 2 || slept[0] != time.Minute || slept[1] != time.Minute {
+		t.Errorf("slept: got %v", slept)
+	}
+	if d := clock.Now().Sub(start); d != 2*time.Minute {
+		t.Errorf("clock moved %s, want 2m", d)
+	}
+}
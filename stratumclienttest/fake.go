@@ -0,0 +1,157 @@
+package stratumclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/stianwa/stratumclient"
+)
+
+// FakeCall is an API call recorded by Fake. Data is the post data as
+// JSON text, or nil.
+type FakeCall struct {
+	Method string
+	Query  string
+	Data   []byte
+}
+
+// Fake is a stratumclient.StratumAPI which records the calls made and
+// answers them with canned responses, without HTTP. Calls without a
+// canned response get an empty response, so the zero Fake is a no-op
+// implementation. Call options are ignored. It is safe for concurrent
+// use.
+//
+//	fake := &stratumclienttest.Fake{}
+//	fake.Respond("GET", "host/?select=id", []map[string]int{{"id": 1}})
+//	n, err := countHosts(fake)
+//	calls := fake.Calls()
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	calls     []FakeCall
+}
+
+// fakeResponse is a canned response of Fake.
+type fakeResponse struct {
+	body []byte
+	err  error
+}
+
+var _ stratumclient.StratumAPI = (*Fake)(nil)
+
+// fakeKey returns the key of the canned response for method and query.
+func fakeKey(method, query string) string {
+	return strings.ToUpper(method) + " " + strings.TrimLeft(query, "/")
+}
+
+// Respond sets the response to calls with method and query. The body
+// is JSON text given as []byte or string, or a value which is encoded
+// as JSON. The function returns an error if body can't be encoded.
+func (f *Fake) Respond(method, query string, body interface{}) error {
+	content, err := fakeJSON(body)
+	if err != nil {
+		return err
+	}
+	f.set(method, query, fakeResponse{body: content})
+
+	return nil
+}
+
+// RespondError makes calls with method and query fail with err, like
+// an *stratumclient.ErrorResponse with the status code of interest.
+func (f *Fake) RespondError(method, query string, err error) {
+	f.set(method, query, fakeResponse{err: err})
+}
+
+// set stores a canned response.
+func (f *Fake) set(method, query string, r fakeResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.responses == nil {
+		f.responses = make(map[string]fakeResponse)
+	}
+	f.responses[fakeKey(method, query)] = r
+}
+
+// Calls returns the calls made, in order.
+func (f *Fake) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// Reset forgets the calls made and the canned responses.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+	f.responses = nil
+}
+
+// Call implements stratumclient.StratumAPI.
+func (f *Fake) Call(method, query string, data interface{}, opts ...stratumclient.CallOption) ([]byte, error) {
+	content, err := fakeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, FakeCall{Method: strings.ToUpper(method), Query: query, Data: content})
+	r := f.responses[fakeKey(method, query)]
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return append([]byte(nil), r.body...), nil
+}
+
+// Unmarshal implements stratumclient.StratumAPI.
+func (f *Fake) Unmarshal(method, query string, data, resp interface{}, opts ...stratumclient.CallOption) error {
+	content, err := f.Call(method, query, data, opts...)
+	if err != nil {
+		return err
+	}
+	if resp == nil || len(bytes.TrimSpace(content)) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(content, resp)
+}
+
+// Get implements stratumclient.StratumAPI.
+func (f *Fake) Get(query string, resp interface{}, opts ...stratumclient.CallOption) error {
+	return f.Unmarshal("GET", query, nil, resp, opts...)
+}
+
+// Post implements stratumclient.StratumAPI.
+func (f *Fake) Post(query string, post, resp interface{}, opts ...stratumclient.CallOption) error {
+	return f.Unmarshal("POST", query, post, resp, opts...)
+}
+
+// Put implements stratumclient.StratumAPI.
+func (f *Fake) Put(query string, post, resp interface{}, opts ...stratumclient.CallOption) error {
+	return f.Unmarshal("PUT", query, post, resp, opts...)
+}
+
+// Delete implements stratumclient.StratumAPI.
+func (f *Fake) Delete(query string, post, resp interface{}, opts ...stratumclient.CallOption) error {
+	return f.Unmarshal("DELETE", query, post, resp, opts...)
+}
+
+// fakeJSON returns v as JSON text, or nil for nil.
+func fakeJSON(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+
+	return json.Marshal(v)
+}
@@ -0,0 +1,52 @@
+package stratumclienttest
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stianwa/stratumclient"
+)
+
+func TestFake(t *testing.T) {
+	fake := &Fake{}
+	var api stratumclient.StratumAPI = fake
+
+	if err := api.Get("host/", nil); err != nil {
+		t.Fatalf("no-op get: %v", err)
+	}
+
+	if err := fake.Respond("GET", "/platform/", `[{"id":1,"name":"Linux"}]`); err != nil {
+		t.Fatal(err)
+	}
+	fake.RespondError("DELETE", "platform/?id=eq.1", &stratumclient.ErrorResponse{Status: "404 Not Found", StatusCode: http.StatusNotFound})
+
+	var rows []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := api.Get("platform/", &rows); err != nil || len(rows) != 1 || rows[0].Name != "Linux" {
+		t.Fatalf("get: got %+v, %v", rows, err)
+	}
+	if err := api.Post("platform/", map[string]string{"name": "BSD"}, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if err := api.Delete("platform/?id=eq.1", nil, nil); !stratumclient.IsNotFound(err) {
+		t.Fatalf("delete: got %v, want not found", err)
+	}
+
+	want := []FakeCall{
+		{Method: "GET", Query: "host/"},
+		{Method: "GET", Query: "platform/"},
+		{Method: "POST", Query: "platform/", Data: []byte(`{"name":"BSD"}`)},
+		{Method: "DELETE", Query: "platform/?id=eq.1"},
+	}
+	if got := fake.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls: got %+v, want %+v", got, want)
+	}
+
+	fake.Reset()
+	if len(fake.Calls()) != 0 {
+		t.Fatalf("reset: calls kept")
+	}
+}
@@ -0,0 +1,567 @@
+// Package stratumclienttest implements an in-memory fake Stratum API
+// server for unit testing code which uses stratumclient, without a
+// live Stratum instance.
+//
+//	srv := stratumclienttest.NewServer()
+//	defer srv.Close()
+//	srv.AddTable("platform", map[string]interface{}{"id": 1, "name": "Linux"})
+//
+//	c, err := srv.Client()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//
+// The server answers login/v1 with a token for its Username and
+// Password, and serves GET, POST, PUT and DELETE on the registered
+// tables. Queries support the select, where, orderby, limit, offset
+// and returning parameters, with where clauses in the syntax
+// described by stratumclient.Expr. The ~ operator is a
+// case-insensitive regular expression match. Orderby takes comma
+// separated columns, where a leading - sorts in descending order.
+//
+// Code depending on the stratumclient.StratumAPI interface can be
+// tested without HTTP using Fake, which records the calls made and
+// answers them with canned responses.
+package stratumclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stianwa/stratumclient"
+)
+
+// Server is a fake Stratum API server.
+type Server struct {
+	*httptest.Server
+
+	// Username and Password are the credentials accepted by
+	// login/v1. Token is the bearer token handed out.
+	Username string
+	Password string
+	Token    string
+	// Prefix is the API path prefix, which is the path part of
+	// the client's BaseURL.
+	Prefix string
+
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// table holds the rows of a table. New rows without an id are given
+// the next free one.
+type table struct {
+	rows   []map[string]interface{}
+	nextID int64
+}
+
+// NewServer starts and returns a fake server with no tables. The
+// server should be closed with Close when done.
+func NewServer() *Server {
+	s := &Server{
+		Username: "user",
+		Password: "secret",
+		Token:    "stratumclienttest-token",
+		Prefix:   "/stratum/v1",
+		tables:   make(map[string]*table),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+// BaseURL returns the BaseURL clients should use for the server.
+func (s *Server) BaseURL() string {
+	return s.URL + s.Prefix
+}
+
+// Client returns a client opened against the server with the
+// server's credentials.
+func (s *Server) Client() (*stratumclient.Client, error) {
+	c := &stratumclient.Client{Username: s.Username, Password: s.Password, BaseURL: s.BaseURL()}
+	if err := c.Open(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// AddTable registers a table with the given fixture rows, replacing
+// any existing table with the same name. The rows are copied through
+// JSON, so they may hold any values encoding/json can marshal.
+func (s *Server) AddTable(name string, rows ...map[string]interface{}) error {
+	t := &table{nextID: 1}
+	for _, row := range rows {
+		r, err := normalize(row)
+		if err != nil {
+			return err
+		}
+		t.insert(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[strings.Trim(name, "/")] = t
+
+	return nil
+}
+
+// Rows returns a copy of the rows of a table, or nil if the table
+// doesn't exist.
+func (s *Server) Rows(name string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.Trim(name, "/")]
+	if !ok {
+		return nil
+	}
+	rows := make([]map[string]interface{}, len(t.rows))
+	for i, row := range t.rows {
+		rows[i] = copyRow(row)
+	}
+
+	return rows
+}
+
+// serve handles all requests to the server.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "login/v1" {
+		s.login(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+	prefix := strings.Trim(s.Prefix, "/") + "/"
+	if !strings.HasPrefix(path+"/", prefix) {
+		writeError(w, http.StatusNotFound, "not found: "+r.URL.Path)
+		return
+	}
+	name := strings.TrimPrefix(path+"/", prefix)
+	name = strings.Trim(name, "/")
+
+	q, err := parseQuery(name + "/?" + r.URL.RawQuery)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var data []map[string]interface{}
+	if r.Method == "POST" || r.Method == "PUT" {
+		data, err = readData(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tables[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown table: "+name)
+		return
+	}
+
+	var rows []map[string]interface{}
+	switch r.Method {
+	case "GET":
+		rows = q.apply(t.at(t.match(q)))
+		writeRows(w, q.project(rows, q.selects))
+		return
+	case "POST":
+		for _, row := range data {
+			rows = append(rows, t.insert(row))
+		}
+	case "PUT":
+		if len(data) != 1 {
+			writeError(w, http.StatusBadRequest, "update takes a single object")
+			return
+		}
+		rows = t.at(t.match(q))
+		for _, row := range rows {
+			for k, v := range data[0] {
+				row[k] = v
+			}
+		}
+	case "DELETE":
+		idx := t.match(q)
+		rows = t.at(idx)
+		t.remove(idx)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed: "+r.Method)
+		return
+	}
+
+	if q.returning == nil {
+		rows = nil
+	}
+	writeRows(w, q.project(rows, q.returning))
+}
+
+// login hands out the token for Basic authentication with the
+// server's credentials.
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok || username != s.Username || password != s.Password {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &stratumclient.LoginResponse{AccessToken: s.Token, ExpiresIn: 3600, TokenType: "bearer"})
+}
+
+// insert adds a row to the table, giving it the next free id if it
+// has none, and returns it.
+func (t *table) insert(row map[string]interface{}) map[string]interface{} {
+	if id, ok := row["id"]; ok {
+		if n, err := strconv.ParseInt(valueText(id), 10, 64); err == nil && n >= t.nextID {
+			t.nextID = n + 1
+		}
+	} else {
+		row["id"] = json.Number(strconv.FormatInt(t.nextID, 10))
+		t.nextID++
+	}
+	t.rows = append(t.rows, row)
+
+	return row
+}
+
+// match returns the indexes of the rows matching the where clause
+// of q.
+func (t *table) match(q *query) []int {
+	var idx []int
+	for i, row := range t.rows {
+		if q.match(q.where, row) {
+			idx = append(idx, i)
+		}
+	}
+
+	return idx
+}
+
+// at returns the rows at the given indexes.
+func (t *table) at(idx []int) []map[string]interface{} {
+	rows := make([]map[string]interface{}, len(idx))
+	for i, n := range idx {
+		rows[i] = t.rows[n]
+	}
+
+	return rows
+}
+
+// remove deletes the rows at the given indexes, which must be in
+// increasing order.
+func (t *table) remove(idx []int) {
+	kept := t.rows[:0]
+	for i, row := range t.rows {
+		if len(idx) > 0 && idx[0] == i {
+			idx = idx[1:]
+			continue
+		}
+		kept = append(kept, row)
+	}
+	t.rows = kept
+}
+
+// query holds the parsed parameters of a request.
+type query struct {
+	selects   []string
+	returning []string
+	where     stratumclient.Expr
+	regexps   map[string]*regexp.Regexp
+	orderby   []string
+	limit     int
+	offset    int
+}
+
+// parseQuery parses the query of a request.
+func parseQuery(text string) (*query, error) {
+	sq, err := stratumclient.ParseQuery(text)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{
+		selects: sq.Select,
+		where:   sq.Where,
+		regexps: make(map[string]*regexp.Regexp),
+		orderby: sq.OrderBy,
+		limit:   -1,
+	}
+	for _, c := range stratumclient.Conds(sq.Where) {
+		if c.Op == "~" || c.Op == "!~" {
+			re, err := regexp.Compile("(?i)" + c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid where condition %s: %v", c, err)
+			}
+			q.regexps[c.Value] = re
+		}
+	}
+
+	for _, p := range sq.Params {
+		switch p.Key {
+		case "returning":
+			q.returning = splitList(p.Value)
+			if q.returning == nil {
+				q.returning = []string{"*"}
+			}
+		case "limit", "offset":
+			n, err := strconv.Atoi(p.Value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s: %s", p.Key, p.Value)
+			}
+			if p.Key == "limit" {
+				q.limit = n
+			} else {
+				q.offset = n
+			}
+		}
+	}
+
+	return q, nil
+}
+
+// match reports whether the row matches the where expression.
+func (q *query) match(e stratumclient.Expr, row map[string]interface{}) bool {
+	switch e := e.(type) {
+	case nil:
+		return true
+	case stratumclient.And:
+		for _, x := range e {
+			if !q.match(x, row) {
+				return false
+			}
+		}
+		return true
+	case stratumclient.Or:
+		for _, x := range e {
+			if q.match(x, row) {
+				return true
+			}
+		}
+		return false
+	case *stratumclient.Not:
+		return !q.match(e.Expr, row)
+	case *stratumclient.Cond:
+		return q.matchCond(e, row)
+	}
+
+	return false
+}
+
+// matchCond reports whether the row matches the condition.
+func (q *query) matchCond(c *stratumclient.Cond, row map[string]interface{}) bool {
+	v := row[c.Column]
+	if c.Null && (c.Op == "=" || c.Op == "!=") {
+		return (v == nil) == (c.Op == "=")
+	}
+	if v == nil {
+		return false
+	}
+
+	switch c.Op {
+	case "~":
+		return q.regexps[c.Value].MatchString(valueText(v))
+	case "!~":
+		return !q.regexps[c.Value].MatchString(valueText(v))
+	}
+
+	n := compare(v, c.Value)
+	switch c.Op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	}
+
+	return n >= 0
+}
+
+// apply sorts and pages the rows.
+func (q *query) apply(rows []map[string]interface{}) []map[string]interface{} {
+	if len(q.orderby) > 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for _, col := range q.orderby {
+				desc := strings.HasPrefix(col, "-")
+				col = strings.TrimPrefix(col, "-")
+				c := compare(rows[i][col], rows[j][col])
+				if c != 0 {
+					return (c < 0) != desc
+				}
+			}
+			return false
+		})
+	}
+
+	if q.offset >= len(rows) {
+		return nil
+	}
+	rows = rows[q.offset:]
+	if q.limit >= 0 && q.limit < len(rows) {
+		rows = rows[:q.limit]
+	}
+
+	return rows
+}
+
+// project returns copies of the rows holding only the selected
+// columns. All columns are kept if none or * are selected.
+func (q *query) project(rows []map[string]interface{}, cols []string) []map[string]interface{} {
+	all := len(cols) == 0 || (len(cols) == 1 && cols[0] == "*")
+	ret := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		if all {
+			ret = append(ret, copyRow(row))
+			continue
+		}
+		r := make(map[string]interface{}, len(cols))
+		for _, col := range cols {
+			r[col] = row[col]
+		}
+		ret = append(ret, r)
+	}
+
+	return ret
+}
+
+// compare compares two values numerically if both are numbers,
+// otherwise as text. Missing values sort first.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	ta, tb := valueText(a), valueText(b)
+	fa, erra := strconv.ParseFloat(ta, 64)
+	fb, errb := strconv.ParseFloat(tb, 64)
+	if erra == nil && errb == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(ta, tb)
+}
+
+// valueText returns the text of a value.
+func valueText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+
+	return fmt.Sprint(v)
+}
+
+// splitList splits a comma separated list, returning nil for an
+// empty one.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// readData reads post data holding an object or an array of
+// objects.
+func readData(r io.Reader) ([]map[string]interface{}, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if t := bytes.TrimSpace(body); len(t) > 0 && t[0] == '[' {
+		var rows []map[string]interface{}
+		if err := dec.Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid post data: %v", err)
+		}
+		return rows, nil
+	}
+
+	var row map[string]interface{}
+	if err := dec.Decode(&row); err != nil {
+		return nil, fmt.Errorf("invalid post data: %v", err)
+	}
+
+	return []map[string]interface{}{row}, nil
+}
+
+// normalize copies a row through JSON, so its values have the types
+// of decoded post data.
+func normalize(row map[string]interface{}) (map[string]interface{}, error) {
+	text, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := readData(bytes.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+
+	return rows[0], nil
+}
+
+// copyRow returns a shallow copy of a row.
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		r[k] = v
+	}
+
+	return r
+}
+
+// writeRows writes rows as a JSON array.
+func writeRows(w http.ResponseWriter, rows []map[string]interface{}) {
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	writeJSON(w, http.StatusOK, rows)
+}
+
+// writeError writes an API error response.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
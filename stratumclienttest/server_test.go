@@ -0,0 +1,79 @@
+package stratumclienttest
+
+import (
+	"testing"
+
+	"github.com/stianwa/stratumclient"
+)
+
+type platform struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Arch string `json:"arch,omitempty"`
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	if err := srv.AddTable("platform",
+		map[string]interface{}{"id": 1, "name": "Linux", "arch": "x86_64"},
+		map[string]interface{}{"id": 2, "name": "Windows", "arch": "x86_64"},
+		map[string]interface{}{"id": 3, "name": "linux-arm", "arch": "aarch64"},
+	); err != nil {
+		t.Fatalf("add table: %v", err)
+	}
+
+	c, err := srv.Client()
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+
+	var got []*platform
+	if err := c.Get("platform/?select=id,name&where=name~^linux,arch=x86_64|id=2", &got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 1 || got[0].Arch != "" {
+		t.Fatalf("get: got %+v", got)
+	}
+
+	got = nil
+	if err := c.Get("platform/?orderby=-id&limit=2&offset=1", &got); err != nil {
+		t.Fatalf("get ordered: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 2 || got[1].ID != 1 {
+		t.Fatalf("get ordered: got %+v", got)
+	}
+
+	created, err := stratumclient.PostAs[platform](c, "platform/?returning=*", map[string]string{"name": "BSD"})
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if len(created) != 1 || created[0].ID != 4 {
+		t.Fatalf("post: got %+v", created)
+	}
+
+	if err := c.Put("platform/?where=id=4", map[string]string{"arch": "riscv"}, nil); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	deleted, err := stratumclient.DeleteAs[platform](c, "platform/?returning=*&where=arch=x86_64", nil)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(deleted) != 2 {
+		t.Fatalf("delete: got %+v", deleted)
+	}
+
+	rows := srv.Rows("platform")
+	if len(rows) != 2 || rows[1]["arch"] != "riscv" {
+		t.Fatalf("rows: got %v", rows)
+	}
+
+	if err := c.Get("missing/", nil); !stratumclient.IsNotFound(err) {
+		t.Fatalf("get missing table: got %v", err)
+	}
+
+	bad := &stratumclient.Client{Username: "user", Password: "wrong", BaseURL: srv.BaseURL()}
+	if err := bad.Open(); !stratumclient.IsUnauthorized(err) {
+		t.Fatalf("open with wrong password: got %v", err)
+	}
+}
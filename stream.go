@@ -0,0 +1,63 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// GetStream will perform a GET API call to stratum and decode the
+// response row by row, calling fn for each row as it is read from
+// the network. Unlike Get, the response is never held in memory as a
+// whole, which makes GetStream suitable for very large result sets.
+// Returning an error from fn stops the decoding and the error is
+// returned.
+func (c *Client) GetStream(query string, fn func(row json.RawMessage) error, opts ...CallOption) error {
+	return c.UnmarshalStream("GET", query, nil, fn, opts...)
+}
+
+// UnmarshalStream will perform an API call to stratum and decode the
+// response row by row like GetStream. It takes a method, query
+// string, post data, and the row callback.
+func (c *Client) UnmarshalStream(method, query string, data interface{}, fn func(row json.RawMessage) error, opts ...CallOption) error {
+	resp, err := c.do(method, query, data, newCallOptions(opts))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeStream(resp.Body, fn)
+}
+
+// decodeStream decodes a JSON array from r, calling fn for each
+// element.
+func decodeStream(r io.Reader, fn func(row json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("stream: expected JSON array, got %v", tok)
+	}
+
+	for dec.More() {
+		var row json.RawMessage
+		if err := dec.Decode(&row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetStream(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "[")
+		for i := 1; i <= 1000; i++ {
+			if i > 1 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":%d,"name":"host%d"}`, i, i)
+		}
+		fmt.Fprint(w, "]")
+	})
+
+	sum := 0
+	err := cl.GetStream("host/", func(row json.RawMessage) error {
+		var h struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(row, &h); err != nil {
+			return err
+		}
+		sum += h.ID
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if sum != 500500 {
+		t.Fatalf("sum: got %d, want 500500", sum)
+	}
+
+	stop := errors.New("stop")
+	rows := 0
+	err = cl.GetStream("host/", func(row json.RawMessage) error {
+		rows++
+		if rows == 10 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || rows != 10 {
+		t.Fatalf("stop: got %v after %d rows", err, rows)
+	}
+}
+
+func TestGetStreamNotArray(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int{"id": 1})
+	})
+
+	if err := cl.GetStream("host/", func(json.RawMessage) error { return nil }); err == nil {
+		t.Fatalf("stream object: expected error")
+	}
+}
@@ -0,0 +1,134 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DecodeMismatchError is returned when a client with StrictDecode
+// decodes response rows which don't match the struct decoded into, a
+// sign of drift between the struct and the API schema. Unknown lists
+// the response columns the struct has no field for, and Missing the
+// struct fields without omitempty which rows lack. The response is
+// decoded regardless.
+type DecodeMismatchError struct {
+	Type    string
+	Unknown []string
+	Missing []string
+}
+
+// Error implements the error interface.
+func (e *DecodeMismatchError) Error() string {
+	var problems []string
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown columns "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Missing) > 0 {
+		problems = append(problems, "missing columns "+strings.Join(e.Missing, ", "))
+	}
+
+	return fmt.Sprintf("response doesn't match %s: %s", e.Type, strings.Join(problems, "; "))
+}
+
+// checkStrict compares the rows of a response to the struct they were
+// decoded into, if the client has StrictDecode set. Missing columns
+// aren't reported for queries selecting columns, and responses which
+// aren't decoded into structs aren't checked.
+func (c *Client) checkStrict(query string, content []byte, resp interface{}) error {
+	if !c.StrictDecode {
+		return nil
+	}
+	t := reflect.TypeOf(resp)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(content, &rows); err != nil {
+		var row map[string]json.RawMessage
+		if json.Unmarshal(content, &row) != nil {
+			return nil
+		}
+		rows = append(rows, row)
+	}
+
+	fields := make(map[string]bool)
+	structFields(t, fields)
+	fold := strings.ToLower
+	if c.snakeCase() {
+		fold = squash
+	}
+	folded := make(map[string]bool, len(fields))
+	for name := range fields {
+		folded[fold(name)] = true
+	}
+
+	e := &DecodeMismatchError{Type: t.String()}
+	unknown := make(map[string]bool)
+	missing := make(map[string]bool)
+	checkMissing := true
+	if q, err := ParseQuery(query); err == nil && len(q.Select) > 0 {
+		checkMissing = false
+	}
+	for _, row := range rows {
+		for key := range row {
+			// encoding/json matches field names case-insensitively
+			if !fields[key] && !folded[fold(key)] {
+				unknown[key] = true
+			}
+		}
+		if !checkMissing {
+			continue
+		}
+		keys := make(map[string]bool, len(row))
+		for key := range row {
+			keys[fold(key)] = true
+		}
+		for name, omitempty := range fields {
+			if !omitempty && !keys[fold(name)] {
+				missing[name] = true
+			}
+		}
+	}
+	e.Unknown = sortedKeys(unknown)
+	e.Missing = sortedKeys(missing)
+	if len(e.Unknown) == 0 && len(e.Missing) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// structFields adds the JSON names of the fields of struct type t to
+// fields, with whether they are omitempty, including the fields of
+// embedded structs.
+func structFields(t reflect.Type, fields map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			structFields(ft, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = strings.Contains(","+opts+",", ",omitempty,")
+	}
+}
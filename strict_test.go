@@ -0,0 +1,61 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type strictBase struct {
+	ID int `json:"id"`
+}
+
+type strictPlatform struct {
+	strictBase
+	Name    string `json:"name"`
+	Arch    string `json:"arch"`
+	Comment string `json:"comment,omitempty"`
+	Ignored string `json:"-"`
+	Vendor  string
+}
+
+func TestStrictDecode(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []map[string]interface{}{
+			{"id": 1, "name": "Linux", "vendor": "Red Hat", "os_family": "unix"},
+			{"id": 2, "name": "BSD", "Vendor": "", "arch": "x86_64"},
+		})
+	})
+
+	var rows []*strictPlatform
+	if err := cl.Get("platform/", &rows); err != nil {
+		t.Fatalf("lenient get: %v", err)
+	}
+
+	cl.StrictDecode = true
+	rows = nil
+	err := cl.Get("platform/", &rows)
+	var mismatch *DecodeMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("strict get: got %v", err)
+	}
+	if len(mismatch.Unknown) != 1 || mismatch.Unknown[0] != "os_family" || len(mismatch.Missing) != 1 || mismatch.Missing[0] != "arch" {
+		t.Errorf("mismatch: got %+v", mismatch)
+	}
+	if len(rows) != 2 || rows[0].Vendor != "Red Hat" {
+		t.Errorf("rows not decoded: got %+v", rows)
+	}
+	want := "response doesn't match stratumclient.strictPlatform: unknown columns os_family; missing columns arch"
+	if err.Error() != want {
+		t.Errorf("error: got %q", err)
+	}
+
+	err = cl.Get("platform/?select=id,name,vendor,os_family", &rows)
+	if !errors.As(err, &mismatch) || len(mismatch.Missing) != 0 {
+		t.Errorf("select: got %v", err)
+	}
+	var maps []map[string]interface{}
+	if err := cl.Get("platform/", &maps); err != nil {
+		t.Errorf("maps: got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package stratumclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagHeader is the request header carrying the tags of a call.
+const TagHeader = "X-Stratum-Tag"
+
+// Tag labels the call with tags, like the name of the job making it,
+// added to the client Tags. The tags are sent comma separated in the
+// X-Stratum-Tag header, and as the client TagParam query parameter if
+// set, so heavy queries logged by the server can be attributed to the
+// jobs sending them. Tags may hold letters, digits and the characters
+// -_.:/ only.
+func Tag(tags ...string) CallOption {
+	return func(o *callOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// callTags returns the tags of a call, the client Tags followed by
+// the ones given with Tag, without duplicates, and an error if a tag
+// is invalid.
+func (c *Client) callTags(o *callOptions) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, c.Tags...), o.tags...) {
+		if !validTag(tag) {
+			return nil, fmt.Errorf("invalid tag: %q", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// validTag reports whether tag is non-empty and safe to send in a
+// header and query parameter.
+func validTag(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, r := range tag {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("-_.:/", r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,36 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTag(t *testing.T) {
+	var header, param string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(TagHeader)
+		param = r.URL.Query().Get("comment")
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	if err := cl.Get("host/", nil, Tag("nightly-sync")); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if header != "nightly-sync" || param != "" {
+		t.Errorf("tag: got header %q, param %q", header, param)
+	}
+
+	cl.Tags = []string{"inventory"}
+	cl.TagParam = "comment"
+	if err := cl.Get("host/?name=eq.a", nil, Tag("nightly-sync", "inventory")); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if header != "inventory,nightly-sync" || param != "inventory,nightly-sync" {
+		t.Errorf("tags: got header %q, param %q", header, param)
+	}
+
+	header = ""
+	if err := cl.Get("host/", nil, Tag("bad tag\r\nX-Evil: 1")); err == nil || header != "" {
+		t.Errorf("invalid tag: got %v, sent %q", err, header)
+	}
+}
@@ -0,0 +1,141 @@
+package stratumclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Transport defaults used when the corresponding Client fields
+// aren't set. The default of 16 idle connections per host lets
+// connections opened by Warmup and concurrent calls be reused.
+const (
+	defaultTLSSessionCacheSize = 64
+	defaultMaxIdleConnsPerHost = 16
+	defaultDialTimeout         = 30 * time.Second
+)
+
+// maxIdleConnsPerHost returns the number of idle connections kept
+// per host.
+func (c *Client) maxIdleConnsPerHost() int {
+	if c.MaxIdleConnsPerHost > 0 {
+		return c.MaxIdleConnsPerHost
+	}
+
+	return defaultMaxIdleConnsPerHost
+}
+
+// newHTTPClient returns the HTTP client used for all API calls,
+// configured with the client's TLS settings. Timeouts are set per
+// call.
+func (c *Client) newHTTPClient() (*http.Client, error) {
+	cfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = c.dialer()
+	transport.MaxIdleConnsPerHost = c.maxIdleConnsPerHost()
+	transport.DisableCompression = c.DisableCompression
+	if c.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	}
+	if c.DisableHTTP2 {
+		// a non-nil empty map turns off the built-in HTTP/2 support
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if c.TLSSessionCacheSize >= 0 {
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if cfg.ClientSessionCache == nil {
+			size := c.TLSSessionCacheSize
+			if size == 0 {
+				size = defaultTLSSessionCacheSize
+			}
+			cfg.ClientSessionCache = tls.NewLRUClientSessionCache(size)
+		}
+	}
+	if cfg != nil {
+		transport.TLSClientConfig = cfg
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// tlsConfig returns the TLS configuration given by TLSConfig or the
+// certificate file fields. It returns nil if none are set, leaving
+// the transport defaults in place.
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	if c.TLSConfig != nil {
+		return c.TLSConfig.Clone(), nil
+	}
+	if c.CACertFile == "" && c.ClientCertFile == "" && c.ClientKeyFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CACertFile != "" {
+		pem, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no CA certificates found in %s", c.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" || c.ClientKeyFile != "" {
+		if c.ClientCertFile == "" {
+			return nil, fmt.Errorf("missing: ClientCertFile")
+		}
+		if c.ClientKeyFile == "" {
+			return nil, fmt.Errorf("missing: ClientKeyFile")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// dialer returns the dial function of the transport, with the
+// client's dual-stack settings.
+func (c *Client) dialer() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	timeout := c.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	d := &net.Dialer{
+		Timeout:       timeout,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: c.FallbackDelay,
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if c.DisableIPv6 && network == "tcp" {
+			network = "tcp4"
+		}
+
+		return d.DialContext(ctx, network, addr)
+	}
+}
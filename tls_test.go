@@ -0,0 +1,152 @@
+package stratumclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSFiles(t *testing.T) {
+	var peers int
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peers = len(r.TLS.PeerCertificates)
+		writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	cert := srv.TLS.Certificates[0]
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", CACertFile: certFile}
+	if err := cl.Open(); err == nil {
+		t.Fatalf("open without client certificate: expected error")
+	}
+
+	cl = &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1",
+		CACertFile: certFile, ClientCertFile: certFile, ClientKeyFile: keyFile}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if peers != 1 {
+		t.Fatalf("client certificates: got %d, want 1", peers)
+	}
+
+	cl = &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", ClientCertFile: certFile}
+	if err := cl.Open(); err == nil {
+		t.Fatalf("open without client key: expected error")
+	}
+}
+
+func TestTLSSessionResumption(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	for _, tt := range []struct {
+		size    int
+		resumed int64
+	}{{0, 1}, {-1, 0}} {
+		cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1",
+			TLSConfig: &tls.Config{RootCAs: roots}, TLSSessionCacheSize: tt.size}
+		if err := cl.Open(); err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		cl.client.CloseIdleConnections()
+		if err := cl.Get("host/", nil); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+
+		st := cl.Stats()
+		if st.TLSHandshakes != 2 || st.TLSResumed != tt.resumed {
+			t.Errorf("size %d: got %d handshakes, %d resumed, want 2, %d", tt.size, st.TLSHandshakes, st.TLSResumed, tt.resumed)
+		}
+	}
+}
+
+func TestDialerDisableIPv6(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	cl := &Client{DisableIPv6: true}
+	dial := cl.dialer()
+	conn, err := dial(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial IPv4: %v", err)
+	}
+	conn.Close()
+
+	if conn, err := dial(context.Background(), "tcp", net.JoinHostPort("::1", port)); err == nil {
+		conn.Close()
+		t.Fatal("dial IPv6 with DisableIPv6: got connection")
+	}
+}
+
+func TestTransportTuning(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{map[string]int{"proto": r.ProtoMajor}})
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	for _, tt := range []struct {
+		disable bool
+		proto   int
+	}{{false, 2}, {true, 1}} {
+		cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1",
+			TLSConfig: &tls.Config{RootCAs: roots}, DisableHTTP2: tt.disable,
+			MaxIdleConnsPerHost: 64, IdleConnTimeout: time.Minute, TLSHandshakeTimeout: 5 * time.Second}
+		if err := cl.Open(); err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		var rows []map[string]int
+		if err := cl.Get("host/", &rows); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if rows[0]["proto"] != tt.proto {
+			t.Errorf("DisableHTTP2 %t: got HTTP/%d, want HTTP/%d", tt.disable, rows[0]["proto"], tt.proto)
+		}
+
+		tr := cl.client.Transport.(*http.Transport)
+		if tr.MaxIdleConnsPerHost != 64 || tr.IdleConnTimeout != time.Minute || tr.TLSHandshakeTimeout != 5*time.Second {
+			t.Errorf("transport: got %d, %s, %s", tr.MaxIdleConnsPerHost, tr.IdleConnTimeout, tr.TLSHandshakeTimeout)
+		}
+	}
+}
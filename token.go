@@ -0,0 +1,165 @@
+package stratumclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AccessToken returns a valid bearer token for the client, logging in
+// again if the current token has expired. The token can be handed
+// to other processes which construct their own client with
+// WithToken.
+func (c *Client) AccessToken() (string, error) {
+	if !c.opened {
+		return "", fmt.Errorf("config not opened with Open()")
+	}
+
+	return c.bearer()
+}
+
+// bearer returns the client's token, logging in again if the token
+// is missing or has expired. A token within RefreshWindow of its
+// expiry is refreshed early, and kept if that fails. It is safe for
+// concurrent use.
+func (c *Client) bearer() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return "", ErrClosed
+	}
+	now := c.clock().Now()
+	if c.token == "" || now.After(c.validUntil) {
+		// token expired or missing: get a fresh one
+		c.token = ""
+		c.validUntil = time.Time{}
+		if err := c.login(); err != nil {
+			return "", err
+		}
+	} else if c.expiring(now) && c.authMethod() != nil && !c.noPreRefresh.Equal(c.validUntil) {
+		err := c.login()
+		if err != nil || c.expiring(now) {
+			// a failed refresh, or a token living shorter than the
+			// window, is refreshed once it has expired
+			c.noPreRefresh = c.validUntil
+		}
+		if err != nil {
+			c.debug("stratum token pre-refresh failed", "valid_until", c.validUntil, "error", err)
+		}
+	}
+
+	return c.token, nil
+}
+
+// defaultRefreshWindow is the RefreshWindow used when it isn't set.
+const defaultRefreshWindow = 30 * time.Second
+
+// expiring reports whether the token is within RefreshWindow of its
+// expiry.
+func (c *Client) expiring(now time.Time) bool {
+	window := c.RefreshWindow
+	switch {
+	case window < 0:
+		return false
+	case window == 0:
+		window = defaultRefreshWindow
+	}
+
+	return now.Add(window).After(c.validUntil)
+}
+
+// TokenValidUntil returns the expiry time of the client's current
+// token, or the zero time if the client holds no token.
+func (c *Client) TokenValidUntil() time.Time {
+	if c.mu == nil {
+		return time.Time{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.validUntil
+}
+
+// replayable reports whether a call rejected with 401 Unauthorized
+// may be replayed after logging in again. Keyed tells whether the
+// call has an idempotency key.
+func (c *Client) replayable(method, query string, keyed bool) bool {
+	if query == "login/v1" || c.authMethod() == nil {
+		return false
+	}
+
+	return method == "GET" || method == "PUT" || method == "DELETE" || c.ReplayPost || keyed
+}
+
+// revokeToken forgets the token of the given Authorization header,
+// unless another call has already replaced it, so the next call logs
+// in again. The token is not picked up from the token store again.
+func (c *Client) revokeToken(auth string) {
+	token := strings.TrimPrefix(auth, "Bearer ")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == token {
+		c.token = ""
+		c.validUntil = time.Time{}
+		c.revoked = token
+	}
+}
+
+// WithToken returns a new client with the same configuration as c,
+// which authorizes all API calls with the given bearer token. The
+// returned client holds no username or password, so it can't log in
+// again and calls fail once the token has expired. This lets a
+// coordinator hand restricted clients to worker processes.
+func (c *Client) WithToken(token string) (*Client, error) {
+	if token == "" {
+		return nil, fmt.Errorf("missing: token")
+	}
+
+	n := *c
+	n.Username = ""
+	n.Password = ""
+	n.Auth = nil
+	n.OIDC = nil
+	n.PasswordProvider = nil
+	n.PasswordFile = ""
+	n.Token = token
+	n.TokenStore = nil
+	if !n.opened {
+		if err := n.Open(); err != nil {
+			return nil, err
+		}
+		return &n, nil
+	}
+	n.token = token
+	n.validUntil = tokenExpiry(token)
+
+	return &n, nil
+}
+
+// tokenExpiry returns the expiry time found in the exp claim of a
+// JWT. Tokens without a readable expiry are assumed to never expire.
+func tokenExpiry(token string) time.Time {
+	never := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return never
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return never
+	}
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return never
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,216 @@
+package stratumclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithToken(t *testing.T) {
+	var auth []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	token, err := cl.AccessToken()
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if token != "token" {
+		t.Fatalf("token: got %q", token)
+	}
+
+	worker, err := cl.WithToken("worker-token")
+	if err != nil {
+		t.Fatalf("with token: %v", err)
+	}
+	if worker.Password != "" {
+		t.Fatalf("worker client holds the password")
+	}
+	if err := worker.Get("platform/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if err := cl.Get("platform/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(auth) != 2 || auth[0] != "Bearer worker-token" || auth[1] != "Bearer token" {
+		t.Fatalf("authorization: got %q", auth)
+	}
+
+	expired, err := cl.WithToken("x." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1}`)) + ".y")
+	if err != nil {
+		t.Fatalf("with token: %v", err)
+	}
+	if err := expired.Get("platform/", nil); err == nil {
+		t.Fatalf("get with expired token: expected error")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x","exp":1700000000}`))
+	if got := tokenExpiry("e30." + payload + ".sig"); !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("expiry: got %v", got)
+	}
+	if got := tokenExpiry("opaque"); got.Year() != 9999 {
+		t.Errorf("opaque expiry: got %v", got)
+	}
+}
+
+func TestTokenMode(t *testing.T) {
+	var auth string
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			logins++
+		}
+		auth = r.Header.Get("Authorization")
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{Token: "api-key", BaseURL: srv.URL + "/stratum/v1"}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := cl.Get("platform/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if logins != 0 {
+		t.Fatalf("logins: got %d, want 0", logins)
+	}
+	if auth != "Bearer api-key" {
+		t.Fatalf("authorization: got %q", auth)
+	}
+
+	if err := (&Client{BaseURL: srv.URL + "/stratum/v1"}).Open(); err == nil {
+		t.Fatalf("open without credentials: expected error")
+	}
+}
+
+func TestRevokedTokenReplay(t *testing.T) {
+	var mu sync.Mutex
+	logins, valid := 0, ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/login/v1" {
+			logins++
+			valid = fmt.Sprintf("token-%d", logins)
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: valid, ExpiresIn: 3600, TokenType: "bearer"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "token revoked"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", TokenStore: NewMemoryTokenStore()}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	revoke := func() {
+		mu.Lock()
+		valid = "revoked"
+		mu.Unlock()
+	}
+
+	revoke()
+	if err := cl.Get("host/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if logins != 2 {
+		t.Fatalf("logins: got %d, want 2", logins)
+	}
+
+	revoke()
+	err := cl.Post("host/", map[string]string{"name": "db1"}, nil)
+	var eresp *ErrorResponse
+	if !errors.As(err, &eresp) || eresp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("post: got %v, want 401", err)
+	}
+
+	cl.ReplayPost = true
+	if err := cl.Post("host/", map[string]string{"name": "db1"}, nil); err != nil {
+		t.Fatalf("post with ReplayPost: %v", err)
+	}
+}
+
+func TestTokenPreRefresh(t *testing.T) {
+	var mu sync.Mutex
+	logins, expiresIn, fail := 0, 3600, false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/login/v1" {
+			if fail {
+				writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "down"})
+				return
+			}
+			logins++
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: fmt.Sprintf("token-%d", logins), ExpiresIn: expiresIn})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", RefreshWindow: time.Minute}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if until := cl.TokenValidUntil(); time.Until(until) < 59*time.Minute {
+		t.Fatalf("valid until: got %s", until)
+	}
+
+	get := func() string {
+		t.Helper()
+		if err := cl.Get("host/", nil); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		token, _ := cl.AccessToken()
+		return token
+	}
+
+	// a token close to expiry is refreshed before it is used
+	cl.mu.Lock()
+	cl.validUntil = time.Now().Add(30 * time.Second)
+	cl.mu.Unlock()
+	if token := get(); token != "token-2" {
+		t.Errorf("pre-refresh: got %s", token)
+	}
+
+	// a failed refresh keeps the token, and isn't retried
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	cl.mu.Lock()
+	cl.validUntil = time.Now().Add(30 * time.Second)
+	cl.mu.Unlock()
+	if token := get(); token != "token-2" || get() != "token-2" {
+		t.Errorf("failed pre-refresh: got %s", token)
+	}
+
+	// tokens living shorter than the window are refreshed once
+	mu.Lock()
+	fail, expiresIn = false, 10
+	mu.Unlock()
+	cl.mu.Lock()
+	cl.validUntil = time.Now().Add(30 * time.Second)
+	cl.mu.Unlock()
+	get()
+	get()
+	mu.Lock()
+	defer mu.Unlock()
+	if logins != 3 {
+		t.Errorf("logins: got %d, want 3", logins)
+	}
+}
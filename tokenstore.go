@@ -0,0 +1,181 @@
+package stratumclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredToken holds a bearer token and its expiry time.
+type StoredToken struct {
+	AccessToken string    `json:"access_token"`
+	ValidUntil  time.Time `json:"valid_until"`
+}
+
+// TokenStore persists tokens between client instances. Tokens are
+// stored per user and API server. Load returns nil without error if
+// no token is stored for the key.
+type TokenStore interface {
+	Load(key string) (*StoredToken, error)
+	Save(key string, token *StoredToken) error
+}
+
+// MemoryTokenStore holds tokens in memory. It is safe for concurrent
+// use and can be shared by several clients in the same process.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*StoredToken
+}
+
+// NewMemoryTokenStore returns an empty in-memory token store.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]*StoredToken)}
+}
+
+// Load returns the token stored for key.
+func (s *MemoryTokenStore) Load(key string) (*StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	stored := *t
+
+	return &stored, nil
+}
+
+// Save stores the token for key.
+func (s *MemoryTokenStore) Save(key string, token *StoredToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *token
+	s.tokens[key] = &stored
+
+	return nil
+}
+
+// FileTokenStore holds tokens as files in a directory, one file per
+// key, readable by the owner only.
+type FileTokenStore struct {
+	Dir string
+}
+
+// NewFileTokenStore returns a token store keeping its files in
+// dir. If dir is empty, a stratumclient directory in the user's
+// cache directory is used.
+func NewFileTokenStore(dir string) (*FileTokenStore, error) {
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cache, "stratumclient")
+	}
+
+	return &FileTokenStore{Dir: dir}, nil
+}
+
+// Load returns the token stored for key.
+func (s *FileTokenStore) Load(key string) (*StoredToken, error) {
+	content, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &StoredToken{}
+	if err := json.Unmarshal(content, t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Save stores the token for key. The file is written atomically.
+func (s *FileTokenStore) Save(key string, token *StoredToken) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.Dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// path returns the file name used for key.
+func (s *FileTokenStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// tokenKey returns the key identifying the client's tokens in a
+// token store.
+func (c *Client) tokenKey() string {
+	name := c.Username
+	if auth := c.authMethod(); auth != nil {
+		name = auth.Name()
+	}
+
+	return name + "@" + c.BaseURL
+}
+
+// loadToken picks up a valid token from the token store. It reports
+// whether a token was found.
+func (c *Client) loadToken() bool {
+	if c.TokenStore == nil {
+		return false
+	}
+
+	t, err := c.TokenStore.Load(c.tokenKey())
+	if err != nil {
+		c.debug("stratum token store load failed", "error", err)
+		return false
+	}
+	if t == nil || t.AccessToken == "" || t.AccessToken == c.revoked || t.AccessToken == c.token || !c.clock().Now().Before(t.ValidUntil) {
+		return false
+	}
+
+	c.token = t.AccessToken
+	c.validUntil = t.ValidUntil
+	c.debug("stratum token loaded from store", "username", c.Username, "valid_until", c.validUntil)
+
+	return true
+}
+
+// saveToken stores the client's token in the token store.
+func (c *Client) saveToken() {
+	if c.TokenStore == nil {
+		return
+	}
+
+	err := c.TokenStore.Save(c.tokenKey(), &StoredToken{AccessToken: c.token, ValidUntil: c.validUntil})
+	if err != nil {
+		c.debug("stratum token store save failed", "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package stratumclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTokenStoreReuse(t *testing.T) {
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/v1" {
+			logins++
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	}))
+	t.Cleanup(srv.Close)
+
+	store, err := NewFileTokenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("file store: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", TokenStore: store}
+		if err := cl.Open(); err != nil {
+			t.Fatalf("open %d: %v", i, err)
+		}
+		if err := cl.Get("platform/", nil); err != nil {
+			t.Fatalf("get %d: %v", i, err)
+		}
+	}
+	if logins != 1 {
+		t.Fatalf("logins: got %d, want 1", logins)
+	}
+
+	files, err := os.ReadDir(store.Dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("token files: got %d, want 1", len(files))
+	}
+	info, err := files[0].Info()
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("token file mode: got %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	s := NewMemoryTokenStore()
+	if tok, err := s.Load("x"); tok != nil || err != nil {
+		t.Fatalf("load empty: got %v %v", tok, err)
+	}
+
+	until := time.Now().Add(time.Hour)
+	if err := s.Save("x", &StoredToken{AccessToken: "a", ValidUntil: until}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	tok, err := s.Load("x")
+	if err != nil || tok.AccessToken != "a" || !tok.ValidUntil.Equal(until) {
+		t.Fatalf("load: got %v %v", tok, err)
+	}
+}
@@ -0,0 +1,126 @@
+package stratumclient
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPRequestDoer is the interface expected by HTTP clients generated
+// with oapi-codegen. The *http.Client returned by HTTPClient
+// satisfies it.
+type HTTPRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPClient returns an *http.Client which authorizes every request
+// with the client's bearer token, refreshing it when necessary, and
+// retries failed requests according to the client's Retry policy.
+// Requests share the client's rate limit and 429 throttling, and the
+// query policy given by Filters and QueryPolicy. It lets code
+// generated by oapi-codegen or openapi-generator against the Stratum
+// OpenAPI spec use this package for authentication. The client must
+// be opened with Open first.
+func (c *Client) HTTPClient() *http.Client {
+	return &http.Client{Transport: &authTransport{c: c}}
+}
+
+// authTransport is an http.RoundTripper adding authorization and
+// retries to requests.
+type authTransport struct {
+	c *Client
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.c.AccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.c.filters != nil || t.c.QueryPolicy != nil {
+		// the policy sees the query relative to the API prefix
+		query, err := t.c.applyPolicy(req.Method, t.relative(req.URL.Path)+"?"+req.URL.RawQuery)
+		if err != nil {
+			return nil, err
+		}
+		_, raw, _ := strings.Cut(query, "?")
+		req = req.Clone(req.Context())
+		req.URL.RawQuery = raw
+	}
+
+	base := t.c.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	policy := t.c.Retry
+	limiter := t.c.rateLimiter()
+	if policy == nil && limiter != nil {
+		policy = rateLimitRetryPolicy
+	}
+	for attempt := 1; ; attempt++ {
+		if err := limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		r := req.Clone(req.Context())
+		r.Header.Set("Authorization", "Bearer "+token)
+		if r.Header.Get("User-Agent") == "" {
+			r.Header.Set("User-Agent", t.c.userAgent())
+		}
+		for key, value := range t.c.DefaultHeaders {
+			if r.Header.Get(key) == "" {
+				r.Header.Set(key, value)
+			}
+		}
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		started := time.Now()
+		resp, err := base.RoundTrip(r)
+		if t.c.Metrics != nil {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			t.c.Metrics.ObserveRequest(r.Method, endpoint(t.relative(r.URL.Path)), status, time.Since(started))
+		}
+		if err != nil {
+			t.c.debug("stratum request", "method", r.Method, "url", r.URL.String(), "latency", time.Since(started), "headers", redactHeader(r.Header), "error", err)
+		} else {
+			t.c.debug("stratum request", "method", r.Method, "url", r.URL.String(), "status", resp.StatusCode, "latency", time.Since(started), "headers", redactHeader(r.Header))
+		}
+
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			limiter.throttle(resp)
+		}
+
+		rewindable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if !rewindable || !policy.retryable(r.Method, r.Header.Get(IdempotencyKeyHeader) != "", resp, err) || attempt >= policy.attempts() {
+			return resp, err
+		}
+
+		backoff := policy.backoff(attempt, resp, t.c.clock().Now())
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		t.c.debug("stratum retry", "method", r.Method, "url", r.URL.String(), "attempt", attempt, "backoff", backoff, "error", err)
+		if err := t.c.clock().Sleep(req.Context(), backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// relative returns a request path relative to the API prefix.
+func (t *authTransport) relative(path string) string {
+	return strings.TrimPrefix(strings.Trim(path, "/"), strings.Trim(t.c.apiPrefix(&callOptions{}), "/"))
+}
@@ -0,0 +1,70 @@
+package stratumclient
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient(t *testing.T) {
+	calls := 0
+	var bodies []string
+	cl, srv := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.Retry = &RetryPolicy{InitialBackoff: time.Millisecond}
+
+	var doer HTTPRequestDoer = cl.HTTPClient()
+	req, err := http.NewRequest("PUT", srv.URL+"/stratum/v1/platform/?where=id=1", strings.NewReader(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status: got %d", resp.StatusCode)
+	}
+	if calls != 2 || bodies[1] != `{"name":"x"}` {
+		t.Fatalf("calls %d, bodies %q", calls, bodies)
+	}
+}
+
+func TestHTTPClientRateLimit(t *testing.T) {
+	_, srv := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1", RateLimit: 50, RateBurst: 1}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	started := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := cl.HTTPClient().Get(srv.URL + "/stratum/v1/platform/")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		resp.Body.Close()
+	}
+	// the login used the burst, so each request waits 20ms
+	if elapsed := time.Since(started); elapsed < 70*time.Millisecond {
+		t.Fatalf("4 requests took %v, want at least 80ms", elapsed)
+	}
+}
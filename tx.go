@@ -0,0 +1,153 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Tx groups POST, PUT and DELETE calls which are applied atomically
+// by the transactional batch endpoint of the server, TxQuery. Calls
+// are queued with Queue and sent as one request by Commit. If any of
+// them fails, the server rolls back all of them. A Tx is done once
+// committed or rolled back, and must not be reused.
+type Tx struct {
+	client *Client
+	opts   []CallOption
+
+	mu   sync.Mutex
+	ops  []*Operation
+	done bool
+}
+
+// txOperation is an operation as sent to the transactional batch
+// endpoint.
+type txOperation struct {
+	Method string      `json:"method"`
+	Query  string      `json:"query"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// Begin starts a transaction on the client's TxQuery endpoint. The
+// call options apply to the commit request. The function returns the
+// transaction and an error if the client has no TxQuery.
+func (c *Client) Begin(opts ...CallOption) (*Tx, error) {
+	if c.TxQuery == "" {
+		return nil, fmt.Errorf("missing: TxQuery")
+	}
+
+	return &Tx{client: c, opts: opts}, nil
+}
+
+// Queue adds a POST, PUT or DELETE call to the transaction. The
+// result of the call is unmarshalled into resp on commit, if resp is
+// not nil. The function returns the queued operation, whose Err is
+// set if the commit fails, and an error if the method isn't supported
+// or the transaction is done.
+func (tx *Tx) Queue(method, query string, data, resp interface{}) (*Operation, error) {
+	method = strings.ToUpper(method)
+	switch method {
+	case "POST", "PUT", "DELETE":
+	default:
+		return nil, fmt.Errorf("tx: unsupported method %s", method)
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return nil, fmt.Errorf("tx: already committed or rolled back")
+	}
+	op := &Operation{Method: method, Query: query, Data: data, Resp: resp}
+	tx.ops = append(tx.ops, op)
+
+	return op, nil
+}
+
+// Commit sends the queued calls to TxQuery as a JSON array of
+// {"method", "query", "data"} objects, which the server applies
+// atomically, responding with a JSON array of the results of the
+// calls in order. The results are unmarshalled into the Resp of the
+// operations, and the cached entries of their tables purged, setting
+// the Err of operations whose result can't be decoded. If the commit
+// request fails, the server has applied none of the calls, and the
+// error is stored in the Err of each operation too. The function
+// returns an error.
+func (tx *Tx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return fmt.Errorf("tx: already committed or rolled back")
+	}
+	tx.done = true
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	applied, err := tx.commit()
+	if err != nil && !applied {
+		for _, op := range tx.ops {
+			op.Err = err
+		}
+	}
+
+	return err
+}
+
+// commit sends the queued calls and decodes their results. It
+// returns whether the server applied the calls, and an error.
+func (tx *Tx) commit() (bool, error) {
+	c := tx.client
+	ops := make([]*txOperation, len(tx.ops))
+	for i, op := range tx.ops {
+		query, err := c.applyPolicy(op.Method, op.Query)
+		if err != nil {
+			return false, fmt.Errorf("tx: operation %d: %w", i+1, err)
+		}
+		ops[i] = &txOperation{Method: op.Method, Query: strings.TrimLeft(query, "/"), Data: op.Data}
+	}
+
+	content, err := c.Call("POST", c.TxQuery, ops, tx.opts...)
+	if err != nil {
+		return false, fmt.Errorf("tx: commit: %w", err)
+	}
+	if c.Cache != nil {
+		for _, op := range tx.ops {
+			c.Cache.Purge(endpoint(op.Query) + "/")
+		}
+	}
+
+	var results []json.RawMessage
+	if err := json.Unmarshal(content, &results); err != nil {
+		return true, fmt.Errorf("tx: results: %v", err)
+	}
+	if len(results) == 0 {
+		// dry runs have no results
+		return true, nil
+	}
+	if len(results) != len(tx.ops) {
+		return true, fmt.Errorf("tx: got %d results for %d operations", len(results), len(tx.ops))
+	}
+	var errs []error
+	for i, op := range tx.ops {
+		if op.Resp == nil || len(results[i]) == 0 {
+			continue
+		}
+		if err := c.codec().Unmarshal(results[i], op.Resp); err != nil {
+			op.Err = fmt.Errorf("tx: operation %d: %v", i+1, err)
+			errs = append(errs, op.Err)
+		}
+	}
+
+	return true, errors.Join(errs...)
+}
+
+// Rollback discards the queued calls, none of which have been sent.
+// Rolling back a done transaction does nothing.
+func (tx *Tx) Rollback() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.done = true
+	tx.ops = nil
+}
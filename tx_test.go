@@ -0,0 +1,80 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTx(t *testing.T) {
+	var sent []*txOperation
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/tx/"):
+			sent = nil
+			json.NewDecoder(r.Body).Decode(&sent)
+			if len(sent) > 0 && sent[len(sent)-1].Query == "missing/" {
+				writeJSON(w, http.StatusConflict, &ErrorResponse{Message: "rolled back"})
+				return
+			}
+			writeJSON(w, http.StatusOK, []interface{}{
+				[]testPlatform{{ID: 7, Name: "web"}},
+				[]testPlatform{},
+			})
+		default:
+			writeJSON(w, http.StatusOK, []testPlatform{{ID: 1}})
+		}
+	})
+
+	if _, err := cl.Begin(); err == nil {
+		t.Fatal("begin without TxQuery: no error")
+	}
+	cl.TxQuery = "tx/"
+	cl.Cache = NewMemoryCache(time.Minute)
+	cl.Get("platform/", nil)
+
+	tx, err := cl.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	var created []testPlatform
+	if _, err := tx.Queue("post", "platform/", map[string]string{"name": "web"}, &created); err != nil {
+		t.Fatalf("queue: %v", err)
+	}
+	tx.Queue("DELETE", "platform/?where=id=1", nil, nil)
+	if _, err := tx.Queue("GET", "platform/", nil, nil); err == nil {
+		t.Error("queue GET: no error")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if len(sent) != 2 || sent[0].Method != "POST" || sent[1].Query != "platform/?where=id=1" {
+		t.Errorf("sent: got %+v", sent)
+	}
+	if len(created) != 1 || created[0].ID != 7 {
+		t.Errorf("result: got %+v", created)
+	}
+	if _, ok := cl.Cache.Get("platform/"); ok {
+		t.Error("cache not purged")
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("second commit: no error")
+	}
+
+	tx, _ = cl.Begin()
+	a, _ := tx.Queue("PUT", "platform/?where=id=7", map[string]string{"name": "db"}, nil)
+	b, _ := tx.Queue("DELETE", "missing/", nil, nil)
+	if err := tx.Commit(); StatusCode(err) != http.StatusConflict || a.Err == nil || b.Err == nil {
+		t.Errorf("failed commit: got %v, operations %v and %v", err, a.Err, b.Err)
+	}
+
+	tx, _ = cl.Begin()
+	tx.Queue("DELETE", "platform/?where=id=7", nil, nil)
+	tx.Rollback()
+	sent = nil
+	if err := tx.Commit(); err == nil || sent != nil {
+		t.Errorf("commit after rollback: got %v, sent %+v", err, sent)
+	}
+}
@@ -0,0 +1,149 @@
+package stratumclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Upload is post data read from Body while the request is sent, for
+// payloads too large to hold in memory and for file uploads. Give
+// an Upload as the post data of Post, Put, Call and friends. Post
+// data given as a plain io.Reader is sent as an Upload with the
+// default content type and unknown length.
+// ContentType defaults to application/json. ContentLength is the
+// length of Body, or 0 if unknown, in which case the body is sent
+// chunked. Body is closed when the call returns if it is an
+// io.Closer. The client retries a failed upload only if Body is an
+// io.Seeker it can rewind.
+type Upload struct {
+	Body          io.Reader
+	ContentType   string
+	ContentLength int64
+}
+
+// NewFileUpload returns an upload of the named file with the given
+// content type. The function returns the upload and an error.
+func NewFileUpload(name, contentType string) (*Upload, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		f.Close()
+		return nil, fmt.Errorf("upload %s: not a regular file", name)
+	}
+
+	return &Upload{Body: f, ContentType: contentType, ContentLength: fi.Size()}, nil
+}
+
+// close closes the body if it is an io.Closer.
+func (u *Upload) close() {
+	if c, ok := u.Body.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// payload is the body of an API call, which may be sent once per
+// attempt.
+type payload struct {
+	data     []byte
+	plain    []byte
+	encoding string
+	upload   *Upload
+	sent     bool
+	start    int64
+}
+
+// replayable reports whether the body can be sent again.
+func (p *payload) replayable() bool {
+	if p.upload == nil {
+		return true
+	}
+	_, ok := p.upload.Body.(io.Seeker)
+
+	return ok
+}
+
+// reader returns the body for the next attempt, rewinding an upload
+// sent before. The transport must not close the upload body, so it
+// is returned without its Close method.
+func (p *payload) reader() (io.Reader, error) {
+	if p.upload == nil {
+		return bytes.NewReader(p.data), nil
+	}
+
+	seeker, ok := p.upload.Body.(io.Seeker)
+	switch {
+	case p.sent && !ok:
+		return nil, fmt.Errorf("upload body can't be sent again")
+	case p.sent:
+		if _, err := seeker.Seek(p.start, io.SeekStart); err != nil {
+			return nil, err
+		}
+	case ok:
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		p.start = start
+	}
+	p.sent = true
+
+	return io.NopCloser(p.upload.Body), nil
+}
+
+// length returns the length of the body, or -1 if unknown.
+func (p *payload) length() int64 {
+	if p.upload == nil {
+		return int64(len(p.data))
+	}
+	if p.upload.ContentLength <= 0 {
+		return -1
+	}
+
+	return p.upload.ContentLength
+}
+
+// contentType returns the content type of the body.
+func (p *payload) contentType() string {
+	if p.upload != nil && p.upload.ContentType != "" {
+		return p.upload.ContentType
+	}
+
+	return "application/json"
+}
+
+// compress gzip compresses the post data of the body, keeping the
+// uncompressed data for servers rejecting compressed bodies.
+func (p *payload) compress() error {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := gz.Write(p.data); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	p.plain = p.data
+	p.data = b.Bytes()
+	p.encoding = "gzip"
+
+	return nil
+}
+
+// uncompress restores the post data compressed by compress.
+func (p *payload) uncompress() {
+	if p.encoding != "" {
+		p.data = p.plain
+		p.plain = nil
+		p.encoding = ""
+	}
+}
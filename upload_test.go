@@ -0,0 +1,142 @@
+package stratumclient
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpload(t *testing.T) {
+	var calls int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "id,name\n1,db1\n" {
+			t.Errorf("body: got %q", body)
+		}
+		if got := r.Header.Get("Content-Type"); got != "text/csv" {
+			t.Errorf("Content-Type: got %q, want text/csv", got)
+		}
+		if r.ContentLength != int64(len(body)) {
+			t.Errorf("Content-Length: got %d, want %d", r.ContentLength, len(body))
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			writeJSON(w, http.StatusServiceUnavailable, &ErrorResponse{Message: "busy"})
+			return
+		}
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.Retry = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+
+	name := filepath.Join(t.TempDir(), "hosts.csv")
+	if err := os.WriteFile(name, []byte("id,name\n1,db1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	up, err := NewFileUpload(name, "text/csv")
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := cl.Put("host/import", up, nil); err != nil {
+		t.Fatalf("put file: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("attempts: got %d, want 2", got)
+	}
+	if _, err := up.Body.(*os.File).Stat(); err == nil {
+		t.Fatal("upload file not closed")
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	reader := &Upload{Body: io.MultiReader(strings.NewReader("id,name\n1,db1\n")), ContentType: "text/csv", ContentLength: 14}
+	if err := cl.Put("host/import", reader, nil); err == nil {
+		t.Fatal("put reader: retried an upload which can't be rewound")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("attempts: got %d, want 1", got)
+	}
+}
+
+func TestReaderBody(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != -1 || len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+			t.Errorf("body: got length %d, encoding %v, want chunked", r.ContentLength, r.TransferEncoding)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type: got %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		writeJSON(w, http.StatusOK, []interface{}{map[string]int{"bytes": len(body)}})
+	})
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("["))
+		for i := 0; i < 1000; i++ {
+			if i > 0 {
+				pw.Write([]byte(","))
+			}
+			pw.Write([]byte(`{"name":"host"}`))
+		}
+		pw.Write([]byte("]"))
+		pw.Close()
+	}()
+
+	var rows []map[string]int
+	if err := cl.Post("host/", pr, &rows); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["bytes"] != 2+1000*15+999 {
+		t.Fatalf("post: got %v", rows)
+	}
+}
+
+func TestRequestCompression(t *testing.T) {
+	var encodings []string
+	var names []string
+	reject := false
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		enc := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, enc)
+		if enc == "gzip" && reject {
+			writeJSON(w, http.StatusUnsupportedMediaType, &ErrorResponse{Message: "unsupported"})
+			return
+		}
+		var body io.Reader = r.Body
+		if enc == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip: %v", err)
+				return
+			}
+			body = gz
+		}
+		var row map[string]string
+		json.NewDecoder(body).Decode(&row)
+		names = append(names, row["name"])
+		writeJSON(w, http.StatusCreated, []interface{}{})
+	})
+	cl.CompressThreshold = 100
+
+	long := strings.Repeat("x", 200)
+	cl.Post("platform/", map[string]string{"name": "short"}, nil)
+	if err := cl.Post("platform/", map[string]string{"name": long}, nil); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	reject = true
+	if err := cl.Post("platform/", map[string]string{"name": long}, nil); err != nil {
+		t.Fatalf("post rejected compressed: %v", err)
+	}
+
+	if got := strings.Join(encodings, ","); got != ",gzip,gzip," {
+		t.Errorf("encodings: got %q", got)
+	}
+	if len(names) != 3 || names[0] != "short" || names[1] != long || names[2] != long {
+		t.Errorf("names: got %q", names)
+	}
+}
@@ -0,0 +1,72 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Upsert will insert data as a row of table, or update the existing
+// row having the same values in the key columns. The row is looked up
+// with a GET call, and then updated with PUT or inserted with POST,
+// with the affected row decoded into resp. Since the lookup and the
+// write are separate calls, a concurrent insert of the same key can
+// still make the POST fail on a unique constraint. The function
+// returns true if a row was inserted, and an error.
+func (c *Client) Upsert(table string, keyColumns []string, data interface{}, resp interface{}, opts ...CallOption) (bool, error) {
+	table = strings.Trim(table, "/")
+	if len(keyColumns) == 0 {
+		return false, fmt.Errorf("missing: key columns")
+	}
+
+	row, err := rowObject(data)
+	if err != nil {
+		return false, fmt.Errorf("upsert %s: %v", table, err)
+	}
+	id, err := NewImportID(table, row, keyColumns...)
+	if err != nil {
+		return false, fmt.Errorf("upsert %s: %v", table, err)
+	}
+
+	lookup := &Query{Table: table, Select: keyColumns, Where: id.Where()}
+	var existing []json.RawMessage
+	if err := c.Get(lookup.String(), &existing, opts...); err != nil {
+		return false, fmt.Errorf("upsert %s: lookup: %w", table, err)
+	}
+
+	write := &Query{Table: table, Params: []*Param{{Key: "returning", Value: "*"}}}
+	switch len(existing) {
+	case 0:
+		return true, c.Post(write.String(), data, resp, opts...)
+	case 1:
+		write.Where = id.Where()
+		return false, c.Put(write.String(), data, resp, opts...)
+	default:
+		return false, fmt.Errorf("upsert %s: key %s matches %d rows", table, id.Where(), len(existing))
+	}
+}
+
+// rowObject returns data, which is a struct, map or encoded JSON, as
+// a JSON object with numbers kept as json.Number.
+func rowObject(data interface{}) (map[string]interface{}, error) {
+	content, ok := data.([]byte)
+	if !ok {
+		var err error
+		if content, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var row map[string]interface{}
+	if err := dec.Decode(&row); err != nil {
+		return nil, fmt.Errorf("data must be a JSON object: %v", err)
+	}
+	if row == nil {
+		return nil, fmt.Errorf("data must be a JSON object")
+	}
+
+	return row, nil
+}
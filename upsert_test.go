@@ -0,0 +1,56 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	hosts := map[string]map[string]interface{}{}
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		where := r.URL.Query().Get("where")
+		switch r.Method {
+		case http.MethodGet:
+			if where != "name=db1" {
+				t.Errorf("lookup where: got %q, want name=db1", where)
+			}
+			rows := []interface{}{}
+			if h, ok := hosts["db1"]; ok {
+				rows = append(rows, h)
+			}
+			writeJSON(w, http.StatusOK, rows)
+		case http.MethodPost, http.MethodPut:
+			if r.Method == http.MethodPut && where != "name=db1" {
+				t.Errorf("update where: got %q, want name=db1", where)
+			}
+			var row map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&row); err != nil {
+				t.Errorf("decode: %v", err)
+			}
+			hosts["db1"] = row
+			writeJSON(w, http.StatusOK, []interface{}{row})
+		}
+	})
+
+	for i, want := range []bool{true, false} {
+		var resp []map[string]interface{}
+		inserted, err := cl.Upsert("host", []string{"name"}, map[string]interface{}{"name": "db1", "rev": i}, &resp)
+		if err != nil {
+			t.Fatalf("upsert %d: %v", i, err)
+		}
+		if inserted != want {
+			t.Fatalf("upsert %d: inserted %t, want %t", i, inserted, want)
+		}
+		if len(resp) != 1 || resp[0]["rev"] != float64(i) {
+			t.Fatalf("upsert %d: resp %v", i, resp)
+		}
+	}
+
+	if _, err := cl.Upsert("host", []string{"id"}, map[string]interface{}{"name": "db1"}, nil); err == nil {
+		t.Fatal("upsert: missing key column gave no error")
+	}
+	if _, err := cl.Upsert("host", []string{"name"}, []int{1}, nil); err == nil {
+		t.Fatal("upsert: array data gave no error")
+	}
+}
@@ -0,0 +1,141 @@
+package stratumclient
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// defaultMaxURLLength is the MaxURLLength used when it isn't set. It
+// stays below the 8 KiB request line limit common to proxies and
+// servers.
+const defaultMaxURLLength = 8000
+
+// URLTooLongError is returned for a GET call whose URL exceeds the
+// client MaxURLLength and can't be split into shorter calls.
+type URLTooLongError struct {
+	Length int
+	Max    int
+}
+
+// Error implements the error interface.
+func (e *URLTooLongError) Error() string {
+	return fmt.Sprintf("request URL of %d bytes exceeds %d bytes", e.Length, e.Max)
+}
+
+// maxURLLength returns the maximum URL length of the client, or 0 if
+// URL lengths aren't checked.
+func (c *Client) maxURLLength() int {
+	switch {
+	case c.MaxURLLength < 0:
+		return 0
+	case c.MaxURLLength == 0:
+		return defaultMaxURLLength
+	}
+
+	return c.MaxURLLength
+}
+
+// urlLength returns the length of the URL of a call.
+func (c *Client) urlLength(query string, o *callOptions) int {
+	return len(c.url.String()) + len(c.apiPrefix(o)) + 2 + len(o.withParams(query))
+}
+
+// splitCall performs a GET call whose URL is too long as several
+// calls, each selecting a share of the alternatives of the where
+// clause, and returns the rows of all calls as one JSON array. The
+// where clause must be an Or of equality conditions on one column,
+// like from Column.In, or an And holding one such Or, so no row
+// matches more than one call and the rows are the same as of the
+// unsplit call. The rows are ordered within each call only, and
+// queries with limit or offset can't be split.
+func (c *Client) splitCall(query string, max int, opts []CallOption) ([]byte, error) {
+	o := newCallOptions(opts)
+	tooLong := &URLTooLongError{Length: c.urlLength(query, o), Max: max}
+
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if q.Get("limit") != "" || q.Get("offset") != "" {
+		return nil, tooLong
+	}
+
+	// find the alternatives to split, and the conditions they share
+	var alts Or
+	var shared And
+	switch where := q.Where.(type) {
+	case Or:
+		alts = where
+	case And:
+		for _, e := range where {
+			if or, ok := e.(Or); ok && alts == nil {
+				alts = or
+			} else {
+				shared = append(shared, e)
+			}
+		}
+	}
+	alts = disjointAlternatives(alts)
+	if len(alts) < 2 {
+		return nil, tooLong
+	}
+
+	part := func(alts Or) string {
+		p := *q
+		p.Where = All(append(append(And{}, shared...), Any(alts...))...)
+		return p.String()
+	}
+
+	var parts []string
+	for start := 0; start < len(alts); {
+		end := start + 1
+		if c.urlLength(part(alts[start:end]), o) > max {
+			return nil, tooLong
+		}
+		for end < len(alts) && c.urlLength(part(alts[start:end+1]), o) <= max {
+			end++
+		}
+		parts = append(parts, part(alts[start:end]))
+		start = end
+	}
+	c.debug("stratum split query", "parts", len(parts), "length", tooLong.Length)
+
+	var rows []json.RawMessage
+	for _, p := range parts {
+		content, err := c.Call("GET", p, nil, opts...)
+		if err != nil {
+			return nil, err
+		}
+		content, _ = unwrapEnvelope(content)
+		var partRows []json.RawMessage
+		if err := json.Unmarshal(content, &partRows); err != nil {
+			return nil, err
+		}
+		rows = append(rows, partRows...)
+	}
+	if rows == nil {
+		rows = []json.RawMessage{}
+	}
+
+	return json.Marshal(rows)
+}
+
+// disjointAlternatives returns the alternatives with repeated ones
+// dropped, if they are equality conditions on the same column, which
+// no row matches more than one of. Otherwise it returns nil.
+func disjointAlternatives(alts Or) Or {
+	var ret Or
+	seen := make(map[string]bool)
+	for _, e := range alts {
+		cond, ok := e.(*Cond)
+		if !ok || cond.Op != "=" || cond.Column != alts[0].(*Cond).Column {
+			return nil
+		}
+		if key := cond.String(); !seen[key] {
+			seen[key] = true
+			ret = append(ret, cond)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,74 @@
+package stratumclient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestURLLength(t *testing.T) {
+	var lengths []int
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		lengths = append(lengths, len(r.URL.String()))
+		where, err := ParseWhere(r.URL.Query().Get("where"))
+		if err != nil {
+			t.Errorf("where: %v", err)
+		}
+		var rows []map[string]string
+		for _, c := range Conds(where) {
+			if c.Column == "id" {
+				rows = append(rows, map[string]string{"id": c.Value})
+			}
+		}
+		// every part returns an identical row too, which must be
+		// kept like in an unsplit call
+		rows = append(rows, map[string]string{"site": "osl"})
+		writeJSON(w, http.StatusOK, rows)
+	})
+	cl.MaxURLLength = 200
+
+	var ids []interface{}
+	for i := 1; i <= 50; i++ {
+		ids = append(ids, i)
+	}
+	q := &Query{Table: "host", Where: All(W("site").Eq("osl"), W("id").In(ids...))}
+	var rows []map[string]string
+	if err := cl.Get(q.String(), &rows); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(lengths) < 2 {
+		t.Fatalf("parts: got %d", len(lengths))
+	}
+	for _, n := range lengths {
+		if n > 200 {
+			t.Errorf("part URL of %d bytes exceeds 200", n)
+		}
+	}
+	if len(rows) != 50+len(lengths) || rows[0]["id"] != "1" || rows[len(rows)-1]["site"] != "osl" {
+		t.Errorf("rows: got %d, %v", len(rows), rows)
+	}
+
+	// alternatives which may match the same row aren't split
+	var tooLong *URLTooLongError
+	var patterns []Expr
+	for i := 1; i <= 50; i++ {
+		patterns = append(patterns, W("name").Like("db"+strconv.Itoa(i)))
+	}
+	lengths = nil
+	overlap := &Query{Table: "host", Where: Any(patterns...)}
+	if err := cl.Get(overlap.String(), &rows); !errors.As(err, &tooLong) || len(lengths) != 0 {
+		t.Errorf("get overlapping: got %v, %d calls, want URLTooLongError", err, len(lengths))
+	}
+
+	q.Set("limit", strconv.Itoa(10))
+	if err := cl.Get(q.String(), &rows); !errors.As(err, &tooLong) {
+		t.Errorf("get with limit: got %v, want URLTooLongError", err)
+	}
+
+	cl.MaxURLLength = -1
+	lengths = nil
+	if err := cl.Get(q.String(), &rows); err != nil || len(lengths) != 1 {
+		t.Errorf("get without check: got %v, %d calls", err, len(lengths))
+	}
+}
@@ -0,0 +1,117 @@
+package stratumclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidatePayload checks that the columns of post data for table,
+// given as a row object or an array of row objects, are columns of
+// the table in the API schema, and that none of them is read-only.
+// Misspelled columns, like guest_os for guestos, are reported with
+// the closest column name. The schema is loaded on first use. Tables
+// missing from the schema are not checked. The function returns an
+// error for the first bad column, otherwise nil.
+func (c *Client) ValidatePayload(table string, data interface{}) error {
+	content, ok := data.([]byte)
+	if !ok {
+		var err error
+		if content, err = c.codec().Marshal(data); err != nil {
+			return err
+		}
+	}
+
+	s, err := c.Schema()
+	if err != nil {
+		return err
+	}
+	t, ok := s.Tables[strings.Trim(table, "/")]
+	if !ok {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("post data is not valid JSON: %v", err)
+	}
+	rows, ok := v.([]interface{})
+	if !ok {
+		rows = []interface{}{v}
+	}
+	for _, r := range rows {
+		row, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("post data for %s must hold row objects", t.Name)
+		}
+		for _, col := range sortedKeys(row) {
+			if err := t.checkWritable(col); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkWritable returns an error if column isn't a writable column
+// of the table.
+func (t *TableSchema) checkWritable(column string) error {
+	cs, ok := t.Columns[column]
+	if !ok {
+		if guess := t.closestColumn(column); guess != "" {
+			return fmt.Errorf("unknown column %s in %s, did you mean %s?", column, t.Name, guess)
+		}
+		return fmt.Errorf("unknown column %s in %s", column, t.Name)
+	}
+	if cs.ReadOnly {
+		return fmt.Errorf("column %s in %s is read-only", column, t.Name)
+	}
+
+	return nil
+}
+
+// closestColumn returns the column name closest to name, or "" if
+// none is within two edits or equal apart from underscores and case.
+func (t *TableSchema) closestColumn(name string) string {
+	fold := func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	}
+
+	best, bestDist := "", 3
+	for _, col := range t.ColumnNames() {
+		if fold(col) == fold(name) {
+			return col
+		}
+		if d := editDistance(col, name); d < bestDist {
+			best, bestDist = col, d
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
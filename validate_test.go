@@ -0,0 +1,58 @@
+package stratumclient
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidatePayload(t *testing.T) {
+	var posts int32
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.SetSchema(&Schema{Tables: map[string]*TableSchema{
+		"platform": {Name: "platform", Columns: map[string]*ColumnSchema{
+			"id":      {Name: "id", Type: "integer", ReadOnly: true},
+			"name":    {Name: "name", Type: "string"},
+			"guestos": {Name: "guestos", Type: "string"},
+		}},
+	}})
+	cl.ValidatePosts = true
+
+	for _, tt := range []struct {
+		data interface{}
+		err  string
+	}{
+		{map[string]string{"name": "Linux", "guestos": "linux"}, ""},
+		{[]byte(`[{"name": "Linux"}, {"guest_os": "linux"}]`), "did you mean guestos"},
+		{map[string]string{"nmae": "Linux"}, "did you mean name"},
+		{map[string]string{"vendor": "acme"}, "unknown column vendor in platform"},
+		{map[string]int{"id": 3}, "read-only"},
+	} {
+		err := cl.Post("platform/", tt.data, nil)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("post %v: got %v, want %q", tt.data, err, tt.err)
+		}
+	}
+	if posts != 1 {
+		t.Errorf("posts sent: got %d, want 1", posts)
+	}
+
+	if err := cl.Post("host/", map[string]string{"anything": "x"}, nil); err != nil {
+		t.Errorf("post to table missing from schema: %v", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		d    int
+	}{{"", "abc", 3}, {"name", "nmae", 2}, {"guestos", "guestos", 0}, {"kitten", "sitting", 3}} {
+		if d := editDistance(tt.a, tt.b); d != tt.d {
+			t.Errorf("%s, %s: got %d, want %d", tt.a, tt.b, d, tt.d)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package stratumclient
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// V returns a clone of c which addresses another version of the API,
+// like c.V("v2").Get("host/", &hosts), for migrating between server
+// versions gradually. The version replaces the last element of the
+// BaseURL path, so a client of https://server/stratum/v1 gets
+// https://server/stratum/v2. The clone shares the token and the
+// connection pool of c, as described for Clone.
+func (c *Client) V(version string) *Client {
+	n := c.Clone()
+	n.version = strings.Trim(version, "/")
+	if u, err := url.Parse(c.BaseURL); err == nil {
+		u.Path = versionPath(u.Path, n.version)
+		n.BaseURL = u.String()
+	}
+
+	return n
+}
+
+// WithVersion makes the call address the given version of the API,
+// like V does for all calls of a client.
+func WithVersion(version string) CallOption {
+	return func(o *callOptions) {
+		o.version = strings.Trim(version, "/")
+	}
+}
+
+// apiVersion returns the API version addressed by a call, or "" for
+// the version of the BaseURL.
+func (c *Client) apiVersion(o *callOptions) string {
+	if o.version != "" {
+		return o.version
+	}
+
+	return c.version
+}
+
+// apiPrefix returns the API path prefix of a call.
+func (c *Client) apiPrefix(o *callOptions) string {
+	if v := c.apiVersion(o); v != "" {
+		return versionPath(c.prefix, v)
+	}
+
+	return c.prefix
+}
+
+// versionPath returns p with its last element replaced by version.
+func versionPath(p, version string) string {
+	return path.Join(path.Dir(strings.TrimSuffix(p, "/")), version)
+}
@@ -0,0 +1,40 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	var paths []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.Cache = NewMemoryCache(0)
+
+	v2 := cl.V("v2")
+	for _, call := range []func() error{
+		func() error { return cl.Get("host/", nil) },
+		func() error { return v2.Get("host/", nil) },
+		func() error { return cl.Get("host/", nil, WithVersion("v3")) },
+		func() error { return v2.Get("host/", nil, WithVersion("v1")) },
+	} {
+		if err := call(); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+
+	want := []string{"//stratum/v1/host/", "//stratum/v2/host/", "//stratum/v3/host/"}
+	if len(paths) != len(want) {
+		t.Fatalf("paths: got %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("path %d: got %s, want %s", i, paths[i], want[i])
+		}
+	}
+	if v2.BaseURL != cl.BaseURL[:len(cl.BaseURL)-2]+"v2" {
+		t.Errorf("BaseURL: got %s", v2.BaseURL)
+	}
+}
@@ -0,0 +1,68 @@
+package stratumclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Warmup makes sure the client holds a valid token and pre-establishes
+// up to n connections (at most MaxIdleConnsPerHost) to the API
+// server, so the TLS handshakes are done before the first user-facing
+// requests. The connections are opened with concurrent HEAD requests
+// to the API base path, whose response status is ignored. Each
+// request is bounded by the client Timeout. If PreloadSchema is set,
+// the schema is loaded too. The function returns an error if n is not
+// positive, a connection couldn't be established or the schema
+// couldn't be loaded.
+func (c *Client) Warmup(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid connection count: %d", n)
+	}
+	if _, err := c.AccessToken(); err != nil {
+		return err
+	}
+	if max := c.maxIdleConnsPerHost(); n > max {
+		n = max
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout)*time.Second)
+	defer cancel()
+
+	target := c.url.String() + c.apiPrefix(&callOptions{}) + "/"
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, "HEAD", target, nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			req.Header.Set("User-Agent", c.userAgent())
+			resp, err := c.client.Do(req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	if c.PreloadSchema {
+		if _, err := c.Schema(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+package stratumclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	var conns, heads int32
+	arrived := make(chan struct{})
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/login/v1":
+			writeJSON(w, http.StatusOK, &LoginResponse{AccessToken: "token", ExpiresIn: 3600})
+		case r.Method == "HEAD":
+			// Hold every probe until all of them have arrived,
+			// so each one needs a connection of its own.
+			if atomic.AddInt32(&heads, 1) == 4 {
+				close(arrived)
+			}
+			select {
+			case <-arrived:
+			case <-time.After(2 * time.Second):
+				w.WriteHeader(http.StatusRequestTimeout)
+			}
+		default:
+			writeJSON(w, http.StatusOK, []interface{}{})
+		}
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	cl := &Client{Username: "user", Password: "secret", BaseURL: srv.URL + "/stratum/v1"}
+	if err := cl.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { cl.Close() })
+
+	if err := cl.Warmup(0); err == nil {
+		t.Fatalf("warmup 0: expected error")
+	}
+
+	if err := cl.Warmup(4); err != nil {
+		t.Fatalf("warmup: %v", err)
+	}
+	warmed := atomic.LoadInt32(&conns)
+	if warmed < 4 {
+		t.Fatalf("connections: got %d, want at least 4", warmed)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cl.Get("platform/", nil); err != nil {
+				t.Errorf("get: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&conns); got != warmed {
+		t.Fatalf("connections after calls: got %d, want %d", got, warmed)
+	}
+}
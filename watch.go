@@ -0,0 +1,283 @@
+package stratumclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventKind tells how a watched row changed.
+type EventKind int
+
+// The kinds of change of a watched row.
+const (
+	EventAdded EventKind = iota + 1
+	EventUpdated
+	EventRemoved
+)
+
+// Stringer function for EventKind fmt.String() compliant.
+func (k EventKind) String() string {
+	switch k {
+	case EventAdded:
+		return "added"
+	case EventUpdated:
+		return "updated"
+	case EventRemoved:
+		return "removed"
+	}
+
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// WatchEvent is a change of a row matched by a watched query. Row is
+// the row as it is now, or as it was last seen if it was removed, and
+// Old is the row before an update. Events with Err set report a
+// failed poll, and the watch goes on with the next poll.
+type WatchEvent struct {
+	Kind EventKind
+	Key  string
+	Row  Row
+	Old  Row
+	Err  error
+}
+
+// Watcher polls a query and reports the rows added to, updated in
+// and removed from its result, compared by the Key column, for sync
+// daemons following changes in Stratum. The query is fetched in full
+// at every Interval, unless UseHistory is set, in which case the
+// audit table of the query table, see HistoryTable, serves as change
+// feed: only the rows changed since the last poll are fetched again.
+// The row_id column of the audit table must then hold the Key of the
+// rows.
+// The first poll reports all rows as added, unless SkipInitial is
+// set. The Client Clock paces the polls.
+type Watcher struct {
+	Client *Client
+	Query  string
+	// Key is the primary key column of the rows. Default id.
+	Key string
+	// Interval is the time between polls. Default 30s.
+	Interval    time.Duration
+	UseHistory  bool
+	SkipInitial bool
+	Options     []CallOption
+}
+
+// Watch returns a channel with the changes of the rows of query,
+// polled every interval by a Watcher with the default settings. The
+// channel is closed once ctx is done.
+func (c *Client) Watch(ctx context.Context, query string, interval time.Duration, opts ...CallOption) <-chan *WatchEvent {
+	w := &Watcher{Client: c, Query: query, Interval: interval, Options: opts}
+
+	return w.Events(ctx)
+}
+
+// Events starts watching and returns the channel the changes are
+// sent on. The channel is closed once ctx is done.
+func (w *Watcher) Events(ctx context.Context) <-chan *WatchEvent {
+	ch := make(chan *WatchEvent)
+	go w.run(ctx, ch)
+
+	return ch
+}
+
+// run polls the query until ctx is done.
+func (w *Watcher) run(ctx context.Context, ch chan<- *WatchEvent) {
+	defer close(ch)
+	send := func(e *WatchEvent) bool {
+		select {
+		case ch <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	opts := append([]CallOption{WithContext(ctx)}, w.Options...)
+
+	var rows map[string]Row
+	since := ""
+	for {
+		var events []*WatchEvent
+		var err error
+		if rows == nil {
+			since, err = w.since(opts)
+			if err == nil {
+				var current map[string]Row
+				if current, err = w.fetch(nil, opts); err == nil {
+					events = diffRows(make(map[string]Row), current, nil)
+					rows = current
+				}
+			}
+			if w.SkipInitial {
+				events = nil
+			}
+		} else if w.UseHistory {
+			events, since, err = w.pollHistory(rows, since, opts)
+		} else {
+			var current map[string]Row
+			if current, err = w.fetch(nil, opts); err == nil {
+				events = diffRows(rows, current, nil)
+				rows = current
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			events = []*WatchEvent{{Err: err}}
+		}
+		for _, e := range events {
+			if !send(e) {
+				return
+			}
+		}
+
+		if err := w.Client.clock().Sleep(ctx, interval); err != nil {
+			return
+		}
+	}
+}
+
+// key returns the key column of the rows.
+func (w *Watcher) key() string {
+	if w.Key == "" {
+		return "id"
+	}
+
+	return w.Key
+}
+
+// fetch returns the rows of the query by key, limited to the rows
+// with the given keys if keys is not nil.
+func (w *Watcher) fetch(keys []interface{}, opts []CallOption) (map[string]Row, error) {
+	q, err := ParseQuery(w.Query)
+	if err != nil {
+		return nil, err
+	}
+	if keys != nil {
+		q.Where = All(q.Where, W(w.key()).In(keys...))
+	}
+
+	result, err := w.Client.GetRows(q.String(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]Row, len(result))
+	for _, row := range result {
+		if !row.Has(w.key()) {
+			return nil, fmt.Errorf("watch %s: row without key column %s", q.Table, w.key())
+		}
+		rows[row.String(w.key())] = row
+	}
+
+	return rows, nil
+}
+
+// since returns the time of the latest change in the audit table of
+// the query table, or "" if the history isn't used or has no
+// changes.
+func (w *Watcher) since(opts []CallOption) (string, error) {
+	if !w.UseHistory {
+		return "", nil
+	}
+	q, err := ParseQuery(w.Query)
+	if err != nil {
+		return "", err
+	}
+
+	hq := &Query{Table: w.Client.historyTable(q.Table), Select: []string{"changed_at"}, OrderBy: []string{"-changed_at"}}
+	hq.Set("limit", "1")
+	changes, err := w.Client.GetRows(hq.String(), opts...)
+	if err != nil || len(changes) == 0 {
+		return "", err
+	}
+
+	return changes[0].String("changed_at"), nil
+}
+
+// pollHistory fetches the rows changed in the audit table since the
+// given time again, and returns their changes and the time of the
+// latest change.
+func (w *Watcher) pollHistory(rows map[string]Row, since string, opts []CallOption) ([]*WatchEvent, string, error) {
+	q, err := ParseQuery(w.Query)
+	if err != nil {
+		return nil, since, err
+	}
+
+	// changes made at the time of the last poll are fetched again,
+	// since more may have been made at that time after the poll
+	hq := &Query{Table: w.Client.historyTable(q.Table), Select: []string{"row_id", "changed_at"}, OrderBy: []string{"changed_at"}}
+	if since != "" {
+		hq.Where = &Cond{Column: "changed_at", Op: ">=", Value: since}
+	}
+	changes, err := w.Client.GetRows(hq.String(), opts...)
+	if err != nil || len(changes) == 0 {
+		return nil, since, err
+	}
+
+	var keys []interface{}
+	seen := make(map[string]bool)
+	for _, ch := range changes {
+		if id := ch.String("row_id"); !seen[id] {
+			seen[id] = true
+			keys = append(keys, id)
+		}
+	}
+	current, err := w.fetch(keys, opts)
+	if err != nil {
+		return nil, since, err
+	}
+
+	events := diffRows(rows, current, seen)
+	for key := range seen {
+		if row, ok := current[key]; ok {
+			rows[key] = row
+		} else {
+			delete(rows, key)
+		}
+	}
+
+	return events, changes[len(changes)-1].String("changed_at"), nil
+}
+
+// diffRows returns the changes from the old to the new rows, in key
+// order. If only is not nil, the changes of the keys in only are
+// returned, and the new rows hold those keys only.
+func diffRows(old, cur map[string]Row, only map[string]bool) []*WatchEvent {
+	var events []*WatchEvent
+	for _, key := range sortedKeys(cur) {
+		row := cur[key]
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			events = append(events, &WatchEvent{Kind: EventAdded, Key: key, Row: row})
+		case !sameRow(prev, row):
+			events = append(events, &WatchEvent{Kind: EventUpdated, Key: key, Row: row, Old: prev})
+		}
+	}
+	for _, key := range sortedKeys(old) {
+		if _, ok := cur[key]; !ok && (only == nil || only[key]) {
+			events = append(events, &WatchEvent{Kind: EventRemoved, Key: key, Row: old[key]})
+		}
+	}
+
+	return events
+}
+
+// sameRow reports whether two rows hold the same columns and values.
+func sameRow(a, b Row) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(ja) == string(jb)
+}
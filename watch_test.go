@@ -0,0 +1,105 @@
+package stratumclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	var mu sync.Mutex
+	hosts := map[string]string{"1": "db1", "2": "web1"}
+	var history []map[string]interface{}
+	var queries []string
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		q, _ := ParseQuery(strings.TrimPrefix(strings.TrimLeft(r.URL.Path, "/"), "stratum/v1/") + "?" + r.URL.RawQuery)
+		queries = append(queries, q.Table)
+		if q.Table == "host_history" {
+			var rows []map[string]interface{}
+			for _, ch := range history {
+				if cond, ok := q.Where.(*Cond); !ok || ch["changed_at"].(string) >= cond.Value {
+					rows = append(rows, ch)
+				}
+			}
+			if q.Get("limit") == "1" && len(rows) > 0 {
+				rows = rows[len(rows)-1:]
+			}
+			writeJSON(w, http.StatusOK, rows)
+			return
+		}
+		want := make(map[string]bool)
+		for _, cond := range Conds(q.Where) {
+			if cond.Column == "id" {
+				want[cond.Value] = true
+			}
+		}
+		var rows []map[string]interface{}
+		for _, id := range sortedKeys(hosts) {
+			if len(want) == 0 || want[id] {
+				rows = append(rows, map[string]interface{}{"id": id, "name": hosts[id]})
+			}
+		}
+		writeJSON(w, http.StatusOK, rows)
+	})
+	change := func(at, id, name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if name == "" {
+			delete(hosts, id)
+		} else {
+			hosts[id] = name
+		}
+		history = append(history, map[string]interface{}{"row_id": id, "changed_at": at})
+	}
+	next := func(events <-chan *WatchEvent) *WatchEvent {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.Err != nil {
+				t.Fatalf("watch: %v", e.Err)
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+		return nil
+	}
+
+	for _, useHistory := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+		w := &Watcher{Client: cl, Query: "host/", Interval: 5 * time.Millisecond, UseHistory: useHistory}
+		events := w.Events(ctx)
+		if e := next(events); e.Kind != EventAdded || e.Key != "1" || e.Row.String("name") != "db1" {
+			t.Errorf("initial: got %v %s %v", e.Kind, e.Key, e.Row)
+		}
+		next(events)
+
+		change("2026-01-01T00:00:01Z", "2", "web2")
+		if e := next(events); e.Kind != EventUpdated || e.Key != "2" || e.Old.String("name") != "web1" || e.Row.String("name") != "web2" {
+			t.Errorf("update: got %v %s %v", e.Kind, e.Key, e.Row)
+		}
+		change("2026-01-01T00:00:02Z", "1", "")
+		change("2026-01-01T00:00:02Z", "3", "mail1")
+		e1, e2 := next(events), next(events)
+		if e1.Kind != EventAdded || e1.Key != "3" || e2.Kind != EventRemoved || e2.Key != "1" || e2.Row.String("name") != "db1" {
+			t.Errorf("add and remove: got %v %s, %v %s", e1.Kind, e1.Key, e2.Kind, e2.Key)
+		}
+		cancel()
+		for range events {
+		}
+
+		mu.Lock()
+		hosts = map[string]string{"1": "db1", "2": "web1"}
+		history = nil
+		if useHistory && strings.Count(strings.Join(queries, ","), "host_history") < 2 {
+			t.Errorf("history not polled: %q", queries)
+		}
+		queries = nil
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,60 @@
+package stratumclient
+
+import (
+	"time"
+)
+
+// Weight annotates the expected cost of a query, selecting the
+// timeout and retry profile used for the call.
+type Weight int
+
+// Query weights.
+const (
+	WeightDefault Weight = iota
+	WeightLight
+	WeightHeavy
+)
+
+// Profile holds the timeout (in seconds) and retry policy for calls
+// of a given weight. Zero valued fields fall back to the client's
+// Timeout and Retry.
+type Profile struct {
+	Timeout int          `yaml:"timeout" json:"timeout"`
+	Retry   *RetryPolicy `yaml:"retry" json:"retry"`
+}
+
+// WithWeight annotates the call with the expected query weight, so
+// quick lookups and heavy aggregate exports can use different
+// timeouts and retry policies on the same client.
+func WithWeight(w Weight) CallOption {
+	return func(o *callOptions) {
+		o.weight = w
+	}
+}
+
+// profile returns the timeout and retry policy for a call of the
+// given weight.
+func (c *Client) profile(w Weight) (time.Duration, *RetryPolicy) {
+	timeout, policy := c.Timeout, c.Retry
+
+	var p *Profile
+	switch w {
+	case WeightLight:
+		p = c.LightProfile
+	case WeightHeavy:
+		p = c.HeavyProfile
+	}
+	if p != nil {
+		if p.Timeout > 0 {
+			timeout = p.Timeout
+		}
+		if p.Retry != nil {
+			policy = p.Retry
+		}
+	}
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	return time.Duration(timeout) * time.Second, policy
+}
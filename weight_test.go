@@ -0,0 +1,56 @@
+package stratumclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWeightProfiles(t *testing.T) {
+	cl, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+	cl.Timeout = 1
+	cl.HeavyProfile = &Profile{Timeout: 5}
+	cl.LightProfile = &Profile{Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}}
+
+	// Record the time left of each request's deadline instead of
+	// waiting for the timeouts to expire.
+	var left time.Duration
+	next := cl.client.Transport
+	cl.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if deadline, ok := req.Context().Deadline(); ok {
+			left = time.Until(deadline)
+		}
+		return next.RoundTrip(req)
+	})
+
+	if err := cl.Get("slow/", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if left <= 0 || left > time.Second {
+		t.Fatalf("default deadline: got %v, want at most 1s", left)
+	}
+	if err := cl.Get("slow/", nil, WithWeight(WeightHeavy)); err != nil {
+		t.Fatalf("get heavy: %v", err)
+	}
+	if left <= 4*time.Second || left > 5*time.Second {
+		t.Fatalf("heavy deadline: got %v, want about 5s", left)
+	}
+
+	timeout, policy := cl.profile(WeightLight)
+	if timeout != time.Second || policy.MaxAttempts != 2 {
+		t.Fatalf("light profile: got %v %+v", timeout, policy)
+	}
+	if _, policy := cl.profile(WeightDefault); policy != nil {
+		t.Fatalf("default profile: got retry policy %+v", policy)
+	}
+}